@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"errors"
+	"server/db"
+	"server/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CollectionControl interface {
+	GetCollections(viewer models.Viewer) ([]models.Collection, error)
+	GetCollection(collectionID string, viewer models.Viewer) (models.Collection, error)
+	CreateCollection(collection models.Collection, viewer models.Viewer) (models.Collection, error)
+	UpdateCollection(collectionID string, collection models.Collection, viewer models.Viewer) (models.Collection, error)
+	DeleteCollection(collectionID string, viewer models.Viewer) error
+	AddRecipe(collectionID string, recipeID string, viewer models.Viewer) (models.Collection, error)
+	RemoveRecipe(collectionID string, recipeID string, viewer models.Viewer) (models.Collection, error)
+	RemoveRecipeFromCollections(recipeID string) error
+	GetCollectionRecipes(collectionID string, paginatedRequest models.PaginatedRecipeRequest) (models.PaginatedRecipeResponse, error)
+}
+
+var (
+	// ErrCollectionNotFound is also returned for collections the viewer isn't allowed to see
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrCollectionName     = errors.New("collection needs a name")
+	// ErrNotCollectionOwner is returned when a household member tries to rename or delete a shared collection
+	ErrNotCollectionOwner = errors.New("only the owner can change the collection")
+)
+
+type CollectionController struct {
+	collectionRepo db.CollectionDB
+	rc             RecipeControl
+}
+
+func NewCollectionController(cr db.CollectionDB, rc RecipeControl) CollectionController {
+	return CollectionController{collectionRepo: cr, rc: rc}
+}
+
+// GetCollections - lists the viewer's collections along with the ones their household shared with them
+func (cc CollectionController) GetCollections(viewer models.Viewer) ([]models.Collection, error) {
+	return cc.collectionRepo.GetCollections(viewer.UserName, householdMembersBesides(viewer))
+}
+
+// GetCollection - gets a collection the viewer owns or that was shared with their household
+func (cc CollectionController) GetCollection(collectionID string, viewer models.Viewer) (models.Collection, error) {
+	if !primitive.IsValidObjectID(collectionID) {
+		return models.Collection{}, ErrCollectionNotFound
+	}
+	collection, err := cc.collectionRepo.GetCollection(collectionID)
+	if err != nil || !canViewCollection(collection, viewer) {
+		return models.Collection{}, ErrCollectionNotFound
+	}
+	return collection, nil
+}
+
+// CreateCollection - creates an empty collection owned by the viewer
+func (cc CollectionController) CreateCollection(collection models.Collection, viewer models.Viewer) (models.Collection, error) {
+	currentTime := time.Now().Format("2006.01.02 15:04:05")
+	collection.Name = strings.TrimSpace(collection.Name)
+	if collection.Name == "" {
+		return models.Collection{}, ErrCollectionName
+	}
+	collection.CollectionID = primitive.NilObjectID
+	collection.UserName = viewer.UserName
+	collection.RecipeIDs = []primitive.ObjectID{}
+	collection.CreatedDate = currentTime
+	collection.LastUpdatedDate = currentTime
+	err := cc.collectionRepo.CreateCollection(&collection)
+	if err != nil {
+		return models.Collection{}, err
+	}
+	return collection, nil
+}
+
+// UpdateCollection - renames the viewer's collection and changes whether it is shared, its recipes are
+// changed one at a time with AddRecipe and RemoveRecipe
+func (cc CollectionController) UpdateCollection(collectionID string, collection models.Collection, viewer models.Viewer) (models.Collection, error) {
+	name := strings.TrimSpace(collection.Name)
+	if name == "" {
+		return models.Collection{}, ErrCollectionName
+	}
+	if _, err := cc.ownedCollection(collectionID, viewer); err != nil {
+		return models.Collection{}, err
+	}
+	return cc.collectionRepo.UpdateCollection(collectionID, name, collection.Shared, time.Now().Format("2006.01.02 15:04:05"))
+}
+
+// DeleteCollection - deletes the viewer's collection, the recipes in it are left alone
+func (cc CollectionController) DeleteCollection(collectionID string, viewer models.Viewer) error {
+	if _, err := cc.ownedCollection(collectionID, viewer); err != nil {
+		return err
+	}
+	return cc.collectionRepo.DeleteCollection(collectionID)
+}
+
+// AddRecipe - saves a recipe the viewer can see to a collection they can see
+func (cc CollectionController) AddRecipe(collectionID string, recipeID string, viewer models.Viewer) (models.Collection, error) {
+	if _, err := cc.GetCollection(collectionID, viewer); err != nil {
+		return models.Collection{}, err
+	}
+	recipe, err := cc.rc.GetRecipe(recipeID, viewer)
+	if err != nil {
+		return models.Collection{}, ErrRecipeNotFound
+	}
+	return cc.collectionRepo.AddRecipeToCollection(collectionID, recipe.RecipeID, time.Now().Format("2006.01.02 15:04:05"))
+}
+
+// RemoveRecipe - takes a recipe out of a collection the viewer can see
+func (cc CollectionController) RemoveRecipe(collectionID string, recipeID string, viewer models.Viewer) (models.Collection, error) {
+	if _, err := cc.GetCollection(collectionID, viewer); err != nil {
+		return models.Collection{}, err
+	}
+	id, err := primitive.ObjectIDFromHex(recipeID)
+	if err != nil {
+		return models.Collection{}, ErrRecipeNotFound
+	}
+	return cc.collectionRepo.RemoveRecipeFromCollection(collectionID, id, time.Now().Format("2006.01.02 15:04:05"))
+}
+
+// RemoveRecipeFromCollections - takes a deleted recipe out of every collection
+func (cc CollectionController) RemoveRecipeFromCollections(recipeID string) error {
+	return cc.collectionRepo.RemoveRecipeFromCollections(recipeID)
+}
+
+// GetCollectionRecipes - pages through the recipes of a collection the same way as PostPaginatedRecipes,
+// leaving out any the viewer isn't allowed to see
+func (cc CollectionController) GetCollectionRecipes(collectionID string, paginatedRequest models.PaginatedRecipeRequest) (models.PaginatedRecipeResponse, error) {
+	collection, err := cc.GetCollection(collectionID, paginatedRequest.Viewer)
+	if err != nil {
+		return models.PaginatedRecipeResponse{}, err
+	}
+	paginatedRequest.Filter.RecipeIDs = append([]primitive.ObjectID{}, collection.RecipeIDs...)
+	return cc.rc.PostPaginatedRecipes(paginatedRequest)
+}
+
+func (cc CollectionController) ownedCollection(collectionID string, viewer models.Viewer) (models.Collection, error) {
+	collection, err := cc.GetCollection(collectionID, viewer)
+	if err != nil {
+		return models.Collection{}, err
+	}
+	if collection.UserName != viewer.UserName {
+		return models.Collection{}, ErrNotCollectionOwner
+	}
+	return collection, nil
+}
+
+func canViewCollection(collection models.Collection, viewer models.Viewer) bool {
+	if viewer.UserName == "" {
+		return false
+	}
+	return collection.UserName == viewer.UserName || (collection.Shared && containsString(viewer.HouseholdMembers, collection.UserName))
+}
+
+// householdMembersBesides lists the rest of the viewer's household
+func householdMembersBesides(viewer models.Viewer) []string {
+	members := []string{}
+	for _, member := range viewer.HouseholdMembers {
+		if member != viewer.UserName {
+			members = append(members, member)
+		}
+	}
+	return members
+}
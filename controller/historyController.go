@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"server/db"
+	"server/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type HistoryControl interface {
+	MarkCooked(householdID string, calendarID string, day string, entry models.CookedEntry, userName string) (models.CookedEntry, error)
+	LogCooked(householdID string, entry models.CookedEntry, viewer models.Viewer) (models.CookedEntry, error)
+	GetHistory(householdID string, query models.CookedHistoryQuery) ([]models.CookedEntry, error)
+}
+
+var (
+	ErrNoHousehold        = errors.New("user is not in a household")
+	ErrUnknownDay         = errors.New("unknown calendar day")
+	ErrNothingPlanned     = errors.New("nothing is planned on that day")
+	ErrInvalidCookedEntry = errors.New("invalid cooked entry")
+)
+
+// calendarDays are the days of a calendar in the order they come after its StartDate
+var calendarDays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+type HistoryController struct {
+	historyRepo  db.HistoryDB
+	calendarRepo db.CalendarDB
+	rc           RecipeControl
+}
+
+func NewHistoryController(hr db.HistoryDB, cr db.CalendarDB, rc RecipeControl) HistoryController {
+	return HistoryController{historyRepo: hr, calendarRepo: cr, rc: rc}
+}
+
+// MarkCooked - records that the household cooked the recipe planned on a day of one of its calendars. The
+// entry is dated with the calendar's day unless it has a cookedDate, and marking a day again replaces its entry.
+func (hc HistoryController) MarkCooked(householdID string, calendarID string, day string, entry models.CookedEntry, userName string) (models.CookedEntry, error) {
+	if !primitive.IsValidObjectID(householdID) {
+		return models.CookedEntry{}, ErrNoHousehold
+	}
+	calendar, err := hc.calendarRepo.GetCalendarByID(calendarID)
+	if err != nil || calendar.HouseholdID.Hex() != householdID {
+		return models.CookedEntry{}, ErrCalendarNotFound
+	}
+	day = strings.ToLower(day)
+	recipe, ok := calendarDay(calendar, day)
+	if !ok {
+		return models.CookedEntry{}, ErrUnknownDay
+	}
+	if recipe.RecipeID.IsZero() && recipe.RecipeName == "" {
+		return models.CookedEntry{}, ErrNothingPlanned
+	}
+	if entry.CookedDate == "" {
+		entry.CookedDate = calendarDate(calendar, day)
+	}
+	entry.EntryID = primitive.NilObjectID
+	entry.CalendarID = calendar.CalendarID
+	entry.Day = day
+	entry.RecipeID = recipe.RecipeID
+	entry.RecipeName = recipe.RecipeName
+	if err := prepareCookedEntry(&entry, calendar.HouseholdID, userName); err != nil {
+		return models.CookedEntry{}, err
+	}
+	if err := hc.historyRepo.SaveCalendarCookedEntry(&entry); err != nil {
+		return models.CookedEntry{}, err
+	}
+	return entry, nil
+}
+
+// LogCooked - records that the household cooked a recipe the viewer can see without it being on a calendar
+func (hc HistoryController) LogCooked(householdID string, entry models.CookedEntry, viewer models.Viewer) (models.CookedEntry, error) {
+	if !primitive.IsValidObjectID(householdID) {
+		return models.CookedEntry{}, ErrNoHousehold
+	}
+	recipe, err := hc.rc.GetRecipe(entry.RecipeID.Hex(), viewer)
+	if err != nil {
+		return models.CookedEntry{}, ErrRecipeNotFound
+	}
+	household, _ := primitive.ObjectIDFromHex(householdID)
+	entry.EntryID = primitive.NilObjectID
+	entry.CalendarID = primitive.NilObjectID
+	entry.Day = ""
+	entry.RecipeName = recipe.RecipeName
+	if err := prepareCookedEntry(&entry, household, viewer.UserName); err != nil {
+		return models.CookedEntry{}, err
+	}
+	if err := hc.historyRepo.CreateCookedEntry(&entry); err != nil {
+		return models.CookedEntry{}, err
+	}
+	return entry, nil
+}
+
+// GetHistory - lists what the household cooked, the most recent first. Asking for one recipe with a limit
+// of 1 answers when it was last made.
+func (hc HistoryController) GetHistory(householdID string, query models.CookedHistoryQuery) ([]models.CookedEntry, error) {
+	if !primitive.IsValidObjectID(householdID) {
+		return []models.CookedEntry{}, ErrNoHousehold
+	}
+	var invalidFields []string
+	from, fromOk := normalizeDay(query.From)
+	to, toOk := normalizeDay(query.To)
+	if !fromOk {
+		invalidFields = append(invalidFields, "from")
+	}
+	if !toOk {
+		invalidFields = append(invalidFields, "to")
+	}
+	if query.RecipeID != "" && !primitive.IsValidObjectID(query.RecipeID) {
+		invalidFields = append(invalidFields, "recipeID")
+	}
+	if query.Limit < 0 {
+		invalidFields = append(invalidFields, "limit")
+	}
+	if len(invalidFields) > 0 {
+		return []models.CookedEntry{}, fmt.Errorf("%w: %s", ErrInvalidFilter, strings.Join(invalidFields, ", "))
+	}
+	query.From = from
+	query.To = to
+	return hc.historyRepo.GetCookedEntries(householdID, query)
+}
+
+// prepareCookedEntry fills in who cooked and when the entry was made, after checking its date and rating
+func prepareCookedEntry(entry *models.CookedEntry, householdID primitive.ObjectID, userName string) error {
+	var invalidFields []string
+	cookedDate, ok := normalizeDay(entry.CookedDate)
+	if !ok {
+		invalidFields = append(invalidFields, "cookedDate")
+	}
+	if entry.Rating != 0 && (entry.Rating < models.MinRating || entry.Rating > models.MaxRating) {
+		invalidFields = append(invalidFields, "rating")
+	}
+	if len(invalidFields) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidCookedEntry, strings.Join(invalidFields, ", "))
+	}
+	currentTime := time.Now()
+	if cookedDate == "" {
+		cookedDate = currentTime.Format("2006.01.02")
+	}
+	entry.HouseholdID = householdID
+	entry.CookedDate = cookedDate
+	entry.UserName = userName
+	entry.Notes = strings.TrimSpace(entry.Notes)
+	entry.CreatedDate = currentTime.Format("2006.01.02 15:04:05")
+	return nil
+}
+
+// normalizeDay reads a date the same ways as a recipe filter does, keeping only the day
+func normalizeDay(date string) (string, bool) {
+	normalized, ok := normalizeDate(date)
+	if !ok || len(normalized) < len("2006.01.02") {
+		return normalized, ok
+	}
+	return normalized[:len("2006.01.02")], true
+}
+
+// calendarDay gets the recipe planned on a day of the calendar by the day's name
+func calendarDay(calendar models.Calendar, day string) (models.PlannedRecipe, bool) {
+	for i, planned := range plannedDays(&calendar) {
+		if calendarDays[i] == day {
+			return *planned, true
+		}
+	}
+	return models.PlannedRecipe{}, false
+}
+
+// plannedDays points at the days of a calendar in the order of calendarDays
+func plannedDays(calendar *models.Calendar) []*models.PlannedRecipe {
+	return []*models.PlannedRecipe{&calendar.Sunday, &calendar.Monday, &calendar.Tuesday, &calendar.Wednesday,
+		&calendar.Thursday, &calendar.Friday, &calendar.Saturday}
+}
+
+// calendarDate works out the date of a day of the calendar from its StartDate, or is empty when
+// the calendar's StartDate isn't a date
+func calendarDate(calendar models.Calendar, day string) string {
+	startDate, ok := normalizeDay(calendar.StartDate)
+	start, err := time.Parse("2006.01.02", startDate)
+	if !ok || err != nil {
+		return ""
+	}
+	for offset, name := range calendarDays {
+		if name == day {
+			return start.AddDate(0, 0, offset).Format("2006.01.02")
+		}
+	}
+	return ""
+}
@@ -2,9 +2,13 @@ package controller
 
 import (
 	"errors"
+	"fmt"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"server/db"
 	"server/models"
+	"strings"
+	"time"
 )
 
 type HouseholdControl interface {
@@ -12,19 +16,37 @@ type HouseholdControl interface {
 	GetHousehold(householdID string) (models.Household, error)
 	AddUserToHousehold(householdID string, username string, ur db.UserGetterUpdater) (models.User, error)
 	DeleteHousehold(householdID string) error
-	GetCalendar(householdID string, startDate string) (models.Calendar, error)
+	GetCalendar(householdID string, startDate string, viewer models.Viewer, expand bool) (models.Calendar, error)
 	UpdateCalendar(householdID string, calendar models.Calendar) (models.Calendar, error)
-	CreateCalendar(startDate string, householdID string) (models.Calendar, error)
+	CreateCalendar(request models.CalendarRequest, householdID string, viewer models.Viewer) (models.Calendar, error)
+	GetShoppingList(householdID string, calendarID string, viewer models.Viewer) (models.ShoppingList, error)
+	GetCalendars(householdID string, query models.CalendarQuery, viewer models.Viewer, expand bool) (models.CalendarPage, error)
+	DeleteCalendar(householdID string, calendarID string) error
+	CopyCalendar(householdID string, calendarID string, startDate string) (models.Calendar, error)
+	SetCalendarDay(householdID string, calendarID string, day string, recipeID string, viewer models.Viewer) (models.Calendar, error)
+	RerollCalendarDay(householdID string, calendarID string, day string, request models.RandomRecipeRequest) (models.Calendar, error)
+	SwapCalendarDays(householdID string, calendarID string, day string, otherDay string) (models.Calendar, error)
+	ClearCalendarDay(householdID string, calendarID string, day string) (models.Calendar, error)
 }
 
+var (
+	ErrCalendarNotFound = errors.New("calendar not found")
+	ErrCalendarExists   = errors.New("calendar already exists")
+	ErrInvalidStartDate = errors.New("invalid start date")
+	ErrNoRerollRecipe   = errors.New("no other recipe to plan")
+	// ErrSlotsNotSynced means the calendar was saved but the meal slots of its week still show it as it was
+	ErrSlotsNotSynced = errors.New("calendar saved but its meal slots could not be updated")
+)
+
 type HouseholdController struct {
 	calendarRepo  db.CalendarDB
 	householdRepo db.HouseholdDB
+	mealRepo      db.MealUpdater
 	rc            RecipeControl
 }
 
-func NewHouseholdController(cr db.CalendarDB, hr db.HouseholdDB, rc RecipeControl) HouseholdController {
-	return HouseholdController{calendarRepo: cr, householdRepo: hr, rc: rc}
+func NewHouseholdController(cr db.CalendarDB, hr db.HouseholdDB, mr db.MealUpdater, rc RecipeControl) HouseholdController {
+	return HouseholdController{calendarRepo: cr, householdRepo: hr, mealRepo: mr, rc: rc}
 }
 
 //CreateHousehold creates a new household
@@ -53,33 +75,459 @@ func (hc HouseholdController) DeleteHousehold(householdID string) error {
 	return hc.householdRepo.DeleteHousehold(householdID)
 }
 
-func (hc HouseholdController) GetCalendar(householdID string, startDate string) (models.Calendar, error) {
-	return hc.calendarRepo.GetCalendar(householdID, startDate)
+// GetCalendar - gets the household's calendar for a week, expanding it fills in the recipes it refers to
+func (hc HouseholdController) GetCalendar(householdID string, startDate string, viewer models.Viewer, expand bool) (models.Calendar, error) {
+	calendar, err := hc.calendarRepo.GetCalendar(householdID, startDate)
+	if err != nil || !expand {
+		return calendar, err
+	}
+	return calendar, hc.expandRecipes(&calendar, viewer)
+}
+
+// GetCalendars - lists a page of the household's calendars starting between two days, the earliest first
+func (hc HouseholdController) GetCalendars(householdID string, query models.CalendarQuery, viewer models.Viewer, expand bool) (models.CalendarPage, error) {
+	if !primitive.IsValidObjectID(householdID) {
+		return models.CalendarPage{}, ErrNoHousehold
+	}
+	var invalidFields []string
+	from, fromOk := normalizeDay(query.From)
+	to, toOk := normalizeDay(query.To)
+	if !fromOk {
+		invalidFields = append(invalidFields, "from")
+	}
+	if !toOk {
+		invalidFields = append(invalidFields, "to")
+	}
+	if query.PageSize < 0 {
+		invalidFields = append(invalidFields, "pageSize")
+	}
+	if query.PageCount < 0 {
+		invalidFields = append(invalidFields, "pageCount")
+	}
+	if len(invalidFields) > 0 {
+		return models.CalendarPage{}, fmt.Errorf("%w: %s", ErrInvalidFilter, strings.Join(invalidFields, ", "))
+	}
+	query.From, query.To = from, to
+
+	calendars, err := hc.calendarRepo.GetCalendars(householdID, query)
+	if err != nil {
+		return models.CalendarPage{}, err
+	}
+	numberOfCalendars, err := hc.calendarRepo.CountCalendars(householdID, query)
+	if err != nil {
+		return models.CalendarPage{}, err
+	}
+	if expand {
+		for i := range calendars {
+			if err := hc.expandRecipes(&calendars[i], viewer); err != nil {
+				return models.CalendarPage{}, err
+			}
+		}
+	}
+	return models.CalendarPage{
+		Calendars:         calendars,
+		NumberOfCalendars: numberOfCalendars,
+		PageCount:         query.PageCount,
+		PageSize:          query.PageSize,
+	}, nil
+}
+
+// DeleteCalendar - deletes one of the household's calendars
+func (hc HouseholdController) DeleteCalendar(householdID string, calendarID string) error {
+	if !primitive.IsValidObjectID(householdID) {
+		return ErrNoHousehold
+	}
+	if !primitive.IsValidObjectID(calendarID) {
+		return ErrCalendarNotFound
+	}
+	err := hc.calendarRepo.DeleteCalendar(householdID, calendarID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrCalendarNotFound
+	}
+	if err != nil {
+		return err
+	}
+	id, _ := primitive.ObjectIDFromHex(calendarID)
+	return hc.syncMealSlots(id, nil)
+}
+
+// CopyCalendar - plans the recipes of one of the household's calendars again for the week starting on startDate,
+// or for the week after it when no startDate is given. The household can't already have a calendar for that week.
+func (hc HouseholdController) CopyCalendar(householdID string, calendarID string, startDate string) (models.Calendar, error) {
+	if !primitive.IsValidObjectID(householdID) {
+		return models.Calendar{}, ErrNoHousehold
+	}
+	calendar, err := hc.calendarRepo.GetCalendarByID(calendarID)
+	if err != nil || calendar.HouseholdID.Hex() != householdID {
+		return models.Calendar{}, ErrCalendarNotFound
+	}
+	if startDate == "" {
+		startDate = calendarDate(calendar, calendarDays[0])
+		if startDate != "" {
+			startDate = recentWeeksStart(startDate, -1)
+		}
+	}
+	startDate, ok := normalizeDay(startDate)
+	if _, err := time.Parse("2006.01.02", startDate); !ok || err != nil {
+		return models.Calendar{}, ErrInvalidStartDate
+	}
+	if _, err := hc.calendarRepo.GetCalendar(householdID, startDate); err == nil {
+		return models.Calendar{}, fmt.Errorf("%w: %s", ErrCalendarExists, startDate)
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Calendar{}, err
+	}
+
+	copied := models.Calendar{HouseholdID: calendar.HouseholdID, StartDate: startDate, Version: 1}
+	var recipes []models.Recipe
+	copiedDays := plannedDays(&copied)
+	for i, planned := range plannedDays(&calendar) {
+		*copiedDays[i] = models.PlannedRecipe{RecipeID: planned.RecipeID, RecipeName: planned.RecipeName}
+		recipes = append(recipes, models.Recipe{RecipeID: planned.RecipeID})
+	}
+	createdCalendar, err := hc.calendarRepo.CreateCalendar(copied)
+	if mongo.IsDuplicateKeyError(err) {
+		return models.Calendar{}, fmt.Errorf("%w: %s", ErrCalendarExists, startDate)
+	}
+	if err != nil {
+		return models.Calendar{}, err
+	}
+	if popularityErr := hc.rc.RecordPlannedRecipes(recipes); popularityErr != nil {
+		fmt.Println("Could not update recipe popularity")
+		fmt.Println(popularityErr)
+	}
+	return createdCalendar, hc.syncMealSlots(createdCalendar.CalendarID, calendarSlots(createdCalendar))
+}
+
+// SetCalendarDay - plans a recipe the viewer can see on a day of one of the household's calendars
+func (hc HouseholdController) SetCalendarDay(householdID string, calendarID string, day string, recipeID string, viewer models.Viewer) (models.Calendar, error) {
+	day, err := checkCalendarDay(householdID, calendarID, day)
+	if err != nil {
+		return models.Calendar{}, err
+	}
+	recipe, err := hc.rc.GetRecipe(recipeID, viewer)
+	if err != nil {
+		return models.Calendar{}, ErrRecipeNotFound
+	}
+	return hc.planCalendarDay(householdID, calendarID, day, recipe)
+}
+
+// RerollCalendarDay - plans another random recipe on a day of one of the household's calendars. The recipe is
+// picked with the tags and filter of the request and the household's restrictions, and isn't one already planned
+// that week unless nothing else is left.
+func (hc HouseholdController) RerollCalendarDay(householdID string, calendarID string, day string, request models.RandomRecipeRequest) (models.Calendar, error) {
+	day, err := checkCalendarDay(householdID, calendarID, day)
+	if err != nil {
+		return models.Calendar{}, err
+	}
+	calendar, err := hc.householdCalendar(householdID, calendarID)
+	if err != nil {
+		return models.Calendar{}, err
+	}
+	request.NumberOfRecipes = 1
+	applyRestrictions(&request.Filter, request.Viewer.HouseholdRestrictions)
+	excluded := request.ExcludeRecipeIDs
+	for _, planned := range calendarRecipes(calendar) {
+		if !planned.RecipeID.IsZero() {
+			request.ExcludeRecipeIDs = append(request.ExcludeRecipeIDs, planned.RecipeID.Hex())
+		}
+	}
+	recipes, err := hc.rc.GetRandomRecipes(request)
+	if current, _ := calendarDay(calendar, day); err == nil && len(recipes) == 0 && !current.RecipeID.IsZero() {
+		request.ExcludeRecipeIDs = append(excluded, current.RecipeID.Hex())
+		recipes, err = hc.rc.GetRandomRecipes(request)
+	}
+	if err != nil {
+		return models.Calendar{}, err
+	}
+	if len(recipes) == 0 {
+		return models.Calendar{}, ErrNoRerollRecipe
+	}
+	return hc.planCalendarDay(householdID, calendarID, day, recipes[0])
+}
+
+// SwapCalendarDays - swaps what is planned on two days of one of the household's calendars
+func (hc HouseholdController) SwapCalendarDays(householdID string, calendarID string, day string, otherDay string) (models.Calendar, error) {
+	day, err := checkCalendarDay(householdID, calendarID, day)
+	if err != nil {
+		return models.Calendar{}, err
+	}
+	otherDay, err = checkCalendarDay(householdID, calendarID, otherDay)
+	if err != nil {
+		return models.Calendar{}, err
+	}
+	if day == otherDay {
+		return hc.householdCalendar(householdID, calendarID)
+	}
+	return hc.syncedCalendar(calendarDayUpdate(hc.calendarRepo.SwapCalendarDays(householdID, calendarID, day, otherDay)))
+}
+
+// ClearCalendarDay - removes what is planned on a day of one of the household's calendars
+func (hc HouseholdController) ClearCalendarDay(householdID string, calendarID string, day string) (models.Calendar, error) {
+	day, err := checkCalendarDay(householdID, calendarID, day)
+	if err != nil {
+		return models.Calendar{}, err
+	}
+	return hc.syncedCalendar(calendarDayUpdate(hc.calendarRepo.ClearCalendarDay(householdID, calendarID, day)))
+}
+
+// planCalendarDay plans the recipe on the day and counts it towards the recipe's popularity, the recipe comes back
+// expanded on the updated calendar
+func (hc HouseholdController) planCalendarDay(householdID string, calendarID string, day string, recipe models.Recipe) (models.Calendar, error) {
+	planned := models.PlannedRecipe{RecipeID: recipe.RecipeID, RecipeName: recipe.RecipeName}
+	calendar, err := hc.syncedCalendar(calendarDayUpdate(hc.calendarRepo.SetCalendarDay(householdID, calendarID, day, planned)))
+	if err != nil {
+		return models.Calendar{}, err
+	}
+	if popularityErr := hc.rc.RecordPlannedRecipes([]models.Recipe{recipe}); popularityErr != nil {
+		fmt.Println("Could not update recipe popularity")
+		fmt.Println(popularityErr)
+	}
+	setCalendarDay(&calendar, day, recipe)
+	return calendar, nil
 }
 
+// householdCalendar gets one of the household's calendars
+func (hc HouseholdController) householdCalendar(householdID string, calendarID string) (models.Calendar, error) {
+	calendar, err := hc.calendarRepo.GetCalendarByID(calendarID)
+	if err != nil || calendar.HouseholdID.Hex() != householdID {
+		return models.Calendar{}, ErrCalendarNotFound
+	}
+	return calendar, nil
+}
+
+// checkCalendarDay checks the household and calendar a day is changed on and normalizes the day's name
+func checkCalendarDay(householdID string, calendarID string, day string) (string, error) {
+	if !primitive.IsValidObjectID(householdID) {
+		return "", ErrNoHousehold
+	}
+	if !primitive.IsValidObjectID(calendarID) {
+		return "", ErrCalendarNotFound
+	}
+	day = strings.ToLower(strings.TrimSpace(day))
+	if !containsString(calendarDays, day) {
+		return "", fmt.Errorf("%w: %s", ErrUnknownDay, day)
+	}
+	return day, nil
+}
+
+// calendarDayUpdate turns a calendar the household doesn't have into ErrCalendarNotFound
+func calendarDayUpdate(calendar models.Calendar, err error) (models.Calendar, error) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Calendar{}, ErrCalendarNotFound
+	}
+	return calendar, err
+}
+
+// syncedCalendar copies a calendar that changed onto its dinner slots
+func (hc HouseholdController) syncedCalendar(calendar models.Calendar, err error) (models.Calendar, error) {
+	if err != nil {
+		return calendar, err
+	}
+	return calendar, hc.syncMealSlots(calendar.CalendarID, calendarSlots(calendar))
+}
+
+// syncMealSlots makes the dinner slots copied from a weekly calendar match it after it changed. The calendar has
+// already been saved by then, so slots that can't be synced return ErrSlotsNotSynced, and are brought up to date
+// by the next change to the calendar.
+func (hc HouseholdController) syncMealSlots(calendarID primitive.ObjectID, slots []models.MealSlot) error {
+	if err := hc.mealRepo.SyncCalendarSlots(calendarID, slots); err != nil {
+		return fmt.Errorf("%w: %v", ErrSlotsNotSynced, err)
+	}
+	return nil
+}
+
+// UpdateCalendar - replaces a calendar made from its current version, on a db.ErrVersionConflict the current
+// calendar is returned with the error
 func (hc HouseholdController) UpdateCalendar(householdID string, calendar models.Calendar) (models.Calendar, error) {
+	if !primitive.IsValidObjectID(householdID) {
+		return models.Calendar{}, ErrNoHousehold
+	}
 	calendar.HouseholdID, _ = primitive.ObjectIDFromHex(householdID)
 	updatedCalendar, err := hc.calendarRepo.UpdateCalendar(calendar)
-	return updatedCalendar, err
+	if errors.Is(err, db.ErrVersionConflict) {
+		currentCalendar, getErr := hc.calendarRepo.GetCalendarByID(calendar.CalendarID.Hex())
+		if getErr == nil && currentCalendar.HouseholdID == calendar.HouseholdID {
+			return currentCalendar, err
+		}
+		return models.Calendar{}, ErrCalendarNotFound
+	}
+	return hc.syncedCalendar(updatedCalendar, err)
 }
 
-func (hc HouseholdController) CreateCalendar(startDate string, householdID string) (models.Calendar, error) {
-	recipes, err := hc.rc.GetRandomRecipes(7)
+// CreateCalendar - plans a week of recipes that the viewer is allowed to see, that suit the dietary restrictions
+// of everyone in the household and that meet the request's constraints as far as they can be met. The calendar
+// comes back with its recipes expanded and reports the constraints it doesn't meet.
+func (hc HouseholdController) CreateCalendar(request models.CalendarRequest, householdID string, viewer models.Viewer) (models.Calendar, error) {
+	if !primitive.IsValidObjectID(householdID) {
+		return models.Calendar{}, ErrNoHousehold
+	}
+	constraints := request.Constraints
+	invalidFields := invalidPlanFields(request)
+	pinned := map[string]models.Recipe{}
+	if len(invalidFields) == 0 {
+		for _, day := range calendarDays {
+			recipeID, ok := constraints.PinnedDays[day]
+			if !ok {
+				continue
+			}
+			recipe, err := hc.rc.GetRecipe(recipeID, viewer)
+			if err != nil {
+				invalidFields = append(invalidFields, "constraints.pinnedDays."+day)
+				continue
+			}
+			pinned[day] = recipe
+		}
+	}
+	if len(invalidFields) > 0 {
+		return models.Calendar{}, fmt.Errorf("%w: %s", ErrInvalidPlan, strings.Join(invalidFields, ", "))
+	}
+
+	recent, err := hc.recentRecipeIDs(householdID, request.StartDate, constraints.NoRepeatWeeks)
 	if err != nil {
+		return models.Calendar{}, err
+	}
+	candidates, err := hc.planCandidates(pinned, recent, viewer)
+	if err != nil || len(candidates)+len(pinned) < len(calendarDays) {
 		return models.Calendar{}, errors.New("could not generate new calendar")
 	}
 
-	calendar := models.Calendar{
-		StartDate: startDate,
-		Sunday:    recipes[0],
-		Monday:    recipes[1],
-		Tuesday:   recipes[2],
-		Wednesday: recipes[3],
-		Thursday:  recipes[4],
-		Friday:    recipes[5],
-		Saturday:  recipes[6],
-	}
+	planned := planWeek(candidates, pinned, recent, constraints)
+	calendar := models.Calendar{StartDate: request.StartDate, Version: 1}
 	calendar.HouseholdID, _ = primitive.ObjectIDFromHex(householdID)
+	var recipes []models.Recipe
+	for _, day := range calendarDays {
+		setCalendarDay(&calendar, day, planned[day])
+		recipes = append(recipes, planned[day])
+	}
 
-	return hc.calendarRepo.CreateCalendar(calendar)
+	createdCalendar, err := hc.calendarRepo.CreateCalendar(calendar)
+	if mongo.IsDuplicateKeyError(err) {
+		return models.Calendar{}, fmt.Errorf("%w: %s", ErrCalendarExists, request.StartDate)
+	}
+	if err != nil {
+		return models.Calendar{}, err
+	}
+	if popularityErr := hc.rc.RecordPlannedRecipes(recipes); popularityErr != nil {
+		fmt.Println("Could not update recipe popularity")
+		fmt.Println(popularityErr)
+	}
+	createdCalendar.Unsatisfied = unsatisfiedConstraints(planned, recent, constraints)
+	return createdCalendar, hc.syncMealSlots(createdCalendar.CalendarID, calendarSlots(createdCalendar))
+}
+
+// planCandidates samples the recipes a week is planned from, leaving out pinned recipes and, while there
+// are enough others, recently planned ones
+func (hc HouseholdController) planCandidates(pinned map[string]models.Recipe, recent map[primitive.ObjectID]bool, viewer models.Viewer) ([]models.Recipe, error) {
+	request := models.RandomRecipeRequest{NumberOfRecipes: plannerPoolSize, Viewer: viewer}
+	applyRestrictions(&request.Filter, viewer.HouseholdRestrictions)
+	for _, recipe := range pinned {
+		request.ExcludeRecipeIDs = append(request.ExcludeRecipeIDs, recipe.RecipeID.Hex())
+	}
+	pinnedIDs := request.ExcludeRecipeIDs
+	for recipeID := range recent {
+		request.ExcludeRecipeIDs = append(request.ExcludeRecipeIDs, recipeID.Hex())
+	}
+	candidates, err := hc.rc.GetRandomRecipes(request)
+	if err != nil || len(candidates)+len(pinned) >= len(calendarDays) || len(recent) == 0 {
+		return candidates, err
+	}
+
+	request.ExcludeRecipeIDs = pinnedIDs
+	for _, recipe := range candidates {
+		request.ExcludeRecipeIDs = append(request.ExcludeRecipeIDs, recipe.RecipeID.Hex())
+	}
+	repeats, err := hc.rc.GetRandomRecipes(request)
+	return append(candidates, repeats...), err
+}
+
+// recentRecipeIDs gets the recipes planned on the household's calendars in the weeks before startDate
+func (hc HouseholdController) recentRecipeIDs(householdID string, startDate string, weeks int) (map[primitive.ObjectID]bool, error) {
+	recent := map[primitive.ObjectID]bool{}
+	if weeks < 1 {
+		return recent, nil
+	}
+	day, _ := normalizeDay(startDate)
+	calendars, err := hc.calendarRepo.GetCalendars(householdID, models.CalendarQuery{From: recentWeeksStart(startDate, weeks), To: day})
+	if err != nil {
+		return recent, err
+	}
+	for _, calendar := range calendars {
+		for _, recipe := range calendarRecipes(calendar) {
+			if !recipe.RecipeID.IsZero() {
+				recent[recipe.RecipeID] = true
+			}
+		}
+	}
+	return recent, nil
+}
+
+// GetShoppingList - builds the combined shopping list for every recipe on one of the household's calendars,
+// leaving out recipes that have been deleted or that the viewer can't see
+func (hc HouseholdController) GetShoppingList(householdID string, calendarID string, viewer models.Viewer) (models.ShoppingList, error) {
+	if !primitive.IsValidObjectID(householdID) {
+		return models.ShoppingList{}, ErrNoHousehold
+	}
+	calendar, err := hc.calendarRepo.GetCalendarByID(calendarID)
+	if err != nil || calendar.HouseholdID.Hex() != householdID {
+		return models.ShoppingList{}, ErrCalendarNotFound
+	}
+	if err := hc.expandRecipes(&calendar, viewer); err != nil {
+		return models.ShoppingList{}, err
+	}
+	var recipes []models.Recipe
+	for _, planned := range calendarRecipes(calendar) {
+		if planned.Recipe != nil {
+			recipes = append(recipes, *planned.Recipe)
+		}
+	}
+	return models.ShoppingList{
+		CalendarID: calendar.CalendarID,
+		StartDate:  calendar.StartDate,
+		Categories: buildShoppingList(recipes),
+	}, nil
+}
+
+// expandRecipes fills in the recipes the calendar's days refer to with one lookup, marking the ones that are
+// gone or hidden from the viewer as unavailable
+func (hc HouseholdController) expandRecipes(calendar *models.Calendar, viewer models.Viewer) error {
+	var recipeIDs []primitive.ObjectID
+	for _, planned := range calendarRecipes(*calendar) {
+		if !planned.RecipeID.IsZero() {
+			recipeIDs = append(recipeIDs, planned.RecipeID)
+		}
+	}
+	recipes, err := hc.rc.GetRecipesByID(recipeIDs, viewer)
+	if err != nil {
+		return err
+	}
+	found := map[primitive.ObjectID]models.Recipe{}
+	for _, recipe := range recipes {
+		found[recipe.RecipeID] = recipe
+	}
+	for _, planned := range plannedDays(calendar) {
+		if planned.RecipeID.IsZero() {
+			continue
+		}
+		recipe, ok := found[planned.RecipeID]
+		if !ok {
+			planned.Recipe = nil
+			planned.Unavailable = true
+			continue
+		}
+		planned.Recipe = &recipe
+		planned.RecipeName = recipe.RecipeName
+		planned.Unavailable = false
+	}
+	return nil
+}
+
+// calendarRecipes lists the recipes planned for each day of a calendar, skipping days with nothing planned
+func calendarRecipes(calendar models.Calendar) []models.PlannedRecipe {
+	var recipes []models.PlannedRecipe
+	for _, planned := range plannedDays(&calendar) {
+		if !planned.RecipeID.IsZero() || planned.RecipeName != "" {
+			recipes = append(recipes, *planned)
+		}
+	}
+	return recipes
 }
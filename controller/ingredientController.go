@@ -1,8 +1,11 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"server/db"
 	"server/models"
+	"strings"
 )
 
 type IngredientControl interface {
@@ -10,8 +13,12 @@ type IngredientControl interface {
 	DeleteIngredient(ingredientID string, repository db.IngredientDeleter) error
 	GetIngredient(ingredientID string, repository db.IngredientGetter) (models.Ingredient, error)
 	QueryIngredient(prefixIngredient string, repository db.IngredientGetter) ([]models.Ingredient, error)
+	UpdateNutrition(ingredientID string, nutrition models.NutritionFacts, repository db.IngredientUpdater) (models.Ingredient, error)
+	UpdateLabels(ingredientID string, labels models.DietaryLabels, repository db.IngredientUpdater) (models.Ingredient, error)
 }
 
+var ErrInvalidNutrition = errors.New("invalid nutrition")
+
 type IngredientController struct {
 }
 
@@ -21,6 +28,16 @@ func NewIngredientController() IngredientController {
 
 //CreateIngredient creates a new ingredient
 func (ic IngredientController) CreateIngredient(ingredient models.Ingredient, repository db.IngredientCreator) (models.Ingredient, error) {
+	if ingredient.Nutrition != nil {
+		if invalidFields := invalidNutritionFields(*ingredient.Nutrition); len(invalidFields) > 0 {
+			return models.Ingredient{}, fmt.Errorf("%w: %s", ErrInvalidNutrition, strings.Join(invalidFields, ", "))
+		}
+	}
+	if ingredient.Labels != nil {
+		if err := normalizeIngredientLabels(ingredient.Labels); err != nil {
+			return models.Ingredient{}, err
+		}
+	}
 	newIngredient, err := repository.CreateIngredient(ingredient)
 	if err != nil {
 		return models.Ingredient{}, err
@@ -42,3 +59,20 @@ func (ic IngredientController) GetIngredient(ingredientID string, repository db.
 func (ic IngredientController) QueryIngredient(prefixIngredient string, repository db.IngredientGetter) ([]models.Ingredient, error) {
 	return repository.QueryIngredients(prefixIngredient)
 }
+
+// UpdateNutrition - sets the nutrition facts recipes use for a catalog ingredient
+func (ic IngredientController) UpdateNutrition(ingredientID string, nutrition models.NutritionFacts, repository db.IngredientUpdater) (models.Ingredient, error) {
+	if invalidFields := invalidNutritionFields(nutrition); len(invalidFields) > 0 {
+		return models.Ingredient{}, fmt.Errorf("%w: %s", ErrInvalidNutrition, strings.Join(invalidFields, ", "))
+	}
+	return repository.UpdateIngredientNutrition(ingredientID, nutrition)
+}
+
+// UpdateLabels - sets the allergens and diets of a catalog ingredient, which recipes using it are labeled with when
+// they're next saved
+func (ic IngredientController) UpdateLabels(ingredientID string, labels models.DietaryLabels, repository db.IngredientUpdater) (models.Ingredient, error) {
+	if err := normalizeIngredientLabels(&labels); err != nil {
+		return models.Ingredient{}, err
+	}
+	return repository.UpdateIngredientLabels(ingredientID, labels)
+}
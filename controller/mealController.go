@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"server/db"
+	"server/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MealControl interface {
+	GetMealSlots(householdID string, query models.MealSlotQuery) ([]models.MealSlot, error)
+	SaveMealSlot(householdID string, date string, mealType string, slot models.MealSlot, viewer models.Viewer) (models.MealSlot, error)
+	DeleteMealSlot(householdID string, date string, mealType string) error
+	MigrateCalendars(householdID string) (models.CalendarMigration, error)
+}
+
+var (
+	ErrInvalidMealSlot  = errors.New("invalid meal slot")
+	ErrMealSlotNotFound = errors.New("meal slot not found")
+)
+
+type MealController struct {
+	mealRepo     db.MealDB
+	calendarRepo db.CalendarGetter
+	rc           RecipeControl
+}
+
+func NewMealController(mr db.MealDB, cr db.CalendarGetter, rc RecipeControl) MealController {
+	return MealController{mealRepo: mr, calendarRepo: cr, rc: rc}
+}
+
+// GetMealSlots - lists the household's meal slots between two dates
+func (mc MealController) GetMealSlots(householdID string, query models.MealSlotQuery) ([]models.MealSlot, error) {
+	if !primitive.IsValidObjectID(householdID) {
+		return []models.MealSlot{}, ErrNoHousehold
+	}
+	var invalidFields []string
+	from, fromOk := normalizeDay(query.From)
+	to, toOk := normalizeDay(query.To)
+	mealType := strings.ToLower(strings.TrimSpace(query.MealType))
+	if !fromOk {
+		invalidFields = append(invalidFields, "from")
+	}
+	if !toOk {
+		invalidFields = append(invalidFields, "to")
+	}
+	if mealType != "" && !containsString(models.MealTypes, mealType) {
+		invalidFields = append(invalidFields, "mealType")
+	}
+	if len(invalidFields) > 0 {
+		return []models.MealSlot{}, fmt.Errorf("%w: %s", ErrInvalidFilter, strings.Join(invalidFields, ", "))
+	}
+	return mc.mealRepo.GetMealSlots(householdID, models.MealSlotQuery{From: from, To: to, MealType: mealType})
+}
+
+// SaveMealSlot - plans the recipes and notes of the slot for a meal, replacing what was planned for it. Every
+// recipe has to be one the viewer can see.
+func (mc MealController) SaveMealSlot(householdID string, date string, mealType string, slot models.MealSlot, viewer models.Viewer) (models.MealSlot, error) {
+	if !primitive.IsValidObjectID(householdID) {
+		return models.MealSlot{}, ErrNoHousehold
+	}
+	day, mealType, invalidFields := normalizeMeal(date, mealType)
+	recipes := []models.SlotRecipe{}
+	for i, planned := range slot.Recipes {
+		if planned.Servings < 0 {
+			invalidFields = append(invalidFields, fmt.Sprintf("recipes[%d].servings", i))
+		}
+		recipe, err := mc.rc.GetRecipe(planned.RecipeID.Hex(), viewer)
+		if err != nil {
+			invalidFields = append(invalidFields, fmt.Sprintf("recipes[%d].recipeID", i))
+			continue
+		}
+		planned.RecipeName = recipe.RecipeName
+		recipes = append(recipes, planned)
+	}
+	if len(invalidFields) > 0 {
+		return models.MealSlot{}, fmt.Errorf("%w: %s", ErrInvalidMealSlot, strings.Join(invalidFields, ", "))
+	}
+	household, _ := primitive.ObjectIDFromHex(householdID)
+	return mc.mealRepo.SaveMealSlot(models.MealSlot{
+		HouseholdID:     household,
+		Date:            day,
+		MealType:        mealType,
+		Recipes:         recipes,
+		Notes:           slot.Notes,
+		LastUpdatedDate: time.Now().Format("2006.01.02 15:04:05"),
+	})
+}
+
+// DeleteMealSlot - clears what the household planned for a meal
+func (mc MealController) DeleteMealSlot(householdID string, date string, mealType string) error {
+	if !primitive.IsValidObjectID(householdID) {
+		return ErrNoHousehold
+	}
+	day, mealType, invalidFields := normalizeMeal(date, mealType)
+	if len(invalidFields) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidMealSlot, strings.Join(invalidFields, ", "))
+	}
+	err := mc.mealRepo.DeleteMealSlot(householdID, day, mealType)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrMealSlotNotFound
+	}
+	return err
+}
+
+// MigrateCalendars - copies the household's weekly calendars into dinner slots on the dates of their days.
+// Slots the household already has are left as they are, so migrating again only adds what's missing.
+func (mc MealController) MigrateCalendars(householdID string) (models.CalendarMigration, error) {
+	if !primitive.IsValidObjectID(householdID) {
+		return models.CalendarMigration{}, ErrNoHousehold
+	}
+	calendars, err := mc.calendarRepo.GetCalendars(householdID, models.CalendarQuery{})
+	if err != nil {
+		return models.CalendarMigration{}, err
+	}
+	migration := models.CalendarMigration{}
+	var slots []models.MealSlot
+	for _, calendar := range calendars {
+		if calendarDate(calendar, calendarDays[0]) == "" {
+			migration.Skipped = append(migration.Skipped, calendar.CalendarID.Hex())
+			continue
+		}
+		migration.Calendars++
+		slots = append(slots, calendarSlots(calendar)...)
+	}
+	migration.Slots, err = mc.mealRepo.CreateMissingMealSlots(slots)
+	if err != nil {
+		return models.CalendarMigration{}, err
+	}
+	return migration, nil
+}
+
+// calendarSlots turns the days of a weekly calendar with something planned into dinner slots, a calendar without
+// a start date has none
+func calendarSlots(calendar models.Calendar) []models.MealSlot {
+	var slots []models.MealSlot
+	if calendarDate(calendar, calendarDays[0]) == "" {
+		return slots
+	}
+	currentTime := time.Now().Format("2006.01.02 15:04:05")
+	for _, day := range calendarDays {
+		recipe, _ := calendarDay(calendar, day)
+		if recipe.RecipeID.IsZero() && recipe.RecipeName == "" {
+			continue
+		}
+		slots = append(slots, models.MealSlot{
+			HouseholdID:     calendar.HouseholdID,
+			Date:            calendarDate(calendar, day),
+			MealType:        models.MealDinner,
+			Recipes:         []models.SlotRecipe{{RecipeID: recipe.RecipeID, RecipeName: recipe.RecipeName}},
+			CalendarID:      calendar.CalendarID,
+			LastUpdatedDate: currentTime,
+		})
+	}
+	return slots
+}
+
+// normalizeMeal checks the date and meal type naming a slot and normalizes them
+func normalizeMeal(date string, mealType string) (string, string, []string) {
+	var invalidFields []string
+	day, ok := normalizeDay(date)
+	if !ok || day == "" {
+		invalidFields = append(invalidFields, "date")
+	}
+	mealType = strings.ToLower(strings.TrimSpace(mealType))
+	if !containsString(models.MealTypes, mealType) {
+		invalidFields = append(invalidFields, "mealType")
+	}
+	return day, mealType, invalidFields
+}
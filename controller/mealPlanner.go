@@ -0,0 +1,273 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"server/models"
+	"server/units"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidPlan = errors.New("invalid plan constraints")
+
+const (
+	// plannerPoolSize is how many random recipes the planner chooses the week from
+	plannerPoolSize = 50
+	// violationCost outweighs any preference, so a recipe breaking a constraint is only planned when
+	// every other one does too
+	violationCost = 100
+	// requiredTagBonus is how much the planner prefers a recipe with a tag the week still needs
+	requiredTagBonus = 20
+)
+
+var weeknights = map[string]bool{"monday": true, "tuesday": true, "wednesday": true, "thursday": true, "friday": true}
+
+// invalidPlanFields lists the parts of a calendar request that can't be planned from
+func invalidPlanFields(request models.CalendarRequest) []string {
+	var invalidFields []string
+	constraints := request.Constraints
+	if day, ok := normalizeDay(request.StartDate); constraints.NoRepeatWeeks > 0 && (!ok || day == "") {
+		invalidFields = append(invalidFields, "startDate")
+	}
+	if constraints.WeeknightMaxTotalTime < 0 {
+		invalidFields = append(invalidFields, "constraints.weeknightMaxTotalTime")
+	}
+	if constraints.MaxDailyCalories < 0 {
+		invalidFields = append(invalidFields, "constraints.maxDailyCalories")
+	}
+	if constraints.NoRepeatWeeks < 0 {
+		invalidFields = append(invalidFields, "constraints.noRepeatWeeks")
+	}
+	if constraints.MaxDaysPerTag < 0 {
+		invalidFields = append(invalidFields, "constraints.maxDaysPerTag")
+	}
+	for i, requirement := range constraints.RequiredTags {
+		if strings.TrimSpace(requirement.Tag) == "" || requirement.MinDays < 1 {
+			invalidFields = append(invalidFields, fmt.Sprintf("constraints.requiredTags[%d]", i))
+		}
+	}
+	var pinnedDays []string
+	for day := range constraints.PinnedDays {
+		pinnedDays = append(pinnedDays, day)
+	}
+	sort.Strings(pinnedDays)
+	for _, day := range pinnedDays {
+		if !containsString(calendarDays, day) || !primitive.IsValidObjectID(constraints.PinnedDays[day]) {
+			invalidFields = append(invalidFields, "constraints.pinnedDays."+day)
+		}
+	}
+	return invalidFields
+}
+
+// planWeek picks a recipe for every day that isn't pinned, one day at a time, choosing the candidate that
+// breaks the fewest constraints and then the one that best fits the week's preferences. Days are left out
+// when there are no candidates left for them.
+func planWeek(candidates []models.Recipe, pinned map[string]models.Recipe, recent map[primitive.ObjectID]bool, constraints models.PlanConstraints) map[string]models.Recipe {
+	planned := map[string]models.Recipe{}
+	used := map[primitive.ObjectID]bool{}
+	tagDays := map[string]int{}
+	for day, recipe := range pinned {
+		planned[day] = recipe
+		used[recipe.RecipeID] = true
+		countTags(tagDays, recipe)
+	}
+	for i, day := range calendarDays {
+		if _, ok := planned[day]; ok {
+			continue
+		}
+		best := -1
+		bestCost := 0
+		for j, candidate := range candidates {
+			if used[candidate.RecipeID] {
+				continue
+			}
+			cost := violationCost * len(dayViolations(day, candidate, recent, constraints))
+			cost += violationCost * len(exceededTags(tagDays, candidate, constraints.MaxDaysPerTag))
+			cost -= requiredTagBonus * neededTags(tagDays, candidate, constraints.RequiredTags)
+			if previous, ok := planned[previousDay(i)]; ok && constraints.ReuseIngredients {
+				cost -= sharedIngredients(previous, candidate)
+			}
+			if best == -1 || cost < bestCost {
+				best = j
+				bestCost = cost
+			}
+		}
+		if best == -1 {
+			break
+		}
+		planned[day] = candidates[best]
+		used[candidates[best].RecipeID] = true
+		countTags(tagDays, candidates[best])
+	}
+	return planned
+}
+
+// unsatisfiedConstraints reports every constraint the planned week doesn't meet, day by day and then
+// those for the whole week
+func unsatisfiedConstraints(planned map[string]models.Recipe, recent map[primitive.ObjectID]bool, constraints models.PlanConstraints) []models.UnsatisfiedConstraint {
+	var unsatisfied []models.UnsatisfiedConstraint
+	tagDays := map[string]int{}
+	for i, day := range calendarDays {
+		recipe := planned[day]
+		countTags(tagDays, recipe)
+		unsatisfied = append(unsatisfied, dayViolations(day, recipe, recent, constraints)...)
+		if previous, ok := planned[previousDay(i)]; ok && constraints.ReuseIngredients && sharedIngredients(previous, recipe) == 0 {
+			unsatisfied = append(unsatisfied, models.UnsatisfiedConstraint{
+				Constraint: models.ConstraintReuseIngredients,
+				Day:        day,
+				Detail:     fmt.Sprintf("%s uses none of the ingredients of %s", recipe.RecipeName, previous.RecipeName),
+			})
+		}
+	}
+	for _, requirement := range constraints.RequiredTags {
+		if days := tagDays[normalizeTag(requirement.Tag)]; days < requirement.MinDays {
+			unsatisfied = append(unsatisfied, models.UnsatisfiedConstraint{
+				Constraint: models.ConstraintRequiredTags,
+				Detail:     fmt.Sprintf("%s is planned on %d of the %d days asked for", requirement.Tag, days, requirement.MinDays),
+			})
+		}
+	}
+	if constraints.MaxDaysPerTag > 0 {
+		var tags []string
+		for tag := range tagDays {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		for _, tag := range tags {
+			if tagDays[tag] > constraints.MaxDaysPerTag {
+				unsatisfied = append(unsatisfied, models.UnsatisfiedConstraint{
+					Constraint: models.ConstraintMaxDaysPerTag,
+					Detail:     fmt.Sprintf("%s is planned on %d days", tag, tagDays[tag]),
+				})
+			}
+		}
+	}
+	return unsatisfied
+}
+
+// dayViolations lists the constraints planning the recipe on the day would break by itself
+func dayViolations(day string, recipe models.Recipe, recent map[primitive.ObjectID]bool, constraints models.PlanConstraints) []models.UnsatisfiedConstraint {
+	var violations []models.UnsatisfiedConstraint
+	totalTime := recipe.PrepTime + recipe.CookTime
+	if constraints.WeeknightMaxTotalTime > 0 && weeknights[day] && totalTime > constraints.WeeknightMaxTotalTime {
+		violations = append(violations, models.UnsatisfiedConstraint{
+			Constraint: models.ConstraintWeeknightMaxTotalTime,
+			Day:        day,
+			Detail:     fmt.Sprintf("%s takes %d minutes", recipe.RecipeName, totalTime),
+		})
+	}
+	if constraints.MaxDailyCalories > 0 && recipe.Calories > constraints.MaxDailyCalories {
+		violations = append(violations, models.UnsatisfiedConstraint{
+			Constraint: models.ConstraintMaxDailyCalories,
+			Day:        day,
+			Detail:     fmt.Sprintf("%s has %d calories per serving", recipe.RecipeName, recipe.Calories),
+		})
+	}
+	if recent[recipe.RecipeID] && !recipe.RecipeID.IsZero() {
+		violations = append(violations, models.UnsatisfiedConstraint{
+			Constraint: models.ConstraintNoRepeatWeeks,
+			Day:        day,
+			Detail:     fmt.Sprintf("%s was planned in the last %d weeks", recipe.RecipeName, constraints.NoRepeatWeeks),
+		})
+	}
+	return violations
+}
+
+// exceededTags lists the recipe's tags that would then be planned on more than maxDays days
+func exceededTags(tagDays map[string]int, recipe models.Recipe, maxDays int) []string {
+	var exceeded []string
+	if maxDays < 1 {
+		return exceeded
+	}
+	for _, tag := range recipeTags(recipe) {
+		if tagDays[tag]+1 > maxDays {
+			exceeded = append(exceeded, tag)
+		}
+	}
+	return exceeded
+}
+
+// neededTags counts the recipe's tags that are still planned on fewer days than required
+func neededTags(tagDays map[string]int, recipe models.Recipe, requirements []models.TagRequirement) int {
+	needed := 0
+	tags := recipeTags(recipe)
+	for _, requirement := range requirements {
+		tag := normalizeTag(requirement.Tag)
+		if tagDays[tag] < requirement.MinDays && containsString(tags, tag) {
+			needed++
+		}
+	}
+	return needed
+}
+
+// sharedIngredients counts the ingredients two recipes have in common, leaving out seasoning added to taste
+func sharedIngredients(first models.Recipe, second models.Recipe) int {
+	names := map[string]bool{}
+	for _, ingredient := range first.Ingredients {
+		if ingredient.Measurement != units.Taste.Name {
+			names[strings.ToLower(strings.TrimSpace(ingredient.Name))] = true
+		}
+	}
+	shared := 0
+	for _, ingredient := range second.Ingredients {
+		name := strings.ToLower(strings.TrimSpace(ingredient.Name))
+		if ingredient.Measurement != units.Taste.Name && names[name] {
+			shared++
+			delete(names, name)
+		}
+	}
+	return shared
+}
+
+func countTags(tagDays map[string]int, recipe models.Recipe) {
+	for _, tag := range recipeTags(recipe) {
+		tagDays[tag]++
+	}
+}
+
+// recipeTags are the recipe's tags normalized and without duplicates
+func recipeTags(recipe models.Recipe) []string {
+	var tags []string
+	for _, tag := range recipe.Tags {
+		if tag = normalizeTag(tag); tag != "" && !containsString(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// previousDay names the day before the calendar's i-th day, or is empty for its first day
+func previousDay(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return calendarDays[i-1]
+}
+
+// recentWeeksStart is the date the weeks a calendar shouldn't repeat start on
+func recentWeeksStart(startDate string, weeks int) string {
+	day, _ := normalizeDay(startDate)
+	start, err := time.Parse("2006.01.02", day)
+	if err != nil {
+		return day
+	}
+	return start.AddDate(0, 0, -7*weeks).Format("2006.01.02")
+}
+
+// setCalendarDay plans the recipe on a day of the calendar by the day's name, expanded since it's at hand
+func setCalendarDay(calendar *models.Calendar, day string, recipe models.Recipe) {
+	for i, planned := range plannedDays(calendar) {
+		if calendarDays[i] == day {
+			*planned = models.PlannedRecipe{RecipeID: recipe.RecipeID, RecipeName: recipe.RecipeName, Recipe: &recipe}
+		}
+	}
+}
@@ -1,111 +1,446 @@
 package controller
 
 import (
+	"encoding/json"
 	"errors"
-	"math/rand"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"reflect"
 	"server/db"
+	"server/export"
+	"server/importer"
 	"server/models"
+	"server/patch"
+	"server/search"
+	"server/units"
+	"sort"
+	"strings"
 	"time"
 )
 
 type RecipeControl interface {
 	CreateRecipe(recipe models.Recipe) (models.Recipe, []string, error)
 	DeleteRecipe(recipeID string) error
-	UpdateRecipe(recipeID string, updatedRecipe models.Recipe) (models.Recipe, error)
-	GetRandomRecipes(numberOfRecipes int) ([]models.Recipe, error)
+	UpdateRecipe(recipeID string, updatedRecipe models.Recipe, userName string) (models.Recipe, []string, error)
+	PatchRecipe(recipeID string, patchType string, recipePatch []byte, version int, userName string) (models.Recipe, []string, error)
+	GetRandomRecipes(request models.RandomRecipeRequest) ([]models.Recipe, error)
 	PostPaginatedRecipes(paginatedRequest models.PaginatedRecipeRequest) (models.PaginatedRecipeResponse, error)
-	GetRecipe(recipeID string) (models.Recipe, error)
+	GetRecipe(recipeID string, viewer models.Viewer) (models.Recipe, error)
+	GetRecipesByID(recipeIDs []primitive.ObjectID, viewer models.Viewer) ([]models.Recipe, error)
+	ScaleRecipe(recipeID string, servings int, viewer models.Viewer) (models.Recipe, error)
+	RecordPlannedRecipes(recipes []models.Recipe) error
+	ImportRecipe(request models.RecipeImportRequest, userName string) (models.Recipe, []string, error)
+	ExportRecipe(recipeID string, format string, viewer models.Viewer) (export.Document, error)
+	ExportRecipes(format string, viewer models.Viewer) (export.Document, error)
+	GetRevisions(recipeID string, viewer models.Viewer) ([]models.RecipeRevision, error)
+	DiffRevisions(recipeID string, from int, to int, viewer models.Viewer) (models.RecipeDiff, error)
+	RevertRecipe(recipeID string, number int, version int, userName string) (models.Recipe, error)
+	ForkRecipe(recipeID string, viewer models.Viewer) (models.Recipe, error)
+	GetForks(recipeID string, paginatedRequest models.PaginatedRecipeRequest) (models.PaginatedRecipeResponse, error)
+	GetNutrition(recipeID string, viewer models.Viewer) (models.RecipeNutrition, error)
 }
 
+var (
+	ErrCannotScale   = errors.New("recipe cannot be scaled")
+	ErrInvalidFilter = errors.New("invalid filter")
+	ErrInvalidSort   = errors.New("invalid sort")
+	// ErrRecipeNotFound is also returned for recipes the viewer isn't allowed to see, so they can't tell them apart
+	ErrRecipeNotFound   = errors.New("recipe not found")
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrImmutableField   = errors.New("field cannot be changed")
+)
+
 type RecipeController struct {
-	recipeRepo db.RecipeDB
+	recipeRepo     db.RecipeDB
+	revisionRepo   db.RevisionDB
+	ingredientRepo db.IngredientGetter
 }
 
-func NewRecipeController(rr db.RecipeDB) RecipeController {
-	return RecipeController{recipeRepo: rr}
+func NewRecipeController(rr db.RecipeDB, rv db.RevisionDB, ir db.IngredientGetter) RecipeController {
+	return RecipeController{recipeRepo: rr, revisionRepo: rv, ingredientRepo: ir}
 }
 
 //CreateRecipe a new recipe
 func (rc RecipeController) CreateRecipe(recipe models.Recipe) (models.Recipe, []string, error) {
+	// only ForkRecipe says where a recipe was copied from
+	recipe.ForkedFrom = nil
+	return rc.createRecipe(recipe, nil)
+}
+
+// createRecipe saves a new recipe, keeping what it was forked from. Measurements in saved are accepted as
+// written, like they would be when updating the recipe they were saved with.
+func (rc RecipeController) createRecipe(recipe models.Recipe, saved []models.Ingredient) (models.Recipe, []string, error) {
 	currentTime := time.Now()
 	recipe.CreatedDate = currentTime.Format("2006.01.02 15:04:05")
 	recipe.LastUpdatedDate = currentTime.Format("2006.01.02 15:04:05")
-	valid, invalidFields := isValidRecipe(recipe)
+	valid, invalidFields := isValidRecipe(recipe, saved)
 	if !valid {
 		return models.Recipe{}, invalidFields, errors.New("invalid fields")
 	}
-	err := rc.recipeRepo.CreateRecipe(&recipe)
+	recipe.Ingredients = normalizeIngredients(recipe.Ingredients)
+	recipe.Visibility = recipeVisibility(recipe)
+	recipe.Private = recipe.Visibility == models.VisibilityPrivate
+	recipe.Version = 1
+	// a new recipe hasn't been planned or reviewed yet, whatever the request says
+	recipe.Popularity = 0
+	recipe.AverageRating = 0
+	recipe.RatingCount = 0
+	rc.deriveFromCatalog(&recipe)
+	// the recipe is kept as its first revision before it is saved, so it needs its ID up front
+	recipe.RecipeID = primitive.NewObjectID()
+	revision, err := rc.recordRevision(recipe, recipe.UserName, recipe.CreatedDate)
+	if err != nil {
+		return models.Recipe{}, invalidFields, err
+	}
+	err = rc.recipeRepo.CreateRecipe(&recipe)
 
 	if err != nil {
+		rc.discardRevision(revision)
 		return models.Recipe{}, invalidFields, err
 	}
 
 	return recipe, invalidFields, nil
 }
 
-//DeleteRecipe - deletes a recipe by its ID.
+// ImportRecipe - parses a recipe from another site for the user. Drafts are normalized and returned along with
+// any fields that still need fixing, saved imports go through CreateRecipe.
+func (rc RecipeController) ImportRecipe(request models.RecipeImportRequest, userName string) (models.Recipe, []string, error) {
+	recipe, err := importer.Parse(request.Format, request.Content)
+	if err != nil {
+		return models.Recipe{}, nil, err
+	}
+	recipe.UserName = userName
+	recipe.Visibility = request.Visibility
+	if request.Save {
+		return rc.CreateRecipe(recipe)
+	}
+	_, invalidFields := isValidRecipe(recipe, nil)
+	recipe.Ingredients = normalizeIngredients(recipe.Ingredients)
+	return recipe, invalidFields, nil
+}
+
+// ExportRecipe - renders a recipe the viewer can see as Markdown, HTML or JSON-LD
+func (rc RecipeController) ExportRecipe(recipeID string, format string, viewer models.Viewer) (export.Document, error) {
+	recipe, err := rc.GetRecipe(recipeID, viewer)
+	if err != nil {
+		return export.Document{}, err
+	}
+	return export.Render(recipe, format)
+}
+
+// ExportRecipes - zips up every recipe the viewer owns in one format
+func (rc RecipeController) ExportRecipes(format string, viewer models.Viewer) (export.Document, error) {
+	page, err := rc.recipeRepo.GetPaginatedRecipes(models.PaginatedRecipeRequest{
+		Filter: models.RecipeFilter{UserName: viewer.UserName},
+		Sort:   []models.RecipeSort{{Field: "name"}},
+		Viewer: viewer,
+	})
+	if err != nil {
+		return export.Document{}, err
+	}
+	return export.Zip(page.Recipes, format)
+}
+
+//DeleteRecipe - deletes a recipe by its ID along with its revisions.
 func (rc RecipeController) DeleteRecipe(recipeID string) error {
 	err := rc.recipeRepo.DeleteRecipe(recipeID)
 	if err != nil {
 		return err
 	}
+	if revisionErr := rc.revisionRepo.DeleteRevisions(recipeID); revisionErr != nil {
+		fmt.Println("Could not delete recipe revisions")
+		fmt.Println(revisionErr)
+	}
 	return nil
 }
 
-func (rc RecipeController) UpdateRecipe(recipeID string, updatedRecipe models.Recipe) (models.Recipe, error) {
-	recipe, err := rc.recipeRepo.UpdateRecipe(recipeID, updatedRecipe)
+// ForkRecipe - copies a recipe the viewer can see into a private recipe of their own that remembers where it came from
+func (rc RecipeController) ForkRecipe(recipeID string, viewer models.Viewer) (models.Recipe, error) {
+	source, err := rc.GetRecipe(recipeID, viewer)
 	if err != nil {
 		return models.Recipe{}, err
 	}
-	return recipe, nil
+	fork := source
+	fork.RecipeID = primitive.NilObjectID
+	fork.UserName = viewer.UserName
+	fork.Visibility = models.VisibilityPrivate
+	fork.Popularity = 0
+	fork.AverageRating = 0
+	fork.RatingCount = 0
+	fork.ForkedFrom = &models.RecipeReference{
+		RecipeID:   source.RecipeID,
+		RecipeName: source.RecipeName,
+		UserName:   source.UserName,
+		Author:     source.Author,
+	}
+	created, _, err := rc.createRecipe(fork, source.Ingredients)
+	return created, err
 }
 
-// GetRandomRecipes - returns a slice of random recipes
-func (rc RecipeController) GetRandomRecipes(numberOfRecipes int) ([]models.Recipe, error) {
-	var emptyResults []models.Recipe
-	itemCount, err := rc.recipeRepo.CountRecipes()
-	if err != nil {
-		return emptyResults, err
-	}
-	if numberOfRecipes > int(itemCount) {
-		numberOfRecipes = int(itemCount)
-	} else if numberOfRecipes < 1 {
-		return emptyResults, err
-	}
-	var seededRand = rand.New(
-		rand.NewSource(time.Now().UnixNano()))
-	var recipeNumbers []int
-	doneComputing := false
-	for !doneComputing {
-		recipeNumber := seededRand.Intn(int(itemCount))
-		if !contains(recipeNumbers, recipeNumber) {
-			recipeNumbers = append(recipeNumbers, recipeNumber)
-			if len(recipeNumbers) == numberOfRecipes {
-				doneComputing = true
-			}
+// GetForks - pages through the copies of a recipe the viewer can see
+func (rc RecipeController) GetForks(recipeID string, paginatedRequest models.PaginatedRecipeRequest) (models.PaginatedRecipeResponse, error) {
+	if _, err := rc.GetRecipe(recipeID, paginatedRequest.Viewer); err != nil {
+		return models.PaginatedRecipeResponse{}, err
+	}
+	paginatedRequest.Filter.ForkedFrom = recipeID
+	return rc.PostPaginatedRecipes(paginatedRequest)
+}
+
+// GetNutrition - works out the nutrition of a recipe the viewer can see from the ingredient catalog as it is now
+func (rc RecipeController) GetNutrition(recipeID string, viewer models.Viewer) (models.RecipeNutrition, error) {
+	recipe, err := rc.GetRecipe(recipeID, viewer)
+	if err != nil {
+		return models.RecipeNutrition{}, err
+	}
+	catalog, err := rc.ingredientRepo.GetIngredientsByName(ingredientNames(recipe.Ingredients))
+	if err != nil {
+		return models.RecipeNutrition{}, err
+	}
+	return recipeNutrition(recipe.Ingredients, recipe.Servings, catalog), nil
+}
+
+// deriveFromCatalog works out the nutrition and dietary labels saved with a recipe. The recipe can still be
+// saved when the catalog can't be read, just without them.
+func (rc RecipeController) deriveFromCatalog(recipe *models.Recipe) {
+	recipe.Nutrition = nil
+	recipe.Labels = nil
+	catalog, err := rc.ingredientRepo.GetIngredientsByName(ingredientNames(recipe.Ingredients))
+	if err != nil {
+		fmt.Println("Could not look up catalog ingredients")
+		fmt.Println(err)
+		return
+	}
+	nutrition := recipeNutrition(recipe.Ingredients, recipe.Servings, catalog)
+	labels := recipeLabels(recipe.Ingredients, catalog)
+	recipe.Nutrition = &nutrition
+	recipe.Labels = &labels
+}
+
+// GetRevisions - lists every saved version of a recipe the viewer can see, oldest first
+func (rc RecipeController) GetRevisions(recipeID string, viewer models.Viewer) ([]models.RecipeRevision, error) {
+	if _, err := rc.GetRecipe(recipeID, viewer); err != nil {
+		return []models.RecipeRevision{}, err
+	}
+	return rc.revisionRepo.GetRevisions(recipeID)
+}
+
+// DiffRevisions - compares two revisions of a recipe field by field
+func (rc RecipeController) DiffRevisions(recipeID string, from int, to int, viewer models.Viewer) (models.RecipeDiff, error) {
+	if _, err := rc.GetRecipe(recipeID, viewer); err != nil {
+		return models.RecipeDiff{}, err
+	}
+	fromRevision, fromErr := rc.revisionRepo.GetRevision(recipeID, from)
+	toRevision, toErr := rc.revisionRepo.GetRevision(recipeID, to)
+	if fromErr != nil || toErr != nil {
+		return models.RecipeDiff{}, ErrRevisionNotFound
+	}
+	return models.RecipeDiff{
+		RecipeID: fromRevision.RecipeID,
+		From:     from,
+		To:       to,
+		Changes:  diffRecipes(fromRevision.Recipe, toRevision.Recipe),
+	}, nil
+}
+
+// RevertRecipe - replaces a recipe at version with one of its earlier revisions, which is kept as a new revision.
+// If the recipe changed since version it returns db.ErrVersionConflict along with the current recipe.
+func (rc RecipeController) RevertRecipe(recipeID string, number int, version int, userName string) (models.Recipe, error) {
+	revision, err := rc.revisionRepo.GetRevision(recipeID, number)
+	if err != nil {
+		return models.Recipe{}, ErrRevisionNotFound
+	}
+	revision.Recipe.Version = version
+	recipe, _, err := rc.UpdateRecipe(recipeID, revision.Recipe, userName)
+	return recipe, err
+}
+
+// recordRevision keeps a snapshot of the recipe as its newest revision. It is recorded before the recipe is
+// saved, so a recipe is never changed without a revision of the change.
+func (rc RecipeController) recordRevision(recipe models.Recipe, userName string, date string) (models.RecipeRevision, error) {
+	revision := models.RecipeRevision{RecipeID: recipe.RecipeID, UserName: userName, CreatedDate: date, Recipe: recipe}
+	err := rc.revisionRepo.CreateRevision(&revision)
+	return revision, err
+}
+
+// discardRevision removes a revision of a change that couldn't be saved
+func (rc RecipeController) discardRevision(revision models.RecipeRevision) {
+	if err := rc.revisionRepo.DeleteRevision(revision.RecipeID.Hex(), revision.Number); err != nil {
+		fmt.Println("Could not remove unsaved recipe revision")
+		fmt.Println(err)
+	}
+}
+
+// recordFirstRevision keeps the current version of a recipe saved before revisions were kept as its first revision
+func (rc RecipeController) recordFirstRevision(currentRecipe models.Recipe) error {
+	revisions, err := rc.revisionRepo.GetRevisions(currentRecipe.RecipeID.Hex())
+	if err != nil || len(revisions) > 0 {
+		return err
+	}
+	revision := models.RecipeRevision{
+		RecipeID:    currentRecipe.RecipeID,
+		UserName:    currentRecipe.UserName,
+		CreatedDate: currentRecipe.LastUpdatedDate,
+		Recipe:      currentRecipe,
+	}
+	return rc.revisionRepo.CreateFirstRevision(&revision)
+}
+
+//UpdateRecipe - replaces a recipe after normalizing and validating it, keeping the new version as a revision.
+// If the recipe changed since updatedRecipe.Version it returns db.ErrVersionConflict along with the current recipe.
+func (rc RecipeController) UpdateRecipe(recipeID string, updatedRecipe models.Recipe, userName string) (models.Recipe, []string, error) {
+	currentRecipe, err := rc.recipeRepo.GetRecipe(recipeID)
+	if err != nil {
+		return models.Recipe{}, nil, ErrRecipeNotFound
+	}
+	valid, invalidFields := isValidRecipe(updatedRecipe, currentRecipe.Ingredients)
+	if !valid {
+		return models.Recipe{}, invalidFields, errors.New("invalid fields")
+	}
+	if currentRecipe.Version != updatedRecipe.Version {
+		return currentRecipe, invalidFields, db.ErrVersionConflict
+	}
+	updatedRecipe.Ingredients = normalizeIngredients(updatedRecipe.Ingredients)
+	// Private can't tell an update that left it out from one that made the recipe public, so only a visibility
+	// or Private being set changes who can see the recipe
+	if updatedRecipe.Visibility == "" && !updatedRecipe.Private {
+		updatedRecipe.Visibility = recipeVisibility(currentRecipe)
+	}
+	updatedRecipe.Visibility = recipeVisibility(updatedRecipe)
+	updatedRecipe.Private = updatedRecipe.Visibility == models.VisibilityPrivate
+	updatedRecipe.RecipeID = currentRecipe.RecipeID
+	updatedRecipe.LastUpdatedDate = time.Now().Format("2006.01.02 15:04:05")
+	rc.deriveFromCatalog(&updatedRecipe)
+	// popularity and ratings are counted by the server and who made the recipe, when and from what can't change,
+	// so keep whatever the current recipe has
+	updatedRecipe.CreatedDate = currentRecipe.CreatedDate
+	updatedRecipe.UserName = currentRecipe.UserName
+	updatedRecipe.ForkedFrom = currentRecipe.ForkedFrom
+	updatedRecipe.Popularity = currentRecipe.Popularity
+	updatedRecipe.AverageRating = currentRecipe.AverageRating
+	updatedRecipe.RatingCount = currentRecipe.RatingCount
+
+	// recipes saved before revisions were kept get their current version as the first revision
+	if err := rc.recordFirstRevision(currentRecipe); err != nil {
+		return models.Recipe{}, invalidFields, err
+	}
+	saved := updatedRecipe
+	saved.Version++
+	revision, err := rc.recordRevision(saved, userName, saved.LastUpdatedDate)
+	if err != nil {
+		return models.Recipe{}, invalidFields, err
+	}
+	recipe, err := rc.recipeRepo.UpdateRecipe(recipeID, updatedRecipe)
+	if err != nil {
+		rc.discardRevision(revision)
+	}
+	if errors.Is(err, db.ErrVersionConflict) {
+		// send back the current recipe so the changes can be made again on top of it
+		if currentRecipe, getErr := rc.recipeRepo.GetRecipe(recipeID); getErr == nil {
+			return currentRecipe, invalidFields, err
 		}
+		return models.Recipe{}, invalidFields, ErrRecipeNotFound
+	}
+	if err != nil {
+		return models.Recipe{}, invalidFields, err
 	}
-	var randomRecipes []models.Recipe
-	for _, num := range recipeNumbers {
-		pageSize := 10
-		pageCount := num / pageSize
-		selectedRecipe := num % pageSize
-		paginatedRequest := models.PaginatedRecipeRequest{
-			PageCount:   pageCount,
-			PageSize:    int64(pageSize),
-			QueryRecipe: models.Recipe{},
+	return recipe, invalidFields, nil
+}
+
+// PatchRecipe - applies a JSON Merge Patch or JSON Patch to the recipe at the version it was made from, then
+// saves it the same way as UpdateRecipe. Steps are renumbered in their new order and fields the server
+// manages can't be patched.
+func (rc RecipeController) PatchRecipe(recipeID string, patchType string, recipePatch []byte, version int, userName string) (models.Recipe, []string, error) {
+	current, err := rc.recipeRepo.GetRecipe(recipeID)
+	if err != nil {
+		return models.Recipe{}, nil, ErrRecipeNotFound
+	}
+	if current.Version != version {
+		return current, nil, db.ErrVersionConflict
+	}
+	document, err := json.Marshal(current)
+	if err != nil {
+		return models.Recipe{}, nil, err
+	}
+	patched, err := patch.Apply(patchType, document, recipePatch)
+	if err != nil {
+		return models.Recipe{}, nil, err
+	}
+	var patchedRecipe models.Recipe
+	if err := json.Unmarshal(patched, &patchedRecipe); err != nil {
+		return models.Recipe{}, nil, fmt.Errorf("%w: %v", patch.ErrInvalidPatch, err)
+	}
+	if changed := changedImmutableFields(current, patchedRecipe); len(changed) > 0 {
+		return models.Recipe{}, changed, ErrImmutableField
+	}
+	for i := range patchedRecipe.Steps {
+		patchedRecipe.Steps[i].Number = i + 1
+	}
+	// older clients only know Private, so a patch to it alone sets the visibility it stands for
+	if patchedRecipe.Private != current.Private && patchedRecipe.Visibility == current.Visibility {
+		patchedRecipe.Visibility = models.VisibilityPublic
+		if patchedRecipe.Private {
+			patchedRecipe.Visibility = models.VisibilityPrivate
 		}
-		recipes, getErr := rc.recipeRepo.GetPaginatedRecipes(paginatedRequest)
-		if getErr != nil {
-			return emptyResults, err
+	}
+	return rc.UpdateRecipe(recipeID, patchedRecipe, userName)
+}
+
+// changedImmutableFields lists the fields set by the server that differ between the two versions of a recipe
+func changedImmutableFields(current models.Recipe, patched models.Recipe) (changed []string) {
+	fields := []struct {
+		name    string
+		changed bool
+	}{
+		{"_id", current.RecipeID != patched.RecipeID},
+		{"createdDate", current.CreatedDate != patched.CreatedDate},
+		{"lastUpdatedDate", current.LastUpdatedDate != patched.LastUpdatedDate},
+		{"userName", current.UserName != patched.UserName},
+		{"forkedFrom", !reflect.DeepEqual(current.ForkedFrom, patched.ForkedFrom)},
+		{"popularity", current.Popularity != patched.Popularity},
+		{"averageRating", current.AverageRating != patched.AverageRating},
+		{"ratingCount", current.RatingCount != patched.RatingCount},
+		{"version", current.Version != patched.Version},
+	}
+	for _, field := range fields {
+		if field.changed {
+			changed = append(changed, field.name)
+		}
+	}
+	return changed
+}
+
+// GetRandomRecipes - returns a slice of random recipes matching the request's filters
+func (rc RecipeController) GetRandomRecipes(request models.RandomRecipeRequest) ([]models.Recipe, error) {
+	if request.NumberOfRecipes < 1 {
+		return []models.Recipe{}, nil
+	}
+	if request.Filter.RespectRestrictions {
+		applyRestrictions(&request.Filter, request.Viewer.Restrictions)
+	}
+	invalidFields := normalizeFilter(&request.Filter)
+	for i, recipeID := range request.ExcludeRecipeIDs {
+		if !primitive.IsValidObjectID(recipeID) {
+			invalidFields = append(invalidFields, fmt.Sprintf("excludeRecipeIDs[%d]", i))
 		}
-		randomRecipes = append(randomRecipes, recipes[selectedRecipe])
 	}
-	return randomRecipes, nil
+	if len(invalidFields) > 0 {
+		return []models.Recipe{}, fmt.Errorf("%w: %s", ErrInvalidFilter, strings.Join(invalidFields, ", "))
+	}
+	return rc.recipeRepo.GetRandomRecipes(request)
 }
 
 //PostPaginateRecipes - gets all recipes
 func (rc RecipeController) PostPaginatedRecipes(paginatedRequest models.PaginatedRecipeRequest) (models.PaginatedRecipeResponse, error) {
+	if paginatedRequest.Filter.RespectRestrictions {
+		applyRestrictions(&paginatedRequest.Filter, paginatedRequest.Viewer.Restrictions)
+	}
+	if invalidFields := normalizeFilter(&paginatedRequest.Filter); len(invalidFields) > 0 {
+		return models.PaginatedRecipeResponse{}, fmt.Errorf("%w: %s", ErrInvalidFilter, strings.Join(invalidFields, ", "))
+	}
+	if invalidFields := invalidSortFields(paginatedRequest.Sort); len(invalidFields) > 0 {
+		return models.PaginatedRecipeResponse{}, fmt.Errorf("%w: %s", ErrInvalidSort, strings.Join(invalidFields, ", "))
+	}
 	itemNumber, countErr := rc.recipeRepo.GetFilteredRecipeCount(paginatedRequest)
 	if countErr != nil {
 		return models.PaginatedRecipeResponse{}, countErr
@@ -118,41 +453,303 @@ func (rc RecipeController) PostPaginatedRecipes(paginatedRequest models.Paginate
 			PageSize:        paginatedRequest.PageSize,
 		}, nil
 	}
-	recipes, getErr := rc.recipeRepo.GetPaginatedRecipes(paginatedRequest)
+	page, getErr := rc.recipeRepo.GetPaginatedRecipes(paginatedRequest)
 
 	if getErr != nil {
 		return models.PaginatedRecipeResponse{}, getErr
 	}
 
 	return models.PaginatedRecipeResponse{
-		Recipes:         recipes,
+		Recipes:         page.Recipes,
 		NumberOfRecipes: itemNumber,
 		PageCount:       paginatedRequest.PageCount,
 		PageSize:        paginatedRequest.PageSize,
+		Highlights:      highlightRecipes(page.Recipes, search.ForRequest(paginatedRequest)),
+		NextCursor:      page.NextCursor,
+		PrevCursor:      page.PrevCursor,
 	}, nil
 }
 
-//GetRecipe - gets recipes by its ID
-func (rc RecipeController) GetRecipe(recipeID string) (models.Recipe, error) {
-	return rc.recipeRepo.GetRecipe(recipeID)
+// highlightRecipes gets a snippet for every field of the recipes that matched a search
+func highlightRecipes(recipes []models.Recipe, query search.Query) []models.RecipeHighlight {
+	var highlights []models.RecipeHighlight
+	if query.IsEmpty() {
+		return highlights
+	}
+	for _, recipe := range recipes {
+		ingredientNames := make([]string, len(recipe.Ingredients))
+		for i, ingredient := range recipe.Ingredients {
+			ingredientNames[i] = ingredient.Name
+		}
+		stepTexts := make([]string, len(recipe.Steps))
+		for i, step := range recipe.Steps {
+			stepTexts[i] = step.Text
+		}
+		fields := []struct {
+			name string
+			text string
+		}{
+			{"recipeName", recipe.RecipeName},
+			{"ingredients", strings.Join(ingredientNames, ", ")},
+			{"steps", strings.Join(stepTexts, " ")},
+			{"tags", strings.Join(recipe.Tags, ", ")},
+		}
+		for _, field := range fields {
+			if snippet, ok := search.Highlight(field.text, query); ok {
+				highlights = append(highlights, models.RecipeHighlight{RecipeID: recipe.RecipeID, Field: field.name, Snippet: snippet})
+			}
+		}
+	}
+	return highlights
 }
 
-func contains(recipeNumbers []int, recipeNumber int) bool {
-	for _, num := range recipeNumbers {
-		if num == recipeNumber {
-			return true
+// RecordPlannedRecipes - counts another use of each recipe towards its popularity
+func (rc RecipeController) RecordPlannedRecipes(recipes []models.Recipe) error {
+	var recipeIDs []primitive.ObjectID
+	for _, recipe := range recipes {
+		if !recipe.RecipeID.IsZero() {
+			recipeIDs = append(recipeIDs, recipe.RecipeID)
 		}
 	}
+	if len(recipeIDs) == 0 {
+		return nil
+	}
+	return rc.recipeRepo.IncrementPopularity(recipeIDs)
+}
+
+//GetRecipe - gets recipes by its ID if the viewer is allowed to see it
+func (rc RecipeController) GetRecipe(recipeID string, viewer models.Viewer) (models.Recipe, error) {
+	recipe, err := rc.recipeRepo.GetRecipe(recipeID)
+	if err != nil {
+		return models.Recipe{}, err
+	}
+	if !CanView(recipe, viewer) {
+		return models.Recipe{}, ErrRecipeNotFound
+	}
+	return recipe, nil
+}
+
+// GetRecipesByID - gets the recipes with any of the IDs that the viewer can see, in no particular order
+func (rc RecipeController) GetRecipesByID(recipeIDs []primitive.ObjectID, viewer models.Viewer) ([]models.Recipe, error) {
+	recipes, err := rc.recipeRepo.GetRecipesByID(recipeIDs)
+	if err != nil {
+		return []models.Recipe{}, err
+	}
+	visible := []models.Recipe{}
+	for _, recipe := range recipes {
+		if CanView(recipe, viewer) {
+			visible = append(visible, recipe)
+		}
+	}
+	return visible, nil
+}
+
+// ScaleRecipe - gets a recipe with its ingredient amounts rescaled to make a different number of servings
+func (rc RecipeController) ScaleRecipe(recipeID string, servings int, viewer models.Viewer) (models.Recipe, error) {
+	recipe, err := rc.GetRecipe(recipeID, viewer)
+	if err != nil {
+		return models.Recipe{}, err
+	}
+	return scaleRecipe(recipe, servings)
+}
+
+// CanView is true when the recipe is public, belongs to the viewer, or is shared with the viewer's household
+func CanView(recipe models.Recipe, viewer models.Viewer) bool {
+	if viewer.UserName != "" && recipe.UserName == viewer.UserName {
+		return true
+	}
+	switch recipeVisibility(recipe) {
+	case models.VisibilityPublic:
+		return true
+	case models.VisibilityHousehold:
+		return containsString(viewer.HouseholdMembers, recipe.UserName)
+	}
 	return false
 }
 
-func isValidRecipe(recipe models.Recipe) (valid bool, invalidFields []string) {
+// recipeVisibility is the recipe's visibility, working it out from Private for recipes saved without one
+func recipeVisibility(recipe models.Recipe) string {
+	if recipe.Visibility != "" {
+		return recipe.Visibility
+	}
+	if recipe.Private {
+		return models.VisibilityPrivate
+	}
+	return models.VisibilityPublic
+}
+
+func scaleRecipe(recipe models.Recipe, servings int) (models.Recipe, error) {
+	if servings < 1 || recipe.Servings < 1 {
+		return models.Recipe{}, ErrCannotScale
+	}
+	factor := float64(servings) / float64(recipe.Servings)
+	scaledIngredients := make([]models.Ingredient, len(recipe.Ingredients))
+	for i, ingredient := range recipe.Ingredients {
+		unit, err := units.Lookup(ingredient.Measurement)
+		if err != nil {
+			// measurements saved before units were normalized may not be recognized, so just scale the amount
+			ingredient.Amount = float32(float64(ingredient.Amount) * factor)
+		} else {
+			scaled := units.Scale(units.Quantity{Amount: float64(ingredient.Amount), Unit: unit}, factor)
+			ingredient.Amount = float32(scaled.Amount)
+			ingredient.Measurement = scaled.Unit.Name
+		}
+		scaledIngredients[i] = ingredient
+	}
+	if recipe.Nutrition != nil {
+		// per serving nutrition stays the same, only the total changes
+		scaledNutrition := *recipe.Nutrition
+		scaledNutrition.Total = roundNutrients(scaledNutrition.Total, factor)
+		recipe.Nutrition = &scaledNutrition
+	}
+	// calories are per serving, so the scaled total is spread back over the new number of servings
+	totalCalories := float64(recipe.Calories*recipe.Servings) * factor
+	recipe.Calories = int(math.Round(totalCalories / float64(servings)))
+	recipe.Ingredients = scaledIngredients
+	recipe.Servings = servings
+	return recipe, nil
+}
+
+// normalizeFilter rewrites the filter's dates into the format recipe dates are stored in so they compare
+// correctly, returning the fields that can't be used to filter
+func normalizeFilter(filter *models.RecipeFilter) (invalidFields []string) {
+	numbers := map[string]int{
+		"maxPrepTime": filter.MaxPrepTime, "maxCookTime": filter.MaxCookTime, "maxTotalTime": filter.MaxTotalTime,
+		"minCalories": filter.MinCalories, "maxCalories": filter.MaxCalories,
+		"minServings": filter.MinServings, "maxServings": filter.MaxServings,
+	}
+	for field, value := range numbers {
+		if value < 0 {
+			invalidFields = append(invalidFields, "filter."+field)
+		}
+	}
+	dates := map[string]*string{
+		"createdAfter": &filter.CreatedAfter, "createdBefore": &filter.CreatedBefore,
+		"updatedAfter": &filter.UpdatedAfter, "updatedBefore": &filter.UpdatedBefore,
+	}
+	for field, date := range dates {
+		normalized, ok := normalizeDate(*date)
+		if !ok {
+			invalidFields = append(invalidFields, "filter."+field)
+		}
+		*date = normalized
+	}
+	if filter.ForkedFrom != "" && !primitive.IsValidObjectID(filter.ForkedFrom) {
+		invalidFields = append(invalidFields, "filter.forkedFrom")
+	}
+	if filter.MinRating < 0 || filter.MinRating > models.MaxRating {
+		invalidFields = append(invalidFields, "filter.minRating")
+	}
+	allergens, allergensOk := normalizeLabels(filter.ExcludeAllergens, models.Allergens)
+	if !allergensOk {
+		invalidFields = append(invalidFields, "filter.excludeAllergens")
+	}
+	// vegan recipes are labeled vegetarian as well, so asking for vegetarian ones doesn't leave them out
+	diets, dietsOk := normalizeLabels(filter.Diets, models.Diets)
+	if !dietsOk {
+		invalidFields = append(invalidFields, "filter.diets")
+	}
+	filter.ExcludeAllergens = allergens
+	filter.Diets = diets
+	sort.Strings(invalidFields)
+	return invalidFields
+}
+
+// normalizeDate accepts dates in the stored "2006.01.02 15:04:05" format, as just the day, or in ISO 8601
+func normalizeDate(date string) (string, bool) {
+	if date == "" {
+		return "", true
+	}
+	if parsed, err := time.Parse("2006.01.02 15:04:05", date); err == nil {
+		return parsed.Format("2006.01.02 15:04:05"), true
+	}
+	if parsed, err := time.Parse(time.RFC3339, date); err == nil {
+		return parsed.Local().Format("2006.01.02 15:04:05"), true
+	}
+	for _, layout := range []string{"2006.01.02", "2006-01-02"} {
+		if parsed, err := time.Parse(layout, date); err == nil {
+			return parsed.Format("2006.01.02"), true
+		}
+	}
+	return date, false
+}
+
+func invalidSortFields(sorts []models.RecipeSort) (invalidFields []string) {
+	for i, sort := range sorts {
+		if !db.IsSortableRecipeField(sort.Field) {
+			invalidFields = append(invalidFields, fmt.Sprintf("sort[%d].field", i))
+		}
+		direction := strings.ToLower(sort.Direction)
+		if direction != "" && direction != "asc" && direction != "desc" {
+			invalidFields = append(invalidFields, fmt.Sprintf("sort[%d].direction", i))
+		}
+	}
+	return invalidFields
+}
+
+// isValidRecipe checks the recipe's fields. Measurements that aren't units are only accepted when they are
+// in saved, since recipes saved before units were normalized can have any measurement.
+func isValidRecipe(recipe models.Recipe, saved []models.Ingredient) (valid bool, invalidFields []string) {
 	if recipe.RecipeName == "" {
 		invalidFields = append(invalidFields, "recipeName")
 	}
+	switch recipe.Visibility {
+	case "", models.VisibilityPrivate, models.VisibilityHousehold, models.VisibilityPublic:
+	default:
+		invalidFields = append(invalidFields, "visibility")
+	}
+	invalidFields = append(invalidFields, invalidIngredients(recipe.Ingredients, saved)...)
 
 	if len(invalidFields) > 0 {
 		return false, invalidFields
 	}
 	return true, invalidFields
 }
+
+// invalidIngredients returns the fields of any ingredients that could not be understood
+func invalidIngredients(ingredients []models.Ingredient, saved []models.Ingredient) (invalidFields []string) {
+	savedMeasurements := map[string]bool{}
+	for _, ingredient := range saved {
+		savedMeasurements[ingredient.Measurement] = true
+	}
+	for i, ingredient := range ingredients {
+		amount := float64(ingredient.Amount)
+		if ingredient.Quantity != "" {
+			parsed, err := units.ParseAmount(ingredient.Quantity)
+			if err != nil {
+				invalidFields = append(invalidFields, fmt.Sprintf("ingredients[%d].quantity", i))
+			}
+			amount = parsed
+		}
+		if amount < 0 {
+			invalidFields = append(invalidFields, fmt.Sprintf("ingredients[%d].amount", i))
+		}
+		if _, err := units.Lookup(ingredient.Measurement); err != nil && !savedMeasurements[ingredient.Measurement] {
+			invalidFields = append(invalidFields, fmt.Sprintf("ingredients[%d].measurement", i))
+		}
+	}
+	return invalidFields
+}
+
+// normalizeIngredients returns a copy of the ingredients with written quantities parsed into amounts and each
+// measurement replaced with its canonical unit name. Anything that can't be understood is kept as written.
+func normalizeIngredients(ingredients []models.Ingredient) []models.Ingredient {
+	if ingredients == nil {
+		return nil
+	}
+	normalized := make([]models.Ingredient, len(ingredients))
+	for i, ingredient := range ingredients {
+		if amount, err := units.ParseAmount(ingredient.Quantity); ingredient.Quantity != "" && err == nil {
+			ingredient.Amount = float32(amount)
+			ingredient.Quantity = ""
+		}
+		if unit, err := units.Lookup(ingredient.Measurement); err == nil {
+			ingredient.Measurement = unit.Name
+			if unit.Kind == units.ToTaste {
+				ingredient.Amount = 0
+			}
+		}
+		normalized[i] = ingredient
+	}
+	return normalized
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"server/models"
+)
+
+// diffRecipes lists the fields that differ between two versions of a recipe: its details first, then its
+// ingredients matched by name, then its steps matched by position
+func diffRecipes(from models.Recipe, to models.Recipe) []models.FieldChange {
+	changes := []models.FieldChange{}
+	details := []struct {
+		field string
+		from  interface{}
+		to    interface{}
+	}{
+		{"recipeName", from.RecipeName, to.RecipeName},
+		{"author", from.Author, to.Author},
+		{"prepTime", from.PrepTime, to.PrepTime},
+		{"cookTime", from.CookTime, to.CookTime},
+		{"servings", from.Servings, to.Servings},
+		{"calories", from.Calories, to.Calories},
+		{"visibility", recipeVisibility(from), recipeVisibility(to)},
+		{"tags", from.Tags, to.Tags},
+	}
+	for _, detail := range details {
+		// comparing how the values print treats a missing list the same as an empty one
+		if fmt.Sprint(detail.from) != fmt.Sprint(detail.to) {
+			changes = append(changes, models.FieldChange{Field: detail.field, Change: "changed", From: detail.from, To: detail.to})
+		}
+	}
+	changes = append(changes, diffIngredients(from.Ingredients, to.Ingredients)...)
+	return append(changes, diffSteps(from.Steps, to.Steps)...)
+}
+
+func diffIngredients(from []models.Ingredient, to []models.Ingredient) []models.FieldChange {
+	var changes []models.FieldChange
+	remaining := map[string]models.Ingredient{}
+	for _, ingredient := range to {
+		remaining[strings.ToLower(ingredient.Name)] = ingredient
+	}
+	for _, fromIngredient := range from {
+		key := strings.ToLower(fromIngredient.Name)
+		field := "ingredients[" + fromIngredient.Name + "]"
+		toIngredient, ok := remaining[key]
+		if !ok {
+			changes = append(changes, models.FieldChange{Field: field, Change: "removed", From: fromIngredient})
+			continue
+		}
+		delete(remaining, key)
+		if fromIngredient.Amount != toIngredient.Amount {
+			changes = append(changes, models.FieldChange{Field: field + ".amount", Change: "changed", From: fromIngredient.Amount, To: toIngredient.Amount})
+		}
+		if fromIngredient.Measurement != toIngredient.Measurement {
+			changes = append(changes, models.FieldChange{Field: field + ".measurement", Change: "changed", From: fromIngredient.Measurement, To: toIngredient.Measurement})
+		}
+		if fromIngredient.Category != toIngredient.Category {
+			changes = append(changes, models.FieldChange{Field: field + ".category", Change: "changed", From: fromIngredient.Category, To: toIngredient.Category})
+		}
+	}
+	for _, toIngredient := range to {
+		if _, ok := remaining[strings.ToLower(toIngredient.Name)]; ok {
+			changes = append(changes, models.FieldChange{Field: "ingredients[" + toIngredient.Name + "]", Change: "added", To: toIngredient})
+		}
+	}
+	return changes
+}
+
+func diffSteps(from []models.Step, to []models.Step) []models.FieldChange {
+	var changes []models.FieldChange
+	for i := 0; i < len(from) || i < len(to); i++ {
+		field := fmt.Sprintf("steps[%d]", i+1)
+		switch {
+		case i >= len(to):
+			changes = append(changes, models.FieldChange{Field: field, Change: "removed", From: from[i].Text})
+		case i >= len(from):
+			changes = append(changes, models.FieldChange{Field: field, Change: "added", To: to[i].Text})
+		case from[i].Text != to[i].Text:
+			changes = append(changes, models.FieldChange{Field: field, Change: "changed", From: from[i].Text, To: to[i].Text})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"server/models"
+)
+
+var ErrInvalidLabels = errors.New("invalid dietary labels")
+
+// impliedDiets are the diets that something fitting a diet also fits
+var impliedDiets = map[string][]string{
+	models.DietVegan:      {models.DietVegetarian, models.DietPescatarian},
+	models.DietVegetarian: {models.DietPescatarian},
+}
+
+// recipeLabels combines the labels of the catalog ingredients the recipe's ingredients match
+func recipeLabels(ingredients []models.Ingredient, catalog []models.Ingredient) models.RecipeLabels {
+	index := catalogIndex(catalog)
+	labels := models.RecipeLabels{Allergens: []string{}, Diets: []string{}}
+	diets := append([]string{}, models.Diets...)
+	for _, ingredient := range ingredients {
+		match, ok := matchCatalog(ingredient.Name, index, func(match models.Ingredient) bool { return match.Labels != nil })
+		if !ok {
+			labels.UnlabeledIngredients = append(labels.UnlabeledIngredients, ingredient.Name)
+			continue
+		}
+		labels.Allergens = append(labels.Allergens, match.Labels.Allergens...)
+		var kept []string
+		for _, diet := range diets {
+			if containsString(match.Labels.Diets, diet) {
+				kept = append(kept, diet)
+			}
+		}
+		diets = kept
+	}
+	labels.Allergens, _ = normalizeLabels(labels.Allergens, models.Allergens)
+	if len(ingredients) > 0 && len(labels.UnlabeledIngredients) == 0 {
+		labels.Diets, _ = normalizeLabels(diets, models.Diets)
+	}
+	return labels
+}
+
+// normalizeLabels lowercases the labels and puts them in the order they are known in without duplicates,
+// returning false when any of them isn't known
+func normalizeLabels(labels []string, known []string) ([]string, bool) {
+	valid := true
+	for _, label := range labels {
+		if !containsString(known, strings.ToLower(strings.TrimSpace(label))) {
+			valid = false
+		}
+	}
+	normalized := []string{}
+	for _, label := range known {
+		for _, candidate := range labels {
+			if strings.ToLower(strings.TrimSpace(candidate)) == label {
+				normalized = append(normalized, label)
+				break
+			}
+		}
+	}
+	return normalized, valid
+}
+
+// normalizeDiets normalizes diets like normalizeLabels, adding the diets they imply
+func normalizeDiets(diets []string) ([]string, bool) {
+	normalized, valid := normalizeLabels(diets, models.Diets)
+	for _, diet := range normalized {
+		diets = append(diets, impliedDiets[diet]...)
+	}
+	normalized, _ = normalizeLabels(diets, models.Diets)
+	return normalized, valid
+}
+
+// normalizeIngredientLabels checks the labels of a catalog ingredient and normalizes them
+func normalizeIngredientLabels(labels *models.DietaryLabels) error {
+	var invalidFields []string
+	allergens, allergensOk := normalizeLabels(labels.Allergens, models.Allergens)
+	diets, dietsOk := normalizeDiets(labels.Diets)
+	if !allergensOk {
+		invalidFields = append(invalidFields, "labels.allergens")
+	}
+	if !dietsOk {
+		invalidFields = append(invalidFields, "labels.diets")
+	}
+	if len(invalidFields) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidLabels, strings.Join(invalidFields, ", "))
+	}
+	labels.Allergens = allergens
+	labels.Diets = diets
+	return nil
+}
+
+// applyRestrictions adds dietary restrictions to a filter's allergens and diets
+func applyRestrictions(filter *models.RecipeFilter, restrictions models.DietaryRestrictions) {
+	filter.ExcludeAllergens = append(filter.ExcludeAllergens, restrictions.Allergens...)
+	filter.Diets = append(filter.Diets, restrictions.Diets...)
+}
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"math"
+	"strings"
+
+	"server/models"
+	"server/units"
+)
+
+// recipeNutrition adds up the nutrients of each ingredient from the facts of the catalog ingredient it
+// matches. Per serving is the same as the total for recipes that don't say how many they serve.
+func recipeNutrition(ingredients []models.Ingredient, servings int, catalog []models.Ingredient) models.RecipeNutrition {
+	index := catalogIndex(catalog)
+	nutrition := models.RecipeNutrition{Complete: true}
+	for _, ingredient := range ingredients {
+		if ingredient.Measurement == units.Taste.Name {
+			continue
+		}
+		ratio, ok := 0.0, false
+		match, found := matchCatalog(ingredient.Name, index, func(match models.Ingredient) bool { return match.Nutrition != nil })
+		if found {
+			ratio, ok = factsRatio(ingredient, *match.Nutrition)
+		}
+		if ok {
+			nutrition.Total = addNutrients(nutrition.Total, match.Nutrition.Nutrients, ratio)
+		} else {
+			nutrition.Complete = false
+			nutrition.MissingIngredients = append(nutrition.MissingIngredients, ingredient.Name)
+		}
+	}
+	nutrition.Total = roundNutrients(nutrition.Total, 1)
+	nutrition.PerServing = nutrition.Total
+	if servings > 0 {
+		nutrition.PerServing = roundNutrients(nutrition.Total, 1/float64(servings))
+	}
+	return nutrition
+}
+
+// catalogIndex looks up catalog ingredients by their name regardless of case
+func catalogIndex(catalog []models.Ingredient) map[string]models.Ingredient {
+	index := map[string]models.Ingredient{}
+	for _, ingredient := range catalog {
+		index[strings.ToLower(strings.TrimSpace(ingredient.Name))] = ingredient
+	}
+	return index
+}
+
+// matchCatalog finds the most specific catalog ingredient a recipe ingredient might be that has what is needed
+func matchCatalog(name string, index map[string]models.Ingredient, has func(models.Ingredient) bool) (models.Ingredient, bool) {
+	for _, candidate := range catalogNames(name) {
+		if match, ok := index[candidate]; ok && has(match) {
+			return match, true
+		}
+	}
+	return models.Ingredient{}, false
+}
+
+// ingredientNames lists every name the recipe's ingredients might be in the catalog under
+func ingredientNames(ingredients []models.Ingredient) []string {
+	names := []string{}
+	for _, ingredient := range ingredients {
+		for _, name := range catalogNames(ingredient.Name) {
+			if !containsString(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// catalogNames lists the names an ingredient might be in the catalog under, from the most to the least
+// specific, so "Eggs, beaten" is looked for as "eggs, beaten", "eggs" and "egg"
+func catalogNames(name string) []string {
+	var names []string
+	add := func(candidate string) {
+		candidate = strings.TrimSpace(candidate)
+		if candidate != "" && !containsString(names, candidate) {
+			names = append(names, candidate)
+		}
+	}
+	lower := strings.ToLower(strings.TrimSpace(name))
+	add(lower)
+	base := strings.TrimSpace(strings.SplitN(lower, ",", 2)[0])
+	add(base)
+	if strings.HasSuffix(base, "es") {
+		add(strings.TrimSuffix(base, "es"))
+	}
+	if strings.HasSuffix(base, "s") {
+		add(strings.TrimSuffix(base, "s"))
+	}
+	return names
+}
+
+// factsRatio is how many of the facts' amount the ingredient's amount is. Volumes and weights are converted
+// into each other with the facts' density, measurements that aren't units only match exactly.
+func factsRatio(ingredient models.Ingredient, facts models.NutritionFacts) (float64, bool) {
+	if ingredient.Amount <= 0 || facts.Amount <= 0 {
+		return 0, false
+	}
+	amount := float64(ingredient.Amount)
+	from, fromErr := units.Lookup(ingredient.Measurement)
+	to, toErr := units.Lookup(facts.Measurement)
+	if fromErr != nil || toErr != nil {
+		if strings.EqualFold(strings.TrimSpace(ingredient.Measurement), strings.TrimSpace(facts.Measurement)) {
+			return amount / facts.Amount, true
+		}
+		return 0, false
+	}
+	if converted, err := units.Convert(amount, from, to); err == nil {
+		return converted / facts.Amount, true
+	}
+	if facts.Density <= 0 {
+		return 0, false
+	}
+	// volumes are based on milliliters and weights on grams, so the density converts between the two bases
+	base := amount * from.Factor
+	switch {
+	case from.Kind == units.Volume && to.Kind == units.Mass:
+		base *= facts.Density
+	case from.Kind == units.Mass && to.Kind == units.Volume:
+		base /= facts.Density
+	default:
+		return 0, false
+	}
+	return base / to.Factor / facts.Amount, true
+}
+
+func addNutrients(total models.Nutrients, nutrients models.Nutrients, ratio float64) models.Nutrients {
+	return models.Nutrients{
+		Calories: total.Calories + nutrients.Calories*ratio,
+		Protein:  total.Protein + nutrients.Protein*ratio,
+		Fat:      total.Fat + nutrients.Fat*ratio,
+		Carbs:    total.Carbs + nutrients.Carbs*ratio,
+		Fiber:    total.Fiber + nutrients.Fiber*ratio,
+		Sodium:   total.Sodium + nutrients.Sodium*ratio,
+		Sugar:    total.Sugar + nutrients.Sugar*ratio,
+	}
+}
+
+// roundNutrients multiplies the nutrients by factor and rounds them to one decimal place
+func roundNutrients(nutrients models.Nutrients, factor float64) models.Nutrients {
+	round := func(value float64) float64 {
+		return math.Round(value*factor*10) / 10
+	}
+	return models.Nutrients{
+		Calories: round(nutrients.Calories),
+		Protein:  round(nutrients.Protein),
+		Fat:      round(nutrients.Fat),
+		Carbs:    round(nutrients.Carbs),
+		Fiber:    round(nutrients.Fiber),
+		Sodium:   round(nutrients.Sodium),
+		Sugar:    round(nutrients.Sugar),
+	}
+}
+
+// invalidNutritionFields lists what is wrong with nutrition facts before they go into the catalog
+func invalidNutritionFields(facts models.NutritionFacts) (invalidFields []string) {
+	if facts.Amount <= 0 {
+		invalidFields = append(invalidFields, "nutrition.amount")
+	}
+	if unit, err := units.Lookup(facts.Measurement); err == nil && unit.Kind == units.ToTaste {
+		invalidFields = append(invalidFields, "nutrition.measurement")
+	}
+	if facts.Density < 0 {
+		invalidFields = append(invalidFields, "nutrition.density")
+	}
+	n := facts.Nutrients
+	if n.Calories < 0 || n.Protein < 0 || n.Fat < 0 || n.Carbs < 0 || n.Fiber < 0 || n.Sodium < 0 || n.Sugar < 0 {
+		invalidFields = append(invalidFields, "nutrition.nutrients")
+	}
+	return invalidFields
+}
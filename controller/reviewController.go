@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"server/db"
+	"server/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ReviewControl interface {
+	GetReviews(recipeID string, viewer models.Viewer) ([]models.Review, error)
+	SaveReview(recipeID string, review models.Review, viewer models.Viewer) (models.Review, error)
+	DeleteReview(recipeID string, viewer models.Viewer) error
+	DeleteRecipeReviews(recipeID string) error
+}
+
+var (
+	ErrInvalidRating  = fmt.Errorf("rating must be from %d to %d", models.MinRating, models.MaxRating)
+	ErrReviewNotFound = errors.New("review not found")
+)
+
+type ReviewController struct {
+	reviewRepo db.ReviewDB
+	recipeRepo db.RecipeDB
+}
+
+func NewReviewController(rv db.ReviewDB, rr db.RecipeDB) ReviewController {
+	return ReviewController{reviewRepo: rv, recipeRepo: rr}
+}
+
+// GetReviews - lists the reviews of a recipe the viewer can see, the most recent first
+func (rc ReviewController) GetReviews(recipeID string, viewer models.Viewer) ([]models.Review, error) {
+	if _, err := rc.visibleRecipe(recipeID, viewer); err != nil {
+		return []models.Review{}, err
+	}
+	return rc.reviewRepo.GetReviews(recipeID)
+}
+
+// SaveReview - rates a recipe the viewer can see, replacing the viewer's earlier review of it if they have one.
+// The recipe's average rating and rating count are worked out again afterwards.
+func (rc ReviewController) SaveReview(recipeID string, review models.Review, viewer models.Viewer) (models.Review, error) {
+	if review.Rating < models.MinRating || review.Rating > models.MaxRating {
+		return models.Review{}, ErrInvalidRating
+	}
+	recipe, err := rc.visibleRecipe(recipeID, viewer)
+	if err != nil {
+		return models.Review{}, err
+	}
+	currentTime := time.Now().Format("2006.01.02 15:04:05")
+	review.RecipeID = recipe.RecipeID
+	review.UserName = viewer.UserName
+	review.Text = strings.TrimSpace(review.Text)
+	review.CreatedDate = currentTime
+	review.LastUpdatedDate = currentTime
+	saved, err := rc.reviewRepo.SaveReview(review)
+	if err != nil {
+		return models.Review{}, err
+	}
+	return saved, rc.refreshRating(recipeID)
+}
+
+// DeleteReview - removes the viewer's own review of a recipe
+func (rc ReviewController) DeleteReview(recipeID string, viewer models.Viewer) error {
+	if _, err := rc.visibleRecipe(recipeID, viewer); err != nil {
+		return err
+	}
+	err := rc.reviewRepo.DeleteReview(recipeID, viewer.UserName)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrReviewNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return rc.refreshRating(recipeID)
+}
+
+// DeleteRecipeReviews - removes every review of a recipe that is being deleted
+func (rc ReviewController) DeleteRecipeReviews(recipeID string) error {
+	return rc.reviewRepo.DeleteReviews(recipeID)
+}
+
+func (rc ReviewController) visibleRecipe(recipeID string, viewer models.Viewer) (models.Recipe, error) {
+	if !primitive.IsValidObjectID(recipeID) {
+		return models.Recipe{}, ErrRecipeNotFound
+	}
+	recipe, err := rc.recipeRepo.GetRecipe(recipeID)
+	if err != nil || !CanView(recipe, viewer) {
+		return models.Recipe{}, ErrRecipeNotFound
+	}
+	return recipe, nil
+}
+
+// refreshRating stores the recipe's rating summary from all of its reviews, rather than adjusting the old
+// summary, so reviews saved at the same time can't leave it off
+func (rc ReviewController) refreshRating(recipeID string) error {
+	average, count, err := rc.reviewRepo.GetRatingSummary(recipeID)
+	if err != nil {
+		return err
+	}
+	return rc.recipeRepo.UpdateRating(recipeID, math.Round(average*100)/100, count)
+}
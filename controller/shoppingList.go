@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"sort"
+	"strings"
+
+	"server/models"
+	"server/units"
+)
+
+// basketCategories are the categories a Basket has room for, in the order they are listed.
+// Anything else is shopped for with the pantry items.
+var basketCategories = []string{"Produce", "Protein", "Dairy", "Pantry", "Alcohol"}
+
+const otherCategory = "Other"
+
+type shoppingListEntry struct {
+	item     models.ShoppingListItem
+	category string
+	quantity units.Quantity
+	// known is false when the measurement isn't a recognized unit, those only merge with the exact same measurement
+	known bool
+}
+
+// buildShoppingList merges the ingredients of every recipe by name and compatible unit and groups them by category
+func buildShoppingList(recipes []models.Recipe) []models.ShoppingListCategory {
+	var entries []*shoppingListEntry
+	for _, recipe := range recipes {
+		for _, ingredient := range recipe.Ingredients {
+			name := strings.ToLower(strings.TrimSpace(ingredient.Name))
+			if name == "" {
+				continue
+			}
+			unit, err := units.Lookup(ingredient.Measurement)
+			known := err == nil
+			if !known {
+				unit = units.Unit{Name: ingredient.Measurement}
+			}
+			quantity := units.Quantity{Amount: float64(ingredient.Amount), Unit: unit}
+
+			entry := findShoppingListEntry(entries, name, quantity.Unit, known)
+			if entry == nil {
+				entry = &shoppingListEntry{
+					item:     models.ShoppingListItem{Name: name},
+					category: ingredient.Category,
+					quantity: quantity,
+					known:    known,
+				}
+				entries = append(entries, entry)
+			} else {
+				entry.quantity, _ = units.Add(entry.quantity, quantity)
+			}
+			if entry.category == "" {
+				entry.category = ingredient.Category
+			}
+			if recipe.RecipeName != "" && !containsString(entry.item.Recipes, recipe.RecipeName) {
+				entry.item.Recipes = append(entry.item.Recipes, recipe.RecipeName)
+			}
+		}
+	}
+	return groupShoppingListEntries(entries)
+}
+
+func findShoppingListEntry(entries []*shoppingListEntry, name string, unit units.Unit, known bool) *shoppingListEntry {
+	for _, entry := range entries {
+		if entry.item.Name != name || entry.known != known {
+			continue
+		}
+		if (known && units.Compatible(entry.quantity.Unit, unit)) || entry.quantity.Unit.Name == unit.Name {
+			return entry
+		}
+	}
+	return nil
+}
+
+func groupShoppingListEntries(entries []*shoppingListEntry) []models.ShoppingListCategory {
+	itemsByCategory := map[string][]models.ShoppingListItem{}
+	for _, entry := range entries {
+		quantity := entry.quantity
+		if entry.known {
+			quantity = units.Round(units.Simplify(quantity))
+		}
+		entry.item.Amount = float32(quantity.Amount)
+		entry.item.Measurement = quantity.Unit.Name
+		category := normalizeCategory(entry.category)
+		itemsByCategory[category] = append(itemsByCategory[category], entry.item)
+	}
+
+	var categoryNames []string
+	for category := range itemsByCategory {
+		categoryNames = append(categoryNames, category)
+	}
+	sort.Slice(categoryNames, func(i, j int) bool {
+		return categoryRank(categoryNames[i]) < categoryRank(categoryNames[j]) ||
+			(categoryRank(categoryNames[i]) == categoryRank(categoryNames[j]) && categoryNames[i] < categoryNames[j])
+	})
+
+	categories := []models.ShoppingListCategory{}
+	for _, category := range categoryNames {
+		items := itemsByCategory[category]
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+		categories = append(categories, models.ShoppingListCategory{Category: category, Items: items})
+	}
+	return categories
+}
+
+// normalizeCategory matches categories against the basket's regardless of case
+func normalizeCategory(category string) string {
+	trimmed := strings.TrimSpace(category)
+	if trimmed == "" {
+		return otherCategory
+	}
+	for _, basketCategory := range basketCategories {
+		if strings.EqualFold(trimmed, basketCategory) {
+			return basketCategory
+		}
+	}
+	return trimmed
+}
+
+// categoryRank orders the basket's categories first, then everything else, then uncategorized items
+func categoryRank(category string) int {
+	for i, basketCategory := range basketCategories {
+		if category == basketCategory {
+			return i
+		}
+	}
+	if category == otherCategory {
+		return len(basketCategories) + 1
+	}
+	return len(basketCategories)
+}
+
+// ShoppingListToBasket flattens a shopping list into the basket that gets emailed to a user
+func ShoppingListToBasket(list models.ShoppingList, userName string) models.Basket {
+	basket := models.Basket{UserName: userName}
+	for _, category := range list.Categories {
+		for _, item := range category.Items {
+			line := units.FormatIngredient(float64(item.Amount), item.Measurement, item.Name)
+			switch category.Category {
+			case "Produce":
+				basket.Produce = append(basket.Produce, line)
+			case "Protein":
+				basket.Protein = append(basket.Protein, line)
+			case "Dairy":
+				basket.Dairy = append(basket.Dairy, line)
+			case "Alcohol":
+				basket.Alcohol = append(basket.Alcohol, line)
+			default:
+				basket.Pantry = append(basket.Pantry, line)
+			}
+		}
+	}
+	return basket
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -2,12 +2,14 @@ package controller
 
 import (
 	"errors"
+	"fmt"
 	"golang.org/x/crypto/bcrypt"
 	"math/rand"
 	"net/smtp"
 	"server/config"
 	"server/db"
 	"server/models"
+	"strings"
 	"time"
 )
 
@@ -25,6 +27,8 @@ type UserControl interface {
 	DeleteUser(userName string, repository db.UserDeleter) error
 	GenerateUserToken(authData models.AuthData, repository db.UserGetterUpdater) (string, error)
 	EmailUser(basket models.Basket, token string, repository db.UserGetter) error
+	GetRestrictions(userName string, repository db.UserGetter) (models.DietaryRestrictions, error)
+	UpdateRestrictions(userName string, restrictions models.DietaryRestrictions, repository db.UserGetterUpdater) (models.DietaryRestrictions, error)
 }
 
 type UserController struct {
@@ -95,6 +99,41 @@ func (uc UserController) GenerateUserToken(authData models.AuthData, repository
 	return user.AccessToken, nil
 }
 
+// GetRestrictions - gets the allergens and diets a user has declared
+func (uc UserController) GetRestrictions(userName string, repository db.UserGetter) (models.DietaryRestrictions, error) {
+	user, err := repository.GetUser(userName, "")
+	if err != nil {
+		return models.DietaryRestrictions{}, err
+	}
+	return user.Restrictions, nil
+}
+
+// UpdateRestrictions - replaces the allergens a user avoids and the diets they keep
+func (uc UserController) UpdateRestrictions(userName string, restrictions models.DietaryRestrictions, repository db.UserGetterUpdater) (models.DietaryRestrictions, error) {
+	allergens, allergensOk := normalizeLabels(restrictions.Allergens, models.Allergens)
+	diets, dietsOk := normalizeLabels(restrictions.Diets, models.Diets)
+	var invalidFields []string
+	if !allergensOk {
+		invalidFields = append(invalidFields, "allergens")
+	}
+	if !dietsOk {
+		invalidFields = append(invalidFields, "diets")
+	}
+	if len(invalidFields) > 0 {
+		return models.DietaryRestrictions{}, fmt.Errorf("%w: %s", ErrInvalidLabels, strings.Join(invalidFields, ", "))
+	}
+	user, err := repository.GetUser(userName, "")
+	if err != nil {
+		return models.DietaryRestrictions{}, err
+	}
+	user.Restrictions = models.DietaryRestrictions{Allergens: allergens, Diets: diets}
+	updatedUser, err := repository.UpdateUser(user)
+	if err != nil {
+		return models.DietaryRestrictions{}, err
+	}
+	return updatedUser.Restrictions, nil
+}
+
 func (uc UserController) EmailUser(basket models.Basket, token string, repository db.UserGetter) error {
 	userEmail, err := uc.lookUpUserEmail(token, repository)
 
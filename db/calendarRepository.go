@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"server/models"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -24,6 +25,9 @@ type CalendarGetterUpdater interface {
 
 type CalendarGetter interface {
 	GetCalendar(householdID string, startDate string) (models.Calendar, error)
+	GetCalendarByID(calendarID string) (models.Calendar, error)
+	GetCalendars(householdID string, query models.CalendarQuery) ([]models.Calendar, error)
+	CountCalendars(householdID string, query models.CalendarQuery) (int64, error)
 }
 
 type CalendarCreator interface {
@@ -31,11 +35,14 @@ type CalendarCreator interface {
 }
 
 type CalendarDeleter interface {
-	DeleteCalendar(householdID string) error
+	DeleteCalendar(householdID string, calendarID string) error
 }
 
 type CalendarUpdater interface {
 	UpdateCalendar(updatedCalendar models.Calendar) (models.Calendar, error)
+	SetCalendarDay(householdID string, calendarID string, day string, planned models.PlannedRecipe) (models.Calendar, error)
+	ClearCalendarDay(householdID string, calendarID string, day string) (models.Calendar, error)
+	SwapCalendarDays(householdID string, calendarID string, day string, otherDay string) (models.Calendar, error)
 }
 
 type CalendarRepository struct {
@@ -43,9 +50,19 @@ type CalendarRepository struct {
 }
 
 func NewCalendarRepository(client *mongo.Client) *CalendarRepository {
-	return &CalendarRepository{
+	repository := &CalendarRepository{
 		calendarCollection: client.Database("tastyBoiDatabase").Collection("calendarCollection"),
 	}
+	// a household plans each week on one calendar
+	uniqueWeek := mongo.IndexModel{
+		Keys:    bson.D{{Key: "householdID", Value: 1}, {Key: "startdate", Value: 1}},
+		Options: options.Index().SetName("oneCalendarPerWeek").SetUnique(true),
+	}
+	if _, err := repository.calendarCollection.Indexes().CreateOne(context.Background(), uniqueWeek); err != nil {
+		fmt.Println("Could not create calendar index")
+		fmt.Println(err)
+	}
+	return repository
 }
 
 func (c CalendarRepository) GetCalendar(householdID string, startDate string) (models.Calendar, error) {
@@ -64,6 +81,61 @@ func (c CalendarRepository) GetCalendar(householdID string, startDate string) (m
 	return result, nil
 }
 
+func (c CalendarRepository) GetCalendarByID(calendarID string) (models.Calendar, error) {
+	result := models.Calendar{}
+	id, _ := primitive.ObjectIDFromHex(calendarID)
+	filter := bson.M{"_id": id}
+	err := c.calendarCollection.FindOne(context.Background(), filter).Decode(&result)
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// GetCalendars gets a page of a household's calendars in the query's range, the earliest first. A PageSize of 0
+// gets every calendar in the range.
+func (c CalendarRepository) GetCalendars(householdID string, query models.CalendarQuery) ([]models.Calendar, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "startdate", Value: 1}, {Key: "_id", Value: 1}})
+	if query.PageSize > 0 {
+		opts.SetSkip(int64(query.PageCount) * query.PageSize).SetLimit(query.PageSize)
+	}
+	cur, err := c.calendarCollection.Find(context.Background(), calendarRangeFilter(householdID, query), opts)
+	if err != nil {
+		return []models.Calendar{}, err
+	}
+	defer cur.Close(context.Background())
+
+	calendars := []models.Calendar{}
+	if err := cur.All(context.Background(), &calendars); err != nil {
+		return []models.Calendar{}, err
+	}
+	return calendars, nil
+}
+
+// CountCalendars counts a household's calendars in the query's range
+func (c CalendarRepository) CountCalendars(householdID string, query models.CalendarQuery) (int64, error) {
+	return c.calendarCollection.CountDocuments(context.Background(), calendarRangeFilter(householdID, query))
+}
+
+// calendarRangeFilter matches a household's calendars starting from the query's From date up to but not including
+// its To date. Dates are compared as written, so they should be like 2006.01.02, and an empty one leaves that end of
+// the range open.
+func calendarRangeFilter(householdID string, query models.CalendarQuery) bson.M {
+	householdIDObject, _ := primitive.ObjectIDFromHex(householdID)
+	filter := bson.M{"householdID": householdIDObject}
+	startDate := bson.M{}
+	if query.From != "" {
+		startDate["$gte"] = query.From
+	}
+	if query.To != "" {
+		startDate["$lt"] = query.To
+	}
+	if len(startDate) > 0 {
+		filter["startdate"] = startDate
+	}
+	return filter
+}
+
 func (c CalendarRepository) CreateCalendar(calendar models.Calendar) (models.Calendar, error) {
 	result, err := c.calendarCollection.InsertOne(context.Background(), calendar)
 
@@ -75,19 +147,68 @@ func (c CalendarRepository) CreateCalendar(calendar models.Calendar) (models.Cal
 	return calendar, nil
 }
 
+// UpdateCalendar replaces one of the household's calendars at the version in updatedCalendar and moves it to the
+// next version. It returns ErrVersionConflict when the calendar has changed since or the household doesn't have it.
 func (c CalendarRepository) UpdateCalendar(updatedCalendar models.Calendar) (models.Calendar, error) {
-	filter := bson.M{"_id": updatedCalendar.CalendarID}
-	opts := options.Replace().SetUpsert(true)
-	result, err := c.calendarCollection.ReplaceOne(context.Background(), filter, updatedCalendar, opts)
+	filter := bson.M{
+		"_id":         updatedCalendar.CalendarID,
+		"householdID": updatedCalendar.HouseholdID,
+		"version":     versionFilter(updatedCalendar.Version),
+	}
+	updatedCalendar.Version++
+	result, err := c.calendarCollection.ReplaceOne(context.Background(), filter, updatedCalendar)
 	if err != nil {
 		return models.Calendar{}, err
 	}
-	if result.UpsertedID != nil {
-		updatedCalendar.CalendarID = result.UpsertedID.(primitive.ObjectID)
+	if result.MatchedCount == 0 {
+		return models.Calendar{}, ErrVersionConflict
 	}
 	return updatedCalendar, nil
 }
 
-func (c CalendarRepository) DeleteCalendar(householdID string) error {
-	panic("implement me")
+// DeleteCalendar deletes one of a household's calendars, returning mongo.ErrNoDocuments when the household
+// doesn't have it
+func (c CalendarRepository) DeleteCalendar(householdID string, calendarID string) error {
+	householdIDObject, _ := primitive.ObjectIDFromHex(householdID)
+	id, _ := primitive.ObjectIDFromHex(calendarID)
+	result, err := c.calendarCollection.DeleteOne(context.Background(), bson.M{"_id": id, "householdID": householdIDObject})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetCalendarDay plans a recipe on a day of one of a household's calendars and moves it to the next version, returning
+// mongo.ErrNoDocuments when the household doesn't have the calendar. Day is the day's lowercase name.
+func (c CalendarRepository) SetCalendarDay(householdID string, calendarID string, day string, planned models.PlannedRecipe) (models.Calendar, error) {
+	return c.updateCalendarDays(householdID, calendarID, bson.M{"$set": bson.M{day: planned}, "$inc": bson.M{"version": 1}})
+}
+
+// ClearCalendarDay removes what was planned on a day of one of a household's calendars like SetCalendarDay
+func (c CalendarRepository) ClearCalendarDay(householdID string, calendarID string, day string) (models.Calendar, error) {
+	return c.updateCalendarDays(householdID, calendarID, bson.M{"$unset": bson.M{day: ""}, "$inc": bson.M{"version": 1}})
+}
+
+// SwapCalendarDays swaps what was planned on two days of one of a household's calendars like SetCalendarDay. The
+// swap is a single pipeline update so nothing can be planned on either day in between.
+func (c CalendarRepository) SwapCalendarDays(householdID string, calendarID string, day string, otherDay string) (models.Calendar, error) {
+	pipeline := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		day:       bson.M{"$ifNull": bson.A{"$" + otherDay, "$$REMOVE"}},
+		otherDay:  bson.M{"$ifNull": bson.A{"$" + day, "$$REMOVE"}},
+		"version": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}},
+	}}}}
+	return c.updateCalendarDays(householdID, calendarID, pipeline)
+}
+
+// updateCalendarDays applies an update to one of a household's calendars and gets the calendar after it
+func (c CalendarRepository) updateCalendarDays(householdID string, calendarID string, update interface{}) (models.Calendar, error) {
+	householdIDObject, _ := primitive.ObjectIDFromHex(householdID)
+	id, _ := primitive.ObjectIDFromHex(calendarID)
+	result := models.Calendar{}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := c.calendarCollection.FindOneAndUpdate(context.Background(), bson.M{"_id": id, "householdID": householdIDObject}, update, opts).Decode(&result)
+	return result, err
 }
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CollectionDB interface {
+	CollectionGetter
+	CollectionCreator
+	CollectionUpdater
+	CollectionDeleter
+}
+
+type CollectionGetter interface {
+	GetCollection(collectionID string) (models.Collection, error)
+	GetCollections(userName string, householdMembers []string) ([]models.Collection, error)
+}
+
+type CollectionCreator interface {
+	CreateCollection(collection *models.Collection) error
+}
+
+type CollectionUpdater interface {
+	UpdateCollection(collectionID string, name string, shared bool, date string) (models.Collection, error)
+	AddRecipeToCollection(collectionID string, recipeID primitive.ObjectID, date string) (models.Collection, error)
+	RemoveRecipeFromCollection(collectionID string, recipeID primitive.ObjectID, date string) (models.Collection, error)
+	RemoveRecipeFromCollections(recipeID string) error
+}
+
+type CollectionDeleter interface {
+	DeleteCollection(collectionID string) error
+}
+
+type CollectionRepository struct {
+	collectionCollection *mongo.Collection
+}
+
+func NewCollectionRepository(client *mongo.Client) *CollectionRepository {
+	return &CollectionRepository{
+		collectionCollection: client.Database("tastyBoiDatabase").Collection("collectionCollection"),
+	}
+}
+
+func (c CollectionRepository) GetCollection(collectionID string) (models.Collection, error) {
+	result := models.Collection{}
+	id, _ := primitive.ObjectIDFromHex(collectionID)
+	err := c.collectionCollection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&result)
+	return result, err
+}
+
+// GetCollections gets the user's own collections and the ones the rest of their household shared, by name
+func (c CollectionRepository) GetCollections(userName string, householdMembers []string) ([]models.Collection, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"username": userName},
+		bson.M{"username": bson.M{"$in": householdMembers}, "shared": true},
+	}}
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := c.collectionCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return []models.Collection{}, err
+	}
+	defer cur.Close(context.Background())
+
+	collections := []models.Collection{}
+	if err := cur.All(context.Background(), &collections); err != nil {
+		return []models.Collection{}, err
+	}
+	return collections, nil
+}
+
+func (c CollectionRepository) CreateCollection(collection *models.Collection) error {
+	result, err := c.collectionCollection.InsertOne(context.Background(), collection)
+	if err != nil {
+		return err
+	}
+	collection.CollectionID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// UpdateCollection renames a collection and changes whether it is shared
+func (c CollectionRepository) UpdateCollection(collectionID string, name string, shared bool, date string) (models.Collection, error) {
+	return c.updateCollection(collectionID, bson.M{"$set": bson.M{"name": name, "shared": shared, "lastupdateddate": date}})
+}
+
+// AddRecipeToCollection adds a recipe to the end of a collection unless it is already in it
+func (c CollectionRepository) AddRecipeToCollection(collectionID string, recipeID primitive.ObjectID, date string) (models.Collection, error) {
+	return c.updateCollection(collectionID, bson.M{
+		"$addToSet": bson.M{"recipeids": recipeID},
+		"$set":      bson.M{"lastupdateddate": date},
+	})
+}
+
+func (c CollectionRepository) RemoveRecipeFromCollection(collectionID string, recipeID primitive.ObjectID, date string) (models.Collection, error) {
+	return c.updateCollection(collectionID, bson.M{
+		"$pull": bson.M{"recipeids": recipeID},
+		"$set":  bson.M{"lastupdateddate": date},
+	})
+}
+
+// RemoveRecipeFromCollections takes a deleted recipe out of every collection it was saved to
+func (c CollectionRepository) RemoveRecipeFromCollections(recipeID string) error {
+	id, _ := primitive.ObjectIDFromHex(recipeID)
+	_, err := c.collectionCollection.UpdateMany(context.Background(),
+		bson.M{"recipeids": id},
+		bson.M{"$pull": bson.M{"recipeids": id}})
+	return err
+}
+
+func (c CollectionRepository) DeleteCollection(collectionID string) error {
+	id, _ := primitive.ObjectIDFromHex(collectionID)
+	result, err := c.collectionCollection.DeleteOne(context.Background(), bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount != 1 {
+		return errors.New("nothing was deleted")
+	}
+	return nil
+}
+
+// updateCollection applies the update to a collection and returns it as it is afterwards
+func (c CollectionRepository) updateCollection(collectionID string, update bson.M) (models.Collection, error) {
+	result := models.Collection{}
+	id, _ := primitive.ObjectIDFromHex(collectionID)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := c.collectionCollection.FindOneAndUpdate(context.Background(), bson.M{"_id": id}, update, opts).Decode(&result)
+	return result, err
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type HistoryDB interface {
+	HistoryGetter
+	HistoryCreator
+}
+
+type HistoryGetter interface {
+	GetCookedEntries(householdID string, query models.CookedHistoryQuery) ([]models.CookedEntry, error)
+}
+
+type HistoryCreator interface {
+	CreateCookedEntry(entry *models.CookedEntry) error
+	SaveCalendarCookedEntry(entry *models.CookedEntry) error
+}
+
+type HistoryRepository struct {
+	historyCollection *mongo.Collection
+}
+
+func NewHistoryRepository(client *mongo.Client) *HistoryRepository {
+	return &HistoryRepository{
+		historyCollection: client.Database("tastyBoiDatabase").Collection("historyCollection"),
+	}
+}
+
+// GetCookedEntries gets a household's cooking history matching the query, the most recent first
+func (h HistoryRepository) GetCookedEntries(householdID string, query models.CookedHistoryQuery) ([]models.CookedEntry, error) {
+	id, _ := primitive.ObjectIDFromHex(householdID)
+	filter := bson.M{"householdid": id}
+	cookedDate := bson.M{}
+	if query.From != "" {
+		cookedDate["$gte"] = query.From
+	}
+	if query.To != "" {
+		cookedDate["$lt"] = query.To
+	}
+	if len(cookedDate) > 0 {
+		filter["cookeddate"] = cookedDate
+	}
+	if query.RecipeID != "" {
+		recipeID, _ := primitive.ObjectIDFromHex(query.RecipeID)
+		filter["recipeid"] = recipeID
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "cookeddate", Value: -1}, {Key: "createddate", Value: -1}})
+	if query.Limit > 0 {
+		opts.SetLimit(query.Limit)
+	}
+	cur, err := h.historyCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return []models.CookedEntry{}, err
+	}
+	defer cur.Close(context.Background())
+
+	entries := []models.CookedEntry{}
+	if err := cur.All(context.Background(), &entries); err != nil {
+		return []models.CookedEntry{}, err
+	}
+	return entries, nil
+}
+
+func (h HistoryRepository) CreateCookedEntry(entry *models.CookedEntry) error {
+	result, err := h.historyCollection.InsertOne(context.Background(), entry)
+	if err != nil {
+		return err
+	}
+	entry.EntryID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// SaveCalendarCookedEntry records a calendar day as cooked, marking the same day again replaces its entry
+func (h HistoryRepository) SaveCalendarCookedEntry(entry *models.CookedEntry) error {
+	filter := bson.M{"calendarid": entry.CalendarID, "day": entry.Day}
+	opts := options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After)
+	return h.historyCollection.FindOneAndReplace(context.Background(), filter, entry, opts).Decode(entry)
+}
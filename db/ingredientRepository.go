@@ -14,11 +14,13 @@ type IngredientDB interface {
 	IngredientGetter
 	IngredientDeleter
 	IngredientCreator
+	IngredientUpdater
 }
 
 type IngredientGetter interface {
 	GetIngredient(ingredientID string) (models.Ingredient, error)
 	QueryIngredients(prefix string) ([]models.Ingredient, error)
+	GetIngredientsByName(names []string) ([]models.Ingredient, error)
 }
 
 type IngredientDeleter interface {
@@ -29,6 +31,11 @@ type IngredientCreator interface {
 	CreateIngredient(ingredient models.Ingredient) (models.Ingredient, error)
 }
 
+type IngredientUpdater interface {
+	UpdateIngredientNutrition(ingredientID string, nutrition models.NutritionFacts) (models.Ingredient, error)
+	UpdateIngredientLabels(ingredientID string, labels models.DietaryLabels) (models.Ingredient, error)
+}
+
 type IngredientRepository struct {
 	ingredientCollection *mongo.Collection
 }
@@ -98,6 +105,40 @@ func (i IngredientRepository) QueryIngredients(prefix string) ([]models.Ingredie
 	return ingredientBatch, nil
 }
 
+// GetIngredientsByName gets the catalog ingredients with any of the names, regardless of case
+func (i IngredientRepository) GetIngredientsByName(names []string) ([]models.Ingredient, error) {
+	findOptions := options.Find().SetCollation(&options.Collation{Locale: "en", Strength: 2})
+	cur, err := i.ingredientCollection.Find(context.Background(), bson.M{"name": bson.M{"$in": names}}, findOptions)
+	if err != nil {
+		return []models.Ingredient{}, err
+	}
+	return decodeCurToIngredients(cur)
+}
+
+// UpdateIngredientNutrition sets the nutrition facts of a catalog ingredient
+func (i IngredientRepository) UpdateIngredientNutrition(ingredientID string, nutrition models.NutritionFacts) (models.Ingredient, error) {
+	result := models.Ingredient{}
+	id, _ := primitive.ObjectIDFromHex(ingredientID)
+	findOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := i.ingredientCollection.FindOneAndUpdate(context.Background(),
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"nutrition": nutrition}},
+		findOptions).Decode(&result)
+	return result, err
+}
+
+// UpdateIngredientLabels sets the allergens and diets of a catalog ingredient
+func (i IngredientRepository) UpdateIngredientLabels(ingredientID string, labels models.DietaryLabels) (models.Ingredient, error) {
+	result := models.Ingredient{}
+	id, _ := primitive.ObjectIDFromHex(ingredientID)
+	findOptions := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := i.ingredientCollection.FindOneAndUpdate(context.Background(),
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"labels": labels}},
+		findOptions).Decode(&result)
+	return result, err
+}
+
 func decodeCurToIngredients(cur *mongo.Cursor) ([]models.Ingredient, error) {
 	emptyResults := []models.Ingredient{}
 	var results []models.Ingredient
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MealDB interface {
+	MealGetter
+	MealUpdater
+	MealDeleter
+}
+
+type MealGetter interface {
+	GetMealSlots(householdID string, query models.MealSlotQuery) ([]models.MealSlot, error)
+}
+
+type MealUpdater interface {
+	SaveMealSlot(slot models.MealSlot) (models.MealSlot, error)
+	CreateMissingMealSlots(slots []models.MealSlot) (int64, error)
+	SyncCalendarSlots(calendarID primitive.ObjectID, slots []models.MealSlot) error
+}
+
+type MealDeleter interface {
+	DeleteMealSlot(householdID string, date string, mealType string) error
+}
+
+type MealRepository struct {
+	mealCollection *mongo.Collection
+}
+
+func NewMealRepository(client *mongo.Client) *MealRepository {
+	repository := &MealRepository{
+		mealCollection: client.Database("tastyBoiDatabase").Collection("mealCollection"),
+	}
+	// a household plans each meal of a day in one slot
+	uniqueSlot := mongo.IndexModel{
+		Keys:    bson.D{{Key: "householdid", Value: 1}, {Key: "date", Value: 1}, {Key: "mealtype", Value: 1}},
+		Options: options.Index().SetName("oneSlotPerMeal").SetUnique(true),
+	}
+	if _, err := repository.mealCollection.Indexes().CreateOne(context.Background(), uniqueSlot); err != nil {
+		fmt.Println("Could not create meal slot index")
+		fmt.Println(err)
+	}
+	return repository
+}
+
+// GetMealSlots gets a household's meal slots matching the query in date order
+func (m MealRepository) GetMealSlots(householdID string, query models.MealSlotQuery) ([]models.MealSlot, error) {
+	id, _ := primitive.ObjectIDFromHex(householdID)
+	filter := bson.M{"householdid": id}
+	date := bson.M{}
+	if query.From != "" {
+		date["$gte"] = query.From
+	}
+	if query.To != "" {
+		date["$lt"] = query.To
+	}
+	if len(date) > 0 {
+		filter["date"] = date
+	}
+	if query.MealType != "" {
+		filter["mealtype"] = query.MealType
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "mealtype", Value: 1}})
+	cur, err := m.mealCollection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return []models.MealSlot{}, err
+	}
+	defer cur.Close(context.Background())
+
+	slots := []models.MealSlot{}
+	if err := cur.All(context.Background(), &slots); err != nil {
+		return []models.MealSlot{}, err
+	}
+	return slots, nil
+}
+
+// SaveMealSlot creates the household's slot for the meal or replaces the one it already has
+func (m MealRepository) SaveMealSlot(slot models.MealSlot) (models.MealSlot, error) {
+	filter := bson.M{"householdid": slot.HouseholdID, "date": slot.Date, "mealtype": slot.MealType}
+	slot.SlotID = primitive.NilObjectID
+	result := models.MealSlot{}
+	opts := options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After)
+	err := m.mealCollection.FindOneAndReplace(context.Background(), filter, slot, opts).Decode(&result)
+	return result, err
+}
+
+// CreateMissingMealSlots creates the slots the household doesn't have yet, leaving the ones it does alone,
+// and counts the ones it created
+func (m MealRepository) CreateMissingMealSlots(slots []models.MealSlot) (int64, error) {
+	if len(slots) == 0 {
+		return 0, nil
+	}
+	var writes []mongo.WriteModel
+	for _, slot := range slots {
+		filter := bson.M{"householdid": slot.HouseholdID, "date": slot.Date, "mealtype": slot.MealType}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(filter).
+			SetUpdate(bson.M{"$setOnInsert": slot}).
+			SetUpsert(true))
+	}
+	result, err := m.mealCollection.BulkWrite(context.Background(), writes)
+	if err != nil {
+		return 0, err
+	}
+	return result.UpsertedCount, nil
+}
+
+// SyncCalendarSlots plans the recipes of a weekly calendar's slots, creating the ones the household doesn't have
+// yet, and deletes the slots copied from the calendar on any other date. Notes on the slots are kept.
+func (m MealRepository) SyncCalendarSlots(calendarID primitive.ObjectID, slots []models.MealSlot) error {
+	var writes []mongo.WriteModel
+	dates := bson.A{}
+	for _, slot := range slots {
+		filter := bson.M{"householdid": slot.HouseholdID, "date": slot.Date, "mealtype": slot.MealType}
+		update := bson.M{"$set": bson.M{"recipes": slot.Recipes, "calendarid": calendarID, "lastupdateddate": slot.LastUpdatedDate}}
+		writes = append(writes, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
+		dates = append(dates, slot.Date)
+	}
+	writes = append(writes, mongo.NewDeleteManyModel().SetFilter(bson.M{"calendarid": calendarID, "date": bson.M{"$nin": dates}}))
+	_, err := m.mealCollection.BulkWrite(context.Background(), writes)
+	return err
+}
+
+// DeleteMealSlot deletes the household's slot for a meal, returning mongo.ErrNoDocuments when there isn't one
+func (m MealRepository) DeleteMealSlot(householdID string, date string, mealType string) error {
+	id, _ := primitive.ObjectIDFromHex(householdID)
+	result, err := m.mealCollection.DeleteOne(context.Background(), bson.M{"householdid": id, "date": date, "mealtype": mealType})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,369 @@
+package db
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"server/models"
+	"server/search"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// RecipeFilter matches the recipes that are part of a paginated request
+func RecipeFilter(request models.PaginatedRecipeRequest) bson.M {
+	return bson.M{"$and": recipeFilters(request, search.ForRequest(request))}
+}
+
+// recipeFilters are the conditions a recipe has to meet to be part of a paginated request,
+// shared by the page and count queries so they always agree
+func recipeFilters(request models.PaginatedRecipeRequest, query search.Query) bson.A {
+	filterArray := bson.A{}
+	if !query.IsEmpty() {
+		textFilter := bson.M{"$text": bson.M{"$search": query.String()}}
+		filterArray = append(filterArray, textFilter)
+	}
+	if len(request.QueryRecipe.Tags) > 0 {
+		tagFilter := bson.M{"tags": bson.M{"$all": request.QueryRecipe.Tags}}
+		filterArray = append(filterArray, tagFilter)
+	}
+	filterArray = append(filterArray, visibilityFilter(request.Viewer))
+	return append(filterArray, criteriaFilters(request.Filter)...)
+}
+
+// visibilityFilter matches the recipes a viewer can see: public ones, their own, and ones shared with
+// their household. Recipes without a visibility are public unless they were marked private.
+func visibilityFilter(viewer models.Viewer) bson.M {
+	visible := bson.A{
+		bson.M{"visibility": models.VisibilityPublic},
+		bson.M{"visibility": bson.M{"$in": bson.A{nil, ""}}, "private": bson.M{"$ne": true}},
+	}
+	if viewer.UserName != "" {
+		visible = append(visible, bson.M{"username": viewer.UserName})
+	}
+	if len(viewer.HouseholdMembers) > 0 {
+		visible = append(visible, bson.M{
+			"visibility": models.VisibilityHousehold,
+			"username":   bson.M{"$in": viewer.HouseholdMembers},
+		})
+	}
+	return bson.M{"$or": visible}
+}
+
+func criteriaFilters(filter models.RecipeFilter) bson.A {
+	filterArray := bson.A{}
+	addRange := func(field string, operator string, value interface{}, isSet bool) {
+		if isSet {
+			filterArray = append(filterArray, bson.M{field: bson.M{operator: value}})
+		}
+	}
+	addRange("preptime", "$lte", filter.MaxPrepTime, filter.MaxPrepTime > 0)
+	addRange("cooktime", "$lte", filter.MaxCookTime, filter.MaxCookTime > 0)
+	addRange("calories", "$gte", filter.MinCalories, filter.MinCalories > 0)
+	addRange("calories", "$lte", filter.MaxCalories, filter.MaxCalories > 0)
+	addRange("servings", "$gte", filter.MinServings, filter.MinServings > 0)
+	addRange("servings", "$lte", filter.MaxServings, filter.MaxServings > 0)
+	addRange("createddate", "$gte", filter.CreatedAfter, filter.CreatedAfter != "")
+	addRange("createddate", "$lt", filter.CreatedBefore, filter.CreatedBefore != "")
+	addRange("lastupdateddate", "$gte", filter.UpdatedAfter, filter.UpdatedAfter != "")
+	addRange("lastupdateddate", "$lt", filter.UpdatedBefore, filter.UpdatedBefore != "")
+
+	if filter.MaxTotalTime > 0 {
+		filterArray = append(filterArray, bson.M{"$expr": bson.M{"$lte": bson.A{totalTime, filter.MaxTotalTime}}})
+	}
+	if filter.Author != "" {
+		authorPattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(filter.Author) + "$", Options: "i"}
+		filterArray = append(filterArray, bson.M{"author": authorPattern})
+	}
+	if filter.UserName != "" {
+		filterArray = append(filterArray, bson.M{"username": filter.UserName})
+	}
+	if filter.MinRating > 0 {
+		filterArray = append(filterArray, bson.M{"averagerating": bson.M{"$gte": filter.MinRating}, "ratingcount": bson.M{"$gt": 0}})
+	}
+	if len(filter.ExcludeAllergens) > 0 {
+		// a recipe without labels, or with ingredients the catalog couldn't label, might have any allergen
+		filterArray = append(filterArray, bson.M{
+			"labels":                      bson.M{"$ne": nil},
+			"labels.unlabeledingredients": bson.M{"$in": bson.A{nil, bson.A{}}},
+			"labels.allergens":            bson.M{"$nin": filter.ExcludeAllergens},
+		})
+	}
+	if len(filter.Diets) > 0 {
+		filterArray = append(filterArray, bson.M{"labels.diets": bson.M{"$all": filter.Diets}})
+	}
+	if filter.RecipeIDs != nil {
+		filterArray = append(filterArray, bson.M{"_id": bson.M{"$in": filter.RecipeIDs}})
+	}
+	if filter.ForkedFrom != "" {
+		forkedFrom, _ := primitive.ObjectIDFromHex(filter.ForkedFrom)
+		filterArray = append(filterArray, bson.M{"forkedfrom.recipeid": forkedFrom})
+	}
+	for _, ingredient := range filter.IncludeIngredients {
+		filterArray = append(filterArray, bson.M{"ingredients.name": ingredientPattern(ingredient)})
+	}
+	if len(filter.ExcludeIngredients) > 0 {
+		excluded := bson.A{}
+		for _, ingredient := range filter.ExcludeIngredients {
+			excluded = append(excluded, bson.M{"ingredients.name": ingredientPattern(ingredient)})
+		}
+		filterArray = append(filterArray, bson.M{"$nor": excluded})
+	}
+	return filterArray
+}
+
+// ingredientPattern matches an ingredient name as a whole word, allowing for a plural
+func ingredientPattern(name string) primitive.Regex {
+	return primitive.Regex{Pattern: `\b` + regexp.QuoteMeta(name) + `(e?s)?\b`, Options: "i"}
+}
+
+// totalTime is how long a recipe takes, counting a missing prep or cook time as none so the recipe isn't
+// compared as null
+var totalTime = bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$preptime", 0}}, bson.M{"$ifNull": bson.A{"$cooktime", 0}}}}
+
+// recipeSortFields maps the sortable fields of a request to the value recipes are sorted on
+var recipeSortFields = map[string]interface{}{
+	// names sort regardless of case
+	"name":            bson.M{"$toLower": "$recipename"},
+	"createdDate":     "$createddate",
+	"lastUpdatedDate": "$lastupdateddate",
+	"totalTime":       totalTime,
+	"calories":        "$calories",
+	"popularity":      "$popularity",
+	"rating":          "$averagerating",
+	"ratingCount":     "$ratingcount",
+	"relevance":       bson.M{"$meta": "textScore"},
+}
+
+// IsSortableRecipeField reports whether recipes can be sorted by a field
+func IsSortableRecipeField(field string) bool {
+	_, ok := recipeSortFields[field]
+	return ok
+}
+
+// recipeSortKey is one value recipes are sorted on. The value is computed into its own field before
+// sorting so missing values sort, and compare against a cursor, the same way as null.
+type recipeSortKey struct {
+	field      string
+	name       string
+	expression interface{}
+	direction  int
+}
+
+// recipeSortKeys resolves the sort of a request, searches are sorted by relevance unless asked otherwise.
+// The ID always breaks any remaining ties so pages never overlap or skip a recipe.
+func recipeSortKeys(sorts []models.RecipeSort, searching bool) []recipeSortKey {
+	if len(sorts) == 0 && searching {
+		sorts = []models.RecipeSort{{Field: "relevance"}}
+	}
+	var keys []recipeSortKey
+	for _, sort := range sorts {
+		expression, ok := recipeSortFields[sort.Field]
+		if !ok || (sort.Field == "relevance" && !searching) {
+			continue
+		}
+		keys = append(keys, recipeSortKey{
+			field:      sort.Field,
+			name:       fmt.Sprintf("sortkey%d", len(keys)),
+			expression: expression,
+			direction:  sortDirection(sort),
+		})
+	}
+	return keys
+}
+
+func sortDirection(sort models.RecipeSort) int {
+	switch strings.ToLower(sort.Direction) {
+	case "asc":
+		return 1
+	case "desc":
+		return -1
+	}
+	switch sort.Field {
+	case "relevance", "popularity", "rating", "ratingCount":
+		return -1
+	}
+	return 1
+}
+
+func sortKeyValues(keys []recipeSortKey) bson.M {
+	values := bson.M{}
+	for _, key := range keys {
+		values[key.name] = bson.M{"$ifNull": bson.A{key.expression, nil}}
+	}
+	return values
+}
+
+// sortStage sorts by the keys, in reverse when paging backwards from a cursor
+func sortStage(keys []recipeSortKey, backward bool) bson.D {
+	reverse := 1
+	if backward {
+		reverse = -1
+	}
+	stage := bson.D{}
+	for _, key := range keys {
+		stage = append(stage, bson.E{Key: key.name, Value: key.direction * reverse})
+	}
+	return append(stage, bson.E{Key: "_id", Value: reverse})
+}
+
+// sortSignature identifies a sort so a cursor can't be used with a different one
+func sortSignature(keys []recipeSortKey, query search.Query) string {
+	var parts []string
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s:%d", key.field, key.direction))
+	}
+	return strings.Join(parts, ",") + "|" + query.String()
+}
+
+// recipeCursor marks a position in a sorted list of recipes: the sort values and ID of a recipe
+// along with which way to page from it. It is handed to clients as an opaque string.
+type recipeCursor struct {
+	Signature string             `bson:"s"`
+	Values    bson.A             `bson:"v"`
+	ID        primitive.ObjectID `bson:"id"`
+	Backward  bool               `bson:"b,omitempty"`
+}
+
+func encodeCursor(cursor recipeCursor) string {
+	encoded, err := bson.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeCursor(text string, keys []recipeSortKey, signature string) (recipeCursor, error) {
+	cursor := recipeCursor{}
+	decoded, err := base64.RawURLEncoding.DecodeString(text)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := bson.Unmarshal(decoded, &cursor); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if cursor.Signature != signature || len(cursor.Values) != len(keys) {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// cursorFilter matches the recipes that come after the cursor's recipe in the sort order, or before it
+// when paging backwards: those with a later first key, or the same first key and a later second key, and so on.
+func cursorFilter(keys []recipeSortKey, cursor recipeCursor) bson.M {
+	clauses := bson.A{}
+	for i := 0; i <= len(keys); i++ {
+		conditions := bson.A{}
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, bson.M{"$eq": bson.A{"$" + keys[j].name, bson.M{"$literal": cursor.Values[j]}}})
+		}
+		if i < len(keys) {
+			operator := cursorOperator(keys[i].direction, cursor.Backward)
+			conditions = append(conditions, bson.M{operator: bson.A{"$" + keys[i].name, bson.M{"$literal": cursor.Values[i]}}})
+		} else {
+			conditions = append(conditions, bson.M{cursorOperator(1, cursor.Backward): bson.A{"$_id", cursor.ID}})
+		}
+		clauses = append(clauses, bson.M{"$and": conditions})
+	}
+	return bson.M{"$expr": bson.M{"$or": clauses}}
+}
+
+// RecipePager finds one page of the recipes in a paginated request, from its cursor when it has one and
+// otherwise by skipping whole pages
+type RecipePager struct {
+	request   models.PaginatedRecipeRequest
+	query     search.Query
+	keys      []recipeSortKey
+	signature string
+	cursor    recipeCursor
+}
+
+// NewRecipePager returns ErrInvalidCursor when the request's cursor wasn't made for its sort and search
+func NewRecipePager(request models.PaginatedRecipeRequest) (RecipePager, error) {
+	query := search.ForRequest(request)
+	keys := recipeSortKeys(request.Sort, !query.IsEmpty())
+	pager := RecipePager{request: request, query: query, keys: keys, signature: sortSignature(keys, query)}
+	if request.Cursor != "" {
+		cursor, err := decodeCursor(request.Cursor, keys, pager.signature)
+		if err != nil {
+			return RecipePager{}, err
+		}
+		pager.cursor = cursor
+	}
+	return pager, nil
+}
+
+// Pipeline finds the recipes on the page along with their sort values, and one more recipe when there is
+// another page after it
+func (p RecipePager) Pipeline() mongo.Pipeline {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"$and": recipeFilters(p.request, p.query)}}}}
+	if len(p.keys) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: sortKeyValues(p.keys)}})
+	}
+	skip := int64(0)
+	if p.request.Cursor != "" {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: cursorFilter(p.keys, p.cursor)}})
+	} else {
+		skip = int64(p.request.PageCount) * p.request.PageSize
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sortStage(p.keys, p.cursor.Backward)}})
+	if skip > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: skip}})
+	}
+	// get one more than a page to tell whether there is another page after it
+	if p.request.PageSize > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: p.request.PageSize + 1}})
+	}
+	return pipeline
+}
+
+// Page makes a page from the recipes found by Pipeline and their sort values, which include one recipe
+// past the end of the page when there is another one after it. The cursors lead to the pages either side.
+func (p RecipePager) Page(results []models.Recipe, values []bson.A) models.RecipePage {
+	request, cursor, signature := p.request, p.cursor, p.signature
+	hasMore := request.PageSize > 0 && int64(len(results)) > request.PageSize
+	if hasMore {
+		results, values = results[:request.PageSize], values[:request.PageSize]
+	}
+	if cursor.Backward {
+		for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+			results[i], results[j] = results[j], results[i]
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+
+	page := models.RecipePage{Recipes: results}
+	if len(results) == 0 || request.PageSize == 0 {
+		return page
+	}
+	first := recipeCursor{Signature: signature, Values: values[0], ID: results[0].RecipeID, Backward: true}
+	last := recipeCursor{Signature: signature, Values: values[len(values)-1], ID: results[len(results)-1].RecipeID}
+	// paging backwards found whether there are more before this page, and it came from a page after it
+	if cursor.Backward {
+		page.NextCursor = encodeCursor(last)
+		if hasMore {
+			page.PrevCursor = encodeCursor(first)
+		}
+	} else {
+		if hasMore {
+			page.NextCursor = encodeCursor(last)
+		}
+		if request.Cursor != "" || request.PageCount > 0 {
+			page.PrevCursor = encodeCursor(first)
+		}
+	}
+	return page
+}
+
+func cursorOperator(direction int, backward bool) string {
+	if (direction == 1) != backward {
+		return "$gt"
+	}
+	return "$lt"
+}
@@ -3,12 +3,13 @@ package db
 import (
 	"context"
 	"errors"
+	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"server/models"
-	"time"
+	"server/search"
 )
 
 type RecipeDB interface {
@@ -20,8 +21,10 @@ type RecipeDB interface {
 
 type RecipeGetter interface {
 	GetRecipe(recipeID string) (models.Recipe, error)
-	GetPaginatedRecipes(request models.PaginatedRecipeRequest) ([]models.Recipe, error)
+	GetRecipesByID(recipeIDs []primitive.ObjectID) ([]models.Recipe, error)
+	GetPaginatedRecipes(request models.PaginatedRecipeRequest) (models.RecipePage, error)
 	GetFilteredRecipeCount(request models.PaginatedRecipeRequest) (int64, error)
+	GetRandomRecipes(request models.RandomRecipeRequest) ([]models.Recipe, error)
 	CountRecipes() (int64, error)
 }
 
@@ -35,6 +38,8 @@ type RecipeDeleter interface {
 
 type RecipeUpdater interface {
 	UpdateRecipe(recipeID string, updatedRecipe models.Recipe) (models.Recipe, error)
+	IncrementPopularity(recipeIDs []primitive.ObjectID) error
+	UpdateRating(recipeID string, averageRating float64, ratingCount int) error
 }
 
 type RecipeRepository struct {
@@ -42,9 +47,35 @@ type RecipeRepository struct {
 }
 
 func NewRecipeRepository(client *mongo.Client) *RecipeRepository {
-	return &RecipeRepository{
+	repository := &RecipeRepository{
 		recipeCollection: client.Database("tastyBoiDatabase").Collection("cookbookCollection"),
 	}
+	if err := repository.createSearchIndex(); err != nil {
+		fmt.Println("Could not create recipe search index")
+		fmt.Println(err)
+	}
+	return repository
+}
+
+// createSearchIndex builds the text index used to search recipes, a match in the name counts
+// for the most and a match in the steps for the least. Creating an index that exists is a no-op.
+func (r RecipeRepository) createSearchIndex() error {
+	searchIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "recipename", Value: "text"},
+			{Key: "tags", Value: "text"},
+			{Key: "ingredients.name", Value: "text"},
+			{Key: "steps.text", Value: "text"},
+		},
+		Options: options.Index().SetName("recipeSearch").SetWeights(bson.M{
+			"recipename":       10,
+			"tags":             5,
+			"ingredients.name": 3,
+			"steps.text":       1,
+		}),
+	}
+	_, err := r.recipeCollection.Indexes().CreateOne(context.Background(), searchIndex)
+	return err
 }
 
 func (r RecipeRepository) GetRecipe(recipeID string) (models.Recipe, error) {
@@ -58,6 +89,18 @@ func (r RecipeRepository) GetRecipe(recipeID string) (models.Recipe, error) {
 	return result, nil
 }
 
+// GetRecipesByID gets the recipes with any of the IDs, leaving out the ones that don't exist
+func (r RecipeRepository) GetRecipesByID(recipeIDs []primitive.ObjectID) ([]models.Recipe, error) {
+	if len(recipeIDs) == 0 {
+		return []models.Recipe{}, nil
+	}
+	cur, err := r.recipeCollection.Find(context.Background(), bson.M{"_id": bson.M{"$in": recipeIDs}})
+	if err != nil {
+		return []models.Recipe{}, err
+	}
+	return decodeCurToRecipes(cur)
+}
+
 // CreateRecipe - CreateRecipe inserts one recipe into the database. It does not validate
 // the recipe fields, after a successful insert the model's RecipeID will be updated with
 // the ID generated by the database
@@ -85,22 +128,48 @@ func (r RecipeRepository) DeleteRecipe(recipeID string) error {
 	return nil
 }
 
+// UpdateRecipe replaces a recipe at the version in updatedRecipe and moves it to the next version, keeping the
+// LastUpdatedDate it is given. It returns ErrVersionConflict when the recipe has changed since or doesn't exist.
+//
+// Popularity and ratings are counted without changing the version, so they are kept from the stored recipe as
+// it is replaced rather than from updatedRecipe, which may have been read before a review came in.
 func (r RecipeRepository) UpdateRecipe(recipeID string, updatedRecipe models.Recipe) (models.Recipe, error) {
-	currentTime := time.Now()
-	updatedRecipe.LastUpdatedDate = currentTime.Format("2006.01.02 15:04:05")
 	id, _ := primitive.ObjectIDFromHex(recipeID)
-	filter := bson.M{"_id": id}
-	//Could do this as an update but that requires checking what fields are different between recipes
-	//Could be a hassle with a long list of ingredients or measurements. Easier to just replace the entire recipe with the new update
-	opts := options.Replace().SetUpsert(true)
-	result, err := r.recipeCollection.ReplaceOne(context.Background(), filter, updatedRecipe, opts)
+	updatedRecipe.RecipeID = id
+	// only replace the recipe if it is still at the version the update was made from
+	filter := bson.M{"_id": id, "version": versionFilter(updatedRecipe.Version)}
+	updatedRecipe.Version++
+	// $literal keeps text like "$5" in the recipe from being read as a field path
+	pipeline := mongo.Pipeline{{{Key: "$replaceWith", Value: bson.M{"$mergeObjects": bson.A{
+		bson.M{"$literal": updatedRecipe},
+		bson.M{"popularity": "$popularity", "averagerating": "$averagerating", "ratingcount": "$ratingcount"},
+	}}}}}
+	result := models.Recipe{}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.recipeCollection.FindOneAndUpdate(context.Background(), filter, pipeline, opts).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Recipe{}, ErrVersionConflict
+	}
 	if err != nil {
 		return models.Recipe{}, err
 	}
-	if result.UpsertedID != nil {
-		updatedRecipe.RecipeID = result.UpsertedID.(primitive.ObjectID)
-	}
-	return updatedRecipe, nil
+	return result, nil
+}
+
+// IncrementPopularity counts one more use of each recipe
+func (r RecipeRepository) IncrementPopularity(recipeIDs []primitive.ObjectID) error {
+	filter := bson.M{"_id": bson.M{"$in": recipeIDs}}
+	_, err := r.recipeCollection.UpdateMany(context.Background(), filter, bson.M{"$inc": bson.M{"popularity": 1}})
+	return err
+}
+
+// UpdateRating sets the summary of a recipe's reviews. Like popularity it is kept by the server, so it
+// doesn't change the recipe's version.
+func (r RecipeRepository) UpdateRating(recipeID string, averageRating float64, ratingCount int) error {
+	id, _ := primitive.ObjectIDFromHex(recipeID)
+	update := bson.M{"$set": bson.M{"averagerating": averageRating, "ratingcount": ratingCount}}
+	_, err := r.recipeCollection.UpdateOne(context.Background(), bson.M{"_id": id}, update)
+	return err
 }
 
 func (r RecipeRepository) CountRecipes() (int64, error) {
@@ -111,66 +180,72 @@ func (r RecipeRepository) CountRecipes() (int64, error) {
 	return count, nil
 }
 
-func (r RecipeRepository) GetPaginatedRecipes(request models.PaginatedRecipeRequest) ([]models.Recipe, error) {
-	// Get the first page
-	var emptyResults []models.Recipe
-	var results []models.Recipe
-	var nextID primitive.ObjectID
-	id, _ := primitive.ObjectIDFromHex("0")
-	firstRecipeBatch, firstBatchErr := r.getPage(request.PageSize, id, request.QueryRecipe)
-	if firstBatchErr != nil {
-		return emptyResults, firstBatchErr
-	}
-	results = firstRecipeBatch
-	nextID = firstRecipeBatch[len(results)-1].RecipeID
-
-	// continue to get a batch of recipes until we reach the page number
-	if request.PageCount > 0 {
-		for i := 1; i <= request.PageCount; i++ {
-			newresults, err := r.getPage(request.PageSize, nextID, request.QueryRecipe)
-			if err != nil {
-				return emptyResults, err
-			}
-			if len(newresults) == 0 {
-				return results, nil
-			}
-			results = newresults
-			nextID = newresults[len(results)-1].RecipeID
-		}
+// GetPaginatedRecipes gets one page of recipes in a single query. Pages are found by continuing from a
+// request's cursor, or by skipping PageCount pages when there is no cursor.
+func (r RecipeRepository) GetPaginatedRecipes(request models.PaginatedRecipeRequest) (models.RecipePage, error) {
+	pager, err := NewRecipePager(request)
+	if err != nil {
+		return models.RecipePage{}, err
 	}
-	return results, nil
+	cur, err := r.recipeCollection.Aggregate(context.Background(), pager.Pipeline())
+	if err != nil {
+		return models.RecipePage{}, err
+	}
+
+	results, values, err := decodeCurToSortedRecipes(cur, pager.keys)
+	if err != nil {
+		return models.RecipePage{}, err
+	}
+	return pager.Page(results, values), nil
 }
 
 func (r RecipeRepository) GetFilteredRecipeCount(request models.PaginatedRecipeRequest) (int64, error) {
-	filterArray := bson.A{}
-	queryRecipe := request.QueryRecipe
-	var itemNumber int64
-	var countErr error
-	if queryRecipe.RecipeName != "" {
-		regex := `(?i).*` + queryRecipe.RecipeName + `.*`
-		nameFilter := bson.M{"recipename": bson.M{"$regex": regex}}
-		filterArray = append(filterArray, nameFilter)
-	}
-	if len(queryRecipe.Tags) > 0 {
-		tagFilter := bson.M{"tags": bson.M{"$all": queryRecipe.Tags}}
-		filterArray = append(filterArray, tagFilter)
-	}
-	if len(filterArray) > 0 {
-		itemCount, err := r.recipeCollection.CountDocuments(
-			context.Background(),
-			bson.M{"$and": filterArray},
-		)
-		itemNumber = itemCount
-		countErr = err
+	return r.recipeCollection.CountDocuments(context.Background(), RecipeFilter(request))
+}
+
+// GetRandomRecipes samples recipes matching the request in one query, there may be fewer than
+// requested if not enough recipes match
+func (r RecipeRepository) GetRandomRecipes(request models.RandomRecipeRequest) ([]models.Recipe, error) {
+	filters := recipeFilters(models.PaginatedRecipeRequest{
+		QueryRecipe: models.Recipe{Tags: request.Tags},
+		Filter:      request.Filter,
+		Viewer:      request.Viewer,
+	}, search.Query{})
+	if len(request.ExcludeRecipeIDs) > 0 {
+		var excludedIDs []primitive.ObjectID
+		for _, recipeID := range request.ExcludeRecipeIDs {
+			id, _ := primitive.ObjectIDFromHex(recipeID)
+			excludedIDs = append(excludedIDs, id)
+		}
+		filters = append(filters, bson.M{"_id": bson.M{"$nin": excludedIDs}})
+	}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"$and": filters}}}}
+	if request.WeightByRating {
+		pipeline = append(pipeline, weightedSampleStages(request.NumberOfRecipes)...)
 	} else {
-		itemCount, err := r.recipeCollection.CountDocuments(
-			context.Background(),
-			bson.D{{}},
-		)
-		itemNumber = itemCount
-		countErr = err
+		pipeline = append(pipeline, bson.D{{Key: "$sample", Value: bson.M{"size": request.NumberOfRecipes}}})
+	}
+	cur, err := r.recipeCollection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return []models.Recipe{}, err
+	}
+	return decodeCurToRecipes(cur)
+}
+
+// weightedSampleStages pick recipes at random with better rated recipes more likely to be picked. Each recipe
+// gets a key of random^(1/weight) and the highest keys are picked, which samples in proportion to the weights.
+func weightedSampleStages(size int) []bson.D {
+	rating := bson.M{"$cond": bson.A{
+		bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$ratingcount", 0}}, 0}},
+		"$averagerating",
+		3,
+	}}
+	sampleKey := bson.M{"$pow": bson.A{bson.M{"$rand": bson.M{}}, bson.M{"$divide": bson.A{1, rating}}}}
+	return []bson.D{
+		{{Key: "$addFields", Value: bson.M{"samplekey": sampleKey}}},
+		{{Key: "$sort", Value: bson.M{"samplekey": -1}}},
+		{{Key: "$limit", Value: size}},
 	}
-	return itemNumber, countErr
 }
 
 func decodeCurToRecipes(cur *mongo.Cursor) ([]models.Recipe, error) {
@@ -194,29 +269,31 @@ func decodeCurToRecipes(cur *mongo.Cursor) ([]models.Recipe, error) {
 	return results, nil
 }
 
-func (r RecipeRepository) getPage(pageSize int64, idLimit primitive.ObjectID, queryRecipe models.Recipe) ([]models.Recipe, error) {
-	var emptyResults []models.Recipe
-	filterArray := bson.A{}
-	if queryRecipe.RecipeName != "" {
-		regex := `(?i).*` + queryRecipe.RecipeName + `.*`
-		nameFilter := bson.M{"recipename": bson.M{"$regex": regex}}
-		filterArray = append(filterArray, nameFilter)
-	}
-	if len(queryRecipe.Tags) > 0 {
-		tagFilter := bson.M{"tags": bson.M{"$all": queryRecipe.Tags}}
-		filterArray = append(filterArray, tagFilter)
-	}
-	findOptions := options.Find()
-	findOptions.SetLimit(pageSize)
-	filterArray = append(filterArray, bson.M{"_id": bson.M{"$gt": idLimit}})
-	cur, err := r.recipeCollection.Find(
-		context.Background(),
-		bson.M{"$and": filterArray},
-		findOptions,
-	)
-	if err != nil {
-		return emptyResults, err
+// decodeCurToSortedRecipes decodes recipes along with the values they were sorted on
+func decodeCurToSortedRecipes(cur *mongo.Cursor, keys []recipeSortKey) ([]models.Recipe, []bson.A, error) {
+	results := []models.Recipe{}
+	values := []bson.A{}
+	for cur.Next(context.Background()) {
+		result := models.Recipe{}
+		if e := cur.Decode(&result); e != nil {
+			return []models.Recipe{}, []bson.A{}, e
+		}
+		resultValues := bson.A{}
+		for _, key := range keys {
+			var value interface{}
+			if e := cur.Current.Lookup(key.name).Unmarshal(&value); e != nil {
+				return []models.Recipe{}, []bson.A{}, e
+			}
+			resultValues = append(resultValues, value)
+		}
+		results = append(results, result)
+		values = append(values, resultValues)
 	}
 
-	return decodeCurToRecipes(cur)
+	if err := cur.Err(); err != nil {
+		return []models.Recipe{}, []bson.A{}, err
+	}
+
+	cur.Close(context.Background())
+	return results, values, nil
 }
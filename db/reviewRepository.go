@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReviewDB interface {
+	ReviewGetter
+	ReviewUpdater
+	ReviewDeleter
+}
+
+type ReviewGetter interface {
+	GetReviews(recipeID string) ([]models.Review, error)
+	GetRatingSummary(recipeID string) (float64, int, error)
+}
+
+type ReviewUpdater interface {
+	SaveReview(review models.Review) (models.Review, error)
+}
+
+type ReviewDeleter interface {
+	DeleteReview(recipeID string, userName string) error
+	DeleteReviews(recipeID string) error
+}
+
+type ReviewRepository struct {
+	reviewCollection *mongo.Collection
+}
+
+func NewReviewRepository(client *mongo.Client) *ReviewRepository {
+	repository := &ReviewRepository{
+		reviewCollection: client.Database("tastyBoiDatabase").Collection("reviewCollection"),
+	}
+	// a user has one review per recipe
+	uniqueReview := mongo.IndexModel{
+		Keys:    bson.D{{Key: "recipeid", Value: 1}, {Key: "username", Value: 1}},
+		Options: options.Index().SetName("oneReviewPerUser").SetUnique(true),
+	}
+	if _, err := repository.reviewCollection.Indexes().CreateOne(context.Background(), uniqueReview); err != nil {
+		fmt.Println("Could not create review index")
+		fmt.Println(err)
+	}
+	return repository
+}
+
+// GetReviews gets every review of a recipe, the most recently changed first
+func (r ReviewRepository) GetReviews(recipeID string) ([]models.Review, error) {
+	id, _ := primitive.ObjectIDFromHex(recipeID)
+	opts := options.Find().SetSort(bson.M{"lastupdateddate": -1})
+	cur, err := r.reviewCollection.Find(context.Background(), bson.M{"recipeid": id}, opts)
+	if err != nil {
+		return []models.Review{}, err
+	}
+
+	reviews := []models.Review{}
+	for cur.Next(context.Background()) {
+		result := models.Review{}
+		if e := cur.Decode(&result); e != nil {
+			return []models.Review{}, e
+		}
+		reviews = append(reviews, result)
+	}
+	if err := cur.Err(); err != nil {
+		return []models.Review{}, err
+	}
+
+	cur.Close(context.Background())
+	return reviews, nil
+}
+
+// GetRatingSummary averages and counts the ratings of a recipe
+func (r ReviewRepository) GetRatingSummary(recipeID string) (float64, int, error) {
+	id, _ := primitive.ObjectIDFromHex(recipeID)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"recipeid": id}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "average": bson.M{"$avg": "$rating"}, "count": bson.M{"$sum": 1}}}},
+	}
+	cur, err := r.reviewCollection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cur.Close(context.Background())
+
+	summary := struct {
+		Average float64
+		Count   int
+	}{}
+	if cur.Next(context.Background()) {
+		if err := cur.Decode(&summary); err != nil {
+			return 0, 0, err
+		}
+	}
+	return summary.Average, summary.Count, cur.Err()
+}
+
+// SaveReview creates the user's review of a recipe or replaces the one they already have
+func (r ReviewRepository) SaveReview(review models.Review) (models.Review, error) {
+	filter := bson.M{"recipeid": review.RecipeID, "username": review.UserName}
+	update := bson.M{
+		"$set": bson.M{
+			"rating":          review.Rating,
+			"text":            review.Text,
+			"lastupdateddate": review.LastUpdatedDate,
+		},
+		"$setOnInsert": bson.M{"createddate": review.CreatedDate},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	result := models.Review{}
+	err := r.reviewCollection.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&result)
+	return result, err
+}
+
+func (r ReviewRepository) DeleteReview(recipeID string, userName string) error {
+	id, _ := primitive.ObjectIDFromHex(recipeID)
+	result, err := r.reviewCollection.DeleteOne(context.Background(), bson.M{"recipeid": id, "username": userName})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount != 1 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r ReviewRepository) DeleteReviews(recipeID string) error {
+	id, _ := primitive.ObjectIDFromHex(recipeID)
+	_, err := r.reviewCollection.DeleteMany(context.Background(), bson.M{"recipeid": id})
+	return err
+}
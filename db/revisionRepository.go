@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RevisionDB interface {
+	RevisionGetter
+	RevisionCreator
+	RevisionDeleter
+}
+
+type RevisionGetter interface {
+	GetRevisions(recipeID string) ([]models.RecipeRevision, error)
+	GetRevision(recipeID string, number int) (models.RecipeRevision, error)
+}
+
+type RevisionCreator interface {
+	CreateRevision(revision *models.RecipeRevision) error
+	CreateFirstRevision(revision *models.RecipeRevision) error
+}
+
+type RevisionDeleter interface {
+	DeleteRevision(recipeID string, number int) error
+	DeleteRevisions(recipeID string) error
+}
+
+type RevisionRepository struct {
+	revisionCollection *mongo.Collection
+}
+
+// revisionNumberAttempts is how many times a revision is numbered again after another was saved with its number
+const revisionNumberAttempts = 5
+
+func NewRevisionRepository(client *mongo.Client) *RevisionRepository {
+	repository := &RevisionRepository{
+		revisionCollection: client.Database("tastyBoiDatabase").Collection("revisionCollection"),
+	}
+	// two updates saved at once can't both become the same revision
+	uniqueNumber := mongo.IndexModel{
+		Keys:    bson.D{{Key: "recipeid", Value: 1}, {Key: "number", Value: 1}},
+		Options: options.Index().SetName("oneRevisionPerNumber").SetUnique(true),
+	}
+	if _, err := repository.revisionCollection.Indexes().CreateOne(context.Background(), uniqueNumber); err != nil {
+		fmt.Println("Could not create revision index")
+		fmt.Println(err)
+	}
+	return repository
+}
+
+// GetRevisions gets every revision of a recipe, oldest first
+func (r RevisionRepository) GetRevisions(recipeID string) ([]models.RecipeRevision, error) {
+	id, _ := primitive.ObjectIDFromHex(recipeID)
+	opts := options.Find().SetSort(bson.M{"number": 1})
+	cur, err := r.revisionCollection.Find(context.Background(), bson.M{"recipeid": id}, opts)
+	if err != nil {
+		return []models.RecipeRevision{}, err
+	}
+
+	revisions := []models.RecipeRevision{}
+	for cur.Next(context.Background()) {
+		result := models.RecipeRevision{}
+		if e := cur.Decode(&result); e != nil {
+			return []models.RecipeRevision{}, e
+		}
+		revisions = append(revisions, result)
+	}
+	if err := cur.Err(); err != nil {
+		return []models.RecipeRevision{}, err
+	}
+
+	cur.Close(context.Background())
+	return revisions, nil
+}
+
+func (r RevisionRepository) GetRevision(recipeID string, number int) (models.RecipeRevision, error) {
+	result := models.RecipeRevision{}
+	id, _ := primitive.ObjectIDFromHex(recipeID)
+	filter := bson.M{"recipeid": id, "number": number}
+	err := r.revisionCollection.FindOne(context.Background(), filter).Decode(&result)
+	return result, err
+}
+
+// CreateRevision saves the revision as the recipe's next one, setting its number and ID. When another revision
+// takes the number first the unique index refuses it and it is numbered again.
+func (r RevisionRepository) CreateRevision(revision *models.RecipeRevision) error {
+	var err error
+	for attempt := 0; attempt < revisionNumberAttempts; attempt++ {
+		latest := models.RecipeRevision{}
+		opts := options.FindOne().SetSort(bson.M{"number": -1})
+		err = r.revisionCollection.FindOne(context.Background(), bson.M{"recipeid": revision.RecipeID}, opts).Decode(&latest)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return err
+		}
+		revision.Number = latest.Number + 1
+		revision.RevisionID = primitive.NilObjectID
+
+		var result *mongo.InsertOneResult
+		result, err = r.revisionCollection.InsertOne(context.Background(), revision)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		revision.RevisionID = result.InsertedID.(primitive.ObjectID)
+		return nil
+	}
+	return err
+}
+
+// CreateFirstRevision saves the revision as the recipe's first one. A recipe only has one first revision, so
+// when it already has one nothing is saved.
+func (r RevisionRepository) CreateFirstRevision(revision *models.RecipeRevision) error {
+	revision.Number = 1
+	revision.RevisionID = primitive.NilObjectID
+	result, err := r.revisionCollection.InsertOne(context.Background(), revision)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	revision.RevisionID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r RevisionRepository) DeleteRevision(recipeID string, number int) error {
+	id, _ := primitive.ObjectIDFromHex(recipeID)
+	_, err := r.revisionCollection.DeleteOne(context.Background(), bson.M{"recipeid": id, "number": number})
+	return err
+}
+
+func (r RevisionRepository) DeleteRevisions(recipeID string) error {
+	id, _ := primitive.ObjectIDFromHex(recipeID)
+	_, err := r.revisionCollection.DeleteMany(context.Background(), bson.M{"recipeid": id})
+	return err
+}
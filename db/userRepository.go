@@ -16,6 +16,7 @@ type UserDB interface {
 	UserCreator
 	UserDeleter
 	UserUpdater
+	HouseholdMemberGetter
 }
 
 type UserGetterUpdater interface {
@@ -29,6 +30,10 @@ type UserGetter interface {
 	GetAllUsers() ([]models.User, error)
 }
 
+type HouseholdMemberGetter interface {
+	GetHouseholdMembers(householdID string) ([]models.User, error)
+}
+
 type UserCreator interface {
 	CreateUser(userInformation models.RequestedUser) (models.User, error)
 }
@@ -171,9 +176,33 @@ func (ur UserRepository) GetAllUsers() ([]models.User, error) {
 	return users, nil
 }
 
+func (ur UserRepository) GetHouseholdMembers(householdID string) ([]models.User, error) {
+	var users []models.User
+	cur, err := ur.userCollection.Find(context.Background(), bson.M{"householdid": householdID})
+	if err != nil {
+		return users, err
+	}
+
+	for cur.Next(context.Background()) {
+		result := models.User{}
+		e := cur.Decode(&result)
+		if e != nil {
+			return users, e
+		}
+		users = append(users, result)
+	}
+
+	if err := cur.Err(); err != nil {
+		return users, err
+	}
+
+	cur.Close(context.Background())
+	return users, nil
+}
+
 func (ur UserRepository) UpdateToken(user models.User) error {
 	updateFilter := bson.M{"_id": user.UserID}
-	setOperation := bson.D{{"$set", bson.D{{"accesstoken", user.AccessToken}, {"expirydate", user.ExpiryDate}}}}
+	setOperation := bson.D{{Key: "$set", Value: bson.D{{Key: "accesstoken", Value: user.AccessToken}, {Key: "expirydate", Value: user.ExpiryDate}}}}
 	updateResult, updateErr := ur.userCollection.UpdateOne(context.Background(), updateFilter, setOperation)
 
 	if updateErr != nil || updateResult.ModifiedCount != 1 {
@@ -0,0 +1,18 @@
+package db
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrVersionConflict is returned when a document was changed since the version an update was made from
+var ErrVersionConflict = errors.New("version conflict")
+
+// versionFilter matches a document at the version, documents saved before versions were kept count as version 0
+func versionFilter(version int) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
+}
@@ -0,0 +1,155 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+
+	"server/models"
+	"server/units"
+)
+
+// The formats a recipe can be exported in
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatJSONLD   = "jsonld"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+var formatAliases = map[string]string{
+	"markdown": FormatMarkdown,
+	"md":       FormatMarkdown,
+	"html":     FormatHTML,
+	"jsonld":   FormatJSONLD,
+	"json-ld":  FormatJSONLD,
+	"json":     FormatJSONLD,
+}
+
+var contentTypes = map[string]string{
+	FormatMarkdown: "text/markdown; charset=utf-8",
+	FormatHTML:     "text/html; charset=utf-8",
+	FormatJSONLD:   "application/ld+json",
+}
+
+var extensions = map[string]string{
+	FormatMarkdown: ".md",
+	FormatHTML:     ".html",
+	FormatJSONLD:   ".json",
+}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Document is an exported recipe ready to be downloaded
+type Document struct {
+	ContentType string
+	FileName    string
+	Body        []byte
+}
+
+// ParseFormat reads a format by name, "md" and "json-ld" are accepted as well
+func ParseFormat(name string) (string, error) {
+	if format, ok := formatAliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return format, nil
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownFormat, name)
+}
+
+// Negotiate picks the first format the Accept header asks for, or Markdown when it asks for none of them
+func Negotiate(accept string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		for format, contentType := range contentTypes {
+			if expected, _, _ := mime.ParseMediaType(contentType); expected == mediaType {
+				return format
+			}
+		}
+	}
+	return FormatMarkdown
+}
+
+// Render exports one recipe in the format
+func Render(recipe models.Recipe, format string) (Document, error) {
+	var body []byte
+	var err error
+	switch format {
+	case FormatMarkdown:
+		body = []byte(Markdown(recipe))
+	case FormatHTML:
+		body, err = HTML(recipe)
+	case FormatJSONLD:
+		body, err = JSONLD(recipe)
+	default:
+		err = fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return Document{}, err
+	}
+	return Document{ContentType: contentTypes[format], FileName: FileName(recipe, format), Body: body}, nil
+}
+
+// Zip exports every recipe in the format into one zip archive
+func Zip(recipes []models.Recipe, format string) (Document, error) {
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for _, recipe := range recipes {
+		document, err := Render(recipe, format)
+		if err != nil {
+			return Document{}, err
+		}
+		file, err := archive.Create(document.FileName)
+		if err != nil {
+			return Document{}, err
+		}
+		if _, err := file.Write(document.Body); err != nil {
+			return Document{}, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return Document{}, err
+	}
+	return Document{ContentType: "application/zip", FileName: "recipes.zip", Body: buffer.Bytes()}, nil
+}
+
+// FileName names the exported recipe after it, with its ID so recipes with the same name don't collide
+func FileName(recipe models.Recipe, format string) string {
+	name := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(recipe.RecipeName), "-"), "-")
+	if name == "" {
+		name = "recipe"
+	}
+	if !recipe.RecipeID.IsZero() {
+		name += "-" + recipe.RecipeID.Hex()
+	}
+	return name + extensions[format]
+}
+
+// formatIngredient writes an ingredient the way a recipe lists it, like "1 1/2 cup flour"
+func formatIngredient(ingredient models.Ingredient) string {
+	return units.FormatIngredient(float64(ingredient.Amount), ingredient.Measurement, ingredient.Name)
+}
+
+// summary lists the recipe's times, servings and calories, skipping any that aren't set
+func summary(recipe models.Recipe) []string {
+	var details []string
+	if recipe.PrepTime > 0 {
+		details = append(details, fmt.Sprintf("Prep %d min", recipe.PrepTime))
+	}
+	if recipe.CookTime > 0 {
+		details = append(details, fmt.Sprintf("Cook %d min", recipe.CookTime))
+	}
+	if recipe.Servings > 0 {
+		details = append(details, fmt.Sprintf("Serves %d", recipe.Servings))
+	}
+	if recipe.Calories > 0 {
+		details = append(details, fmt.Sprintf("%d calories per serving", recipe.Calories))
+	}
+	return details
+}
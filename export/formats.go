@@ -0,0 +1,186 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"server/models"
+)
+
+// Markdown writes the recipe as a Markdown document
+func Markdown(recipe models.Recipe) string {
+	var builder strings.Builder
+	builder.WriteString("# " + recipe.RecipeName + "\n\n")
+	if recipe.Author != "" {
+		builder.WriteString("*By " + recipe.Author + "*\n\n")
+	}
+	if details := summary(recipe); len(details) > 0 {
+		builder.WriteString(strings.Join(details, " · ") + "\n\n")
+	}
+	if len(recipe.Tags) > 0 {
+		builder.WriteString("Tags: " + strings.Join(recipe.Tags, ", ") + "\n\n")
+	}
+	if len(recipe.Ingredients) > 0 {
+		builder.WriteString("## Ingredients\n\n")
+		for _, ingredient := range recipe.Ingredients {
+			builder.WriteString("- " + formatIngredient(ingredient) + "\n")
+		}
+		builder.WriteString("\n")
+	}
+	if len(recipe.Steps) > 0 {
+		builder.WriteString("## Steps\n\n")
+		for i, step := range recipe.Steps {
+			builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, step.Text))
+		}
+	}
+	return strings.TrimRight(builder.String(), "\n") + "\n"
+}
+
+var cardTemplate = template.Must(template.New("card").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Recipe.RecipeName}}</title>
+<style>
+body { font-family: Georgia, serif; color: #222; max-width: 42rem; margin: 2rem auto; padding: 0 1rem; }
+h1 { margin-bottom: 0.25rem; }
+.author, .summary, .tags { color: #555; margin: 0.25rem 0; }
+h2 { border-bottom: 1px solid #ccc; padding-bottom: 0.25rem; }
+li { margin: 0.3rem 0; }
+@media print {
+  body { margin: 0; max-width: none; font-size: 12pt; }
+  section { break-inside: avoid; }
+}
+</style>
+</head>
+<body>
+<article>
+<h1>{{.Recipe.RecipeName}}</h1>
+{{- if .Recipe.Author}}
+<p class="author">By {{.Recipe.Author}}</p>
+{{- end}}
+{{- if .Summary}}
+<p class="summary">{{.Summary}}</p>
+{{- end}}
+{{- if .Recipe.Tags}}
+<p class="tags">{{range $i, $tag := .Recipe.Tags}}{{if $i}}, {{end}}{{$tag}}{{end}}</p>
+{{- end}}
+{{- if .Ingredients}}
+<section>
+<h2>Ingredients</h2>
+<ul>
+{{- range .Ingredients}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+</section>
+{{- end}}
+{{- if .Recipe.Steps}}
+<section>
+<h2>Steps</h2>
+<ol>
+{{- range .Recipe.Steps}}
+<li>{{.Text}}</li>
+{{- end}}
+</ol>
+</section>
+{{- end}}
+</article>
+</body>
+</html>
+`))
+
+// HTML writes the recipe as a printable card that needs nothing but itself to display
+func HTML(recipe models.Recipe) ([]byte, error) {
+	ingredients := make([]string, len(recipe.Ingredients))
+	for i, ingredient := range recipe.Ingredients {
+		ingredients[i] = formatIngredient(ingredient)
+	}
+	var buffer bytes.Buffer
+	err := cardTemplate.Execute(&buffer, struct {
+		Recipe      models.Recipe
+		Summary     string
+		Ingredients []string
+	}{recipe, strings.Join(summary(recipe), " · "), ingredients})
+	return buffer.Bytes(), err
+}
+
+type recipeJSONLD struct {
+	Context            string       `json:"@context"`
+	Type               string       `json:"@type"`
+	Name               string       `json:"name"`
+	Author             *thingJSONLD `json:"author,omitempty"`
+	DatePublished      string       `json:"datePublished,omitempty"`
+	PrepTime           string       `json:"prepTime,omitempty"`
+	CookTime           string       `json:"cookTime,omitempty"`
+	TotalTime          string       `json:"totalTime,omitempty"`
+	RecipeYield        string       `json:"recipeYield,omitempty"`
+	Keywords           string       `json:"keywords,omitempty"`
+	Nutrition          *thingJSONLD `json:"nutrition,omitempty"`
+	RecipeIngredient   []string     `json:"recipeIngredient,omitempty"`
+	RecipeInstructions []stepJSONLD `json:"recipeInstructions,omitempty"`
+}
+
+type thingJSONLD struct {
+	Type     string `json:"@type"`
+	Name     string `json:"name,omitempty"`
+	Calories string `json:"calories,omitempty"`
+}
+
+type stepJSONLD struct {
+	Type string `json:"@type"`
+	Text string `json:"text"`
+}
+
+// JSONLD writes the recipe as a schema.org Recipe
+func JSONLD(recipe models.Recipe) ([]byte, error) {
+	document := recipeJSONLD{
+		Context:  "https://schema.org",
+		Type:     "Recipe",
+		Name:     recipe.RecipeName,
+		PrepTime: isoDuration(recipe.PrepTime),
+		CookTime: isoDuration(recipe.CookTime),
+		Keywords: strings.Join(recipe.Tags, ", "),
+	}
+	if recipe.PrepTime > 0 && recipe.CookTime > 0 {
+		document.TotalTime = isoDuration(recipe.PrepTime + recipe.CookTime)
+	}
+	if recipe.Author != "" {
+		document.Author = &thingJSONLD{Type: "Person", Name: recipe.Author}
+	}
+	if created, err := time.Parse("2006.01.02 15:04:05", recipe.CreatedDate); err == nil {
+		document.DatePublished = created.Format("2006-01-02")
+	}
+	if recipe.Servings > 0 {
+		document.RecipeYield = fmt.Sprintf("%d servings", recipe.Servings)
+	}
+	if recipe.Calories > 0 {
+		document.Nutrition = &thingJSONLD{Type: "NutritionInformation", Calories: fmt.Sprintf("%d calories", recipe.Calories)}
+	}
+	for _, ingredient := range recipe.Ingredients {
+		document.RecipeIngredient = append(document.RecipeIngredient, formatIngredient(ingredient))
+	}
+	for _, step := range recipe.Steps {
+		document.RecipeInstructions = append(document.RecipeInstructions, stepJSONLD{Type: "HowToStep", Text: step.Text})
+	}
+	return json.MarshalIndent(document, "", "  ")
+}
+
+// isoDuration writes minutes as an ISO 8601 duration like PT1H30M
+func isoDuration(minutes int) string {
+	if minutes <= 0 {
+		return ""
+	}
+	duration := "PT"
+	if minutes >= 60 {
+		duration += fmt.Sprintf("%dH", minutes/60)
+	}
+	if minutes%60 > 0 {
+		duration += fmt.Sprintf("%dM", minutes%60)
+	}
+	return duration
+}
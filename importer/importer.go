@@ -0,0 +1,131 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"server/models"
+	"server/units"
+)
+
+// The formats a recipe can be imported from
+const (
+	FormatJSONLD = "jsonld"
+	FormatText   = "text"
+)
+
+var ErrUnrecognizedRecipe = errors.New("unrecognized recipe")
+
+var (
+	bulletPattern  = regexp.MustCompile(`^\s*(?:[-*•▪◦]|\[\s?\])\s*`)
+	numberPattern  = regexp.MustCompile(`\d+`)
+	hoursPattern   = regexp.MustCompile(`(?i)(\d+)\s*(?:h|hr|hrs|hour|hours)\b`)
+	minutesPattern = regexp.MustCompile(`(?i)(\d+)\s*(?:m|min|mins|minute|minutes)\b`)
+)
+
+// Parse reads a recipe in the given format. When the format is left out, content that looks like
+// JSON is read as JSON-LD and anything else as plain text.
+func Parse(format string, content string) (models.Recipe, error) {
+	if format == "" {
+		format = FormatText
+		if trimmed := strings.TrimSpace(content); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			format = FormatJSONLD
+		}
+	}
+	switch strings.ToLower(format) {
+	case FormatJSONLD:
+		return FromJSONLD([]byte(content))
+	case FormatText:
+		return FromText(content)
+	}
+	return models.Recipe{}, fmt.Errorf("%w: unknown format %q", ErrUnrecognizedRecipe, format)
+}
+
+// ParseIngredient splits a line like "1 1/2 cups flour, sifted" into its amount, unit and name.
+// Lines without an amount are kept whole as the name, and "salt to taste" is measured to taste.
+func ParseIngredient(line string) models.Ingredient {
+	text := strings.TrimSpace(bulletPattern.ReplaceAllString(line, ""))
+	lower := strings.ToLower(text)
+	for _, suffix := range []string{", to taste", " to taste"} {
+		if strings.HasSuffix(lower, suffix) {
+			return models.Ingredient{Name: trimName(text[:len(text)-len(suffix)]), Measurement: units.Taste.Name}
+		}
+	}
+
+	words := strings.Fields(text)
+	amount, used := leadingAmount(words)
+	if used == 0 {
+		return models.Ingredient{Name: trimName(text)}
+	}
+	words = words[used:]
+	ingredient := models.Ingredient{Amount: float32(amount)}
+	for length := 2; length > 0; length-- {
+		if len(words) <= length {
+			continue
+		}
+		if unit, err := units.Lookup(strings.Join(words[:length], " ")); err == nil {
+			ingredient.Measurement = unit.Name
+			words = words[length:]
+			break
+		}
+	}
+	if len(words) > 1 && strings.EqualFold(words[0], "of") {
+		words = words[1:]
+	}
+	ingredient.Name = trimName(strings.Join(words, " "))
+	return ingredient
+}
+
+// leadingAmount finds the amount at the start of the words, returning how many words it took up
+func leadingAmount(words []string) (float64, int) {
+	for length := 2; length > 0; length-- {
+		if len(words) < length {
+			continue
+		}
+		if amount, err := units.ParseAmount(strings.Join(words[:length], " ")); err == nil {
+			return amount, length
+		}
+	}
+	return 0, 0
+}
+
+func trimName(name string) string {
+	return strings.TrimSpace(strings.Trim(strings.TrimSpace(name), ",;:"))
+}
+
+// firstNumber is the first whole number written in text, like the 4 in "Serves 4 to 6"
+func firstNumber(text string) int {
+	number, _ := strconv.Atoi(numberPattern.FindString(text))
+	return number
+}
+
+// parseMinutes reads a written duration like "1 hour 15 mins" as minutes
+func parseMinutes(text string) int {
+	minutes := 0
+	if match := hoursPattern.FindStringSubmatch(text); match != nil {
+		hours, _ := strconv.Atoi(match[1])
+		minutes += hours * 60
+	}
+	if match := minutesPattern.FindStringSubmatch(text); match != nil {
+		mins, _ := strconv.Atoi(match[1])
+		minutes += mins
+	}
+	if minutes == 0 {
+		return firstNumber(text)
+	}
+	return minutes
+}
+
+// numberSteps numbers the steps in order, dropping any that are empty
+func numberSteps(texts []string) []models.Step {
+	var steps []models.Step
+	for _, text := range texts {
+		if trimmed := strings.TrimSpace(text); trimmed != "" {
+			steps = append(steps, models.Step{Number: len(steps) + 1, Text: trimmed})
+		}
+	}
+	return steps
+}
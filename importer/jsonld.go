@@ -0,0 +1,169 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"server/models"
+)
+
+var (
+	tagPattern      = regexp.MustCompile(`<[^>]*>`)
+	durationPattern = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+)
+
+// FromJSONLD reads a schema.org Recipe. The recipe may be the document itself, part of an array
+// or part of an @graph, which is how most sites embed it.
+func FromJSONLD(data []byte) (models.Recipe, error) {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return models.Recipe{}, fmt.Errorf("%w: %v", ErrUnrecognizedRecipe, err)
+	}
+	node := findRecipeNode(document)
+	if node == nil {
+		return models.Recipe{}, fmt.Errorf("%w: no schema.org Recipe found", ErrUnrecognizedRecipe)
+	}
+
+	recipe := models.Recipe{
+		RecipeName: cleanText(text(node["name"])),
+		Author:     cleanText(text(node["author"])),
+		PrepTime:   parseDuration(text(node["prepTime"])),
+		CookTime:   parseDuration(text(node["cookTime"])),
+		Servings:   firstNumber(text(node["recipeYield"])),
+	}
+	if nutrition, ok := node["nutrition"].(map[string]interface{}); ok {
+		recipe.Calories = firstNumber(text(nutrition["calories"]))
+	}
+	for _, line := range list(node["recipeIngredient"]) {
+		if ingredient := ParseIngredient(cleanText(text(line))); ingredient.Name != "" {
+			recipe.Ingredients = append(recipe.Ingredients, ingredient)
+		}
+	}
+	recipe.Steps = numberSteps(instructions(node["recipeInstructions"]))
+	recipe.Tags = tags(node["recipeCategory"], node["recipeCuisine"], node["keywords"])
+	return recipe, nil
+}
+
+// findRecipeNode searches the document for the first node typed as a Recipe
+func findRecipeNode(value interface{}) map[string]interface{} {
+	switch typed := value.(type) {
+	case []interface{}:
+		for _, item := range typed {
+			if node := findRecipeNode(item); node != nil {
+				return node
+			}
+		}
+	case map[string]interface{}:
+		for _, recipeType := range list(typed["@type"]) {
+			name, _ := recipeType.(string)
+			if name == "Recipe" || strings.HasSuffix(name, "/Recipe") || strings.HasSuffix(name, ":Recipe") {
+				return typed
+			}
+		}
+		return findRecipeNode(typed["@graph"])
+	}
+	return nil
+}
+
+// instructions flattens recipeInstructions, which may be one block of text, a list of strings or of
+// HowToSteps, or HowToSections that each have their own steps
+func instructions(value interface{}) []string {
+	if block, ok := value.(string); ok {
+		return strings.Split(cleanText(strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n").Replace(block)), "\n")
+	}
+	var texts []string
+	for _, item := range list(value) {
+		if node, ok := item.(map[string]interface{}); ok && node["itemListElement"] != nil {
+			texts = append(texts, instructions(node["itemListElement"])...)
+		} else {
+			texts = append(texts, cleanText(text(item)))
+		}
+	}
+	return texts
+}
+
+// tags gathers categories, cuisines and keywords, which are often a single comma separated string
+func tags(values ...interface{}) []string {
+	var result []string
+	seen := map[string]bool{}
+	for _, value := range values {
+		for _, item := range list(value) {
+			for _, tag := range strings.Split(text(item), ",") {
+				tag = strings.ToLower(cleanText(tag))
+				if tag != "" && !seen[tag] {
+					seen[tag] = true
+					result = append(result, tag)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// text reads a value that may be a string, a number, an object with a name or text, or a list of those
+func text(value interface{}) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case map[string]interface{}:
+		for _, key := range []string{"text", "name", "@value"} {
+			if found := text(typed[key]); found != "" {
+				return found
+			}
+		}
+	case []interface{}:
+		var texts []string
+		for _, item := range typed {
+			if found := text(item); found != "" {
+				texts = append(texts, found)
+			}
+		}
+		return strings.Join(texts, ", ")
+	}
+	return ""
+}
+
+// list treats a single value as a list of one, since JSON-LD allows either
+func list(value interface{}) []interface{} {
+	switch typed := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return typed
+	}
+	return []interface{}{value}
+}
+
+// cleanText strips HTML and collapses whitespace, keeping line breaks
+func cleanText(value string) string {
+	lines := strings.Split(html.UnescapeString(tagPattern.ReplaceAllString(value, "")), "\n")
+	var cleaned []string
+	for _, line := range lines {
+		if trimmed := strings.Join(strings.Fields(line), " "); trimmed != "" {
+			cleaned = append(cleaned, trimmed)
+		}
+	}
+	return strings.Join(cleaned, "\n")
+}
+
+// parseDuration reads an ISO 8601 duration like PT1H30M as minutes
+func parseDuration(value string) int {
+	match := durationPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(value)))
+	if match == nil {
+		return parseMinutes(value)
+	}
+	minutes := 0.0
+	for i, perUnit := range []float64{24 * 60, 60, 1, 1.0 / 60} {
+		if match[i+1] != "" {
+			amount, _ := strconv.ParseFloat(match[i+1], 64)
+			minutes += amount * perUnit
+		}
+	}
+	return int(minutes + 0.5)
+}
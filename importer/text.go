@@ -0,0 +1,84 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"server/models"
+)
+
+var (
+	ingredientHeaders = []string{"ingredients", "ingredient list", "you will need"}
+	stepHeaders       = []string{"instructions", "directions", "steps", "method", "preparation"}
+	servingsPattern   = regexp.MustCompile(`(?i)^(?:serves|servings|yield|yields|makes)\b`)
+	prepTimePattern   = regexp.MustCompile(`(?i)^prep(?:aration)?(?: time)?\s*[:\-]`)
+	cookTimePattern   = regexp.MustCompile(`(?i)^cook(?:ing)?(?: time)?\s*[:\-]`)
+	caloriesPattern   = regexp.MustCompile(`(?i)^calories\b`)
+	stepNumberPattern = regexp.MustCompile(`(?i)^\s*(?:step\s*)?\d+\s*[.):]\s*`)
+)
+
+// FromText reads a pasted recipe. The first line is its name, followed by optional lines like
+// "Serves 4" or "Prep time: 10 minutes", then an ingredients section and a steps section, each
+// starting with a heading like "Ingredients" or "Directions".
+func FromText(content string) (models.Recipe, error) {
+	recipe := models.Recipe{}
+	section := ""
+	var stepTexts []string
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		heading := strings.ToLower(strings.TrimRight(trimmed, ":"))
+		if isHeading(heading, ingredientHeaders) {
+			section = "ingredients"
+			continue
+		}
+		if isHeading(heading, stepHeaders) {
+			section = "steps"
+			continue
+		}
+
+		switch section {
+		case "ingredients":
+			if ingredient := ParseIngredient(trimmed); ingredient.Name != "" {
+				recipe.Ingredients = append(recipe.Ingredients, ingredient)
+			}
+		case "steps":
+			stepTexts = append(stepTexts, stepNumberPattern.ReplaceAllString(bulletPattern.ReplaceAllString(trimmed, ""), ""))
+		default:
+			readDetail(&recipe, trimmed)
+		}
+	}
+	recipe.Steps = numberSteps(stepTexts)
+	if len(recipe.Ingredients) == 0 && len(recipe.Steps) == 0 {
+		return models.Recipe{}, fmt.Errorf("%w: no ingredients or steps sections found", ErrUnrecognizedRecipe)
+	}
+	return recipe, nil
+}
+
+// readDetail reads a line before the ingredients, which is the name or one of the recipe's numbers
+func readDetail(recipe *models.Recipe, line string) {
+	switch {
+	case servingsPattern.MatchString(line):
+		recipe.Servings = firstNumber(line)
+	case prepTimePattern.MatchString(line):
+		recipe.PrepTime = parseMinutes(line)
+	case cookTimePattern.MatchString(line):
+		recipe.CookTime = parseMinutes(line)
+	case caloriesPattern.MatchString(line):
+		recipe.Calories = firstNumber(line)
+	case recipe.RecipeName == "":
+		recipe.RecipeName = line
+	}
+}
+
+func isHeading(line string, headings []string) bool {
+	for _, heading := range headings {
+		if line == heading {
+			return true
+		}
+	}
+	return false
+}
@@ -50,30 +50,37 @@ func main() {
 
 	// Get controllers with their associated DB connections
 	var userController = controller.NewUserController()
-	var recipeController = controller.NewRecipeController(db.NewRecipeRepository(mongoClient))
+	var recipeController = controller.NewRecipeController(db.NewRecipeRepository(mongoClient), db.NewRevisionRepository(mongoClient), db.NewIngredientRepository(mongoClient))
+	var reviewController = controller.NewReviewController(db.NewReviewRepository(mongoClient), db.NewRecipeRepository(mongoClient))
+	var collectionController = controller.NewCollectionController(db.NewCollectionRepository(mongoClient), recipeController)
 	var ingredientController = controller.NewIngredientController()
 	// Check this one since it calls NewUserRepository a second time
 	var authController = controller.NewAuthenticationController()
 	var serverController = controller.NewServerController(mongoClient)
-	var householdController = controller.NewHouseholdController(db.NewCalendarRepository(mongoClient), db.NewHouseholdRepository(mongoClient), recipeController)
+	var householdController = controller.NewHouseholdController(db.NewCalendarRepository(mongoClient), db.NewHouseholdRepository(mongoClient), db.NewMealRepository(mongoClient), recipeController)
+	var historyController = controller.NewHistoryController(db.NewHistoryRepository(mongoClient), db.NewCalendarRepository(mongoClient), recipeController)
+	var mealController = controller.NewMealController(db.NewMealRepository(mongoClient), db.NewCalendarRepository(mongoClient), recipeController)
 
 	// Get middleware wrapping their controllers
 	var authMiddleware = middleware.NewAuthMiddleware(authController, db.NewUserRepository(mongoClient))
 	var userMiddleware = middleware.NewUserMiddleware(authMiddleware, userController, db.NewUserRepository(mongoClient))
-	var recipeMiddleware = middleware.NewRecipeMiddleware(authMiddleware, recipeController)
+	var recipeMiddleware = middleware.NewRecipeMiddleware(authMiddleware, recipeController, reviewController, collectionController)
 	var ingredientMiddleware = middleware.NewIngredientMiddleware(authMiddleware, ingredientController, db.NewIngredientRepository(mongoClient))
 	var serverMiddleware = middleware.NewServerMiddleware(serverController)
 	var householdMiddleware = middleware.NewHouseholdMiddleware(authMiddleware,
 		userMiddleware,
 		householdController,
 		db.NewHouseholdRepository(mongoClient),
-		db.NewCalendarRepository(mongoClient))
+		db.NewCalendarRepository(mongoClient),
+		historyController)
+	var collectionMiddleware = middleware.NewCollectionMiddleware(authMiddleware, collectionController)
+	var mealMiddleware = middleware.NewMealMiddleware(authMiddleware, mealController)
 
 	// If the above dependency setup starts getting much bigger we might want to look into a DI package like dig or wire
 	// to more cleanly manage it
 
 	// Build router from middleware
-	var tastyRouter = router.NewTastyBoiRouter(userMiddleware, recipeMiddleware, ingredientMiddleware, serverMiddleware, householdMiddleware)
+	var tastyRouter = router.NewTastyBoiRouter(userMiddleware, recipeMiddleware, ingredientMiddleware, serverMiddleware, householdMiddleware, collectionMiddleware, mealMiddleware)
 	if err != nil {
 		log.Fatal(err)
 	}
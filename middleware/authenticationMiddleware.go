@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"server/controller"
 	"server/db"
+	"server/models"
 	"strings"
 )
 
@@ -37,3 +38,32 @@ func (am AuthMiddleware) AuthenticateSpecificUser(response http.ResponseWriter,
 	}
 	return userErr
 }
+
+// CurrentUser looks up the user making the request from their token
+func (am AuthMiddleware) CurrentUser(request *http.Request) (models.User, error) {
+	bearerToken := request.Header.Get("Authorization")
+	return am.repository.GetUserByAccessToken(strings.ReplaceAll(bearerToken, "Bearer ", ""))
+}
+
+// CurrentViewer resolves the user making the request and their household, which decides the recipes they can see
+func (am AuthMiddleware) CurrentViewer(request *http.Request) (models.Viewer, error) {
+	user, err := am.CurrentUser(request)
+	if err != nil {
+		return models.Viewer{}, err
+	}
+	viewer := models.Viewer{UserName: user.UserName, Restrictions: user.Restrictions, HouseholdRestrictions: user.Restrictions}
+	if user.HouseholdId == "" {
+		return viewer, nil
+	}
+	viewer.HouseholdRestrictions = models.DietaryRestrictions{}
+	members, err := am.repository.GetHouseholdMembers(user.HouseholdId)
+	if err != nil {
+		return models.Viewer{}, err
+	}
+	for _, member := range members {
+		viewer.HouseholdMembers = append(viewer.HouseholdMembers, member.UserName)
+		viewer.HouseholdRestrictions.Allergens = append(viewer.HouseholdRestrictions.Allergens, member.Restrictions.Allergens...)
+		viewer.HouseholdRestrictions.Diets = append(viewer.HouseholdRestrictions.Diets, member.Restrictions.Diets...)
+	}
+	return viewer, nil
+}
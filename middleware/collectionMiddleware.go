@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"server/db"
+
+	"server/controller"
+	"server/models"
+
+	"github.com/gorilla/mux"
+)
+
+type CollectionMiddleware struct {
+	auth       AuthMiddleware
+	controller controller.CollectionControl
+}
+
+func NewCollectionMiddleware(auth AuthMiddleware, controller controller.CollectionControl) CollectionMiddleware {
+	return CollectionMiddleware{auth, controller}
+}
+
+// GetCollections lists the user's collections and the ones shared with their household
+func (cm CollectionMiddleware) GetCollections(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := cm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		viewer, _ := cm.auth.CurrentViewer(r)
+		payload, err := cm.controller.GetCollections(viewer)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// CreateCollection creates an empty collection for the user
+func (cm CollectionMiddleware) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+	userErr := cm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		var collection models.Collection
+		_ = json.NewDecoder(r.Body).Decode(&collection)
+		viewer, _ := cm.auth.CurrentViewer(r)
+		payload, err := cm.controller.CreateCollection(collection, viewer)
+		if errors.Is(err, controller.ErrCollectionName) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// GetCollection gets a collection by ID
+func (cm CollectionMiddleware) GetCollection(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := cm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		viewer, _ := cm.auth.CurrentViewer(r)
+		payload, err := cm.controller.GetCollection(params["id"], viewer)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// UpdateCollection renames a collection and sets whether it is shared with the user's household
+func (cm CollectionMiddleware) UpdateCollection(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "PUT")
+	userErr := cm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		var collection models.Collection
+		_ = json.NewDecoder(r.Body).Decode(&collection)
+		viewer, _ := cm.auth.CurrentViewer(r)
+		payload, err := cm.controller.UpdateCollection(params["id"], collection, viewer)
+		writeCollectionResult(w, payload, err)
+	}
+}
+
+// DeleteCollection deletes one of the user's collections
+func (cm CollectionMiddleware) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "DELETE")
+	userErr := cm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		viewer, _ := cm.auth.CurrentViewer(r)
+		err := cm.controller.DeleteCollection(params["id"], viewer)
+		if errors.Is(err, controller.ErrNotCollectionOwner) {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// AddRecipe saves a recipe to a collection
+func (cm CollectionMiddleware) AddRecipe(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "PUT")
+	userErr := cm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		viewer, _ := cm.auth.CurrentViewer(r)
+		payload, err := cm.controller.AddRecipe(params["id"], params["recipeID"], viewer)
+		writeCollectionResult(w, payload, err)
+	}
+}
+
+// RemoveRecipe takes a recipe out of a collection
+func (cm CollectionMiddleware) RemoveRecipe(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "DELETE")
+	userErr := cm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		viewer, _ := cm.auth.CurrentViewer(r)
+		payload, err := cm.controller.RemoveRecipe(params["id"], params["recipeID"], viewer)
+		writeCollectionResult(w, payload, err)
+	}
+}
+
+// GetCollectionRecipes pages through the recipes of a collection, taking the same body as PostPaginatedRecipes
+func (cm CollectionMiddleware) GetCollectionRecipes(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+	userErr := cm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		var paginatedRequest models.PaginatedRecipeRequest
+		_ = json.NewDecoder(r.Body).Decode(&paginatedRequest)
+		paginatedRequest.Viewer, _ = cm.auth.CurrentViewer(r)
+		payload, err := cm.controller.GetCollectionRecipes(params["id"], paginatedRequest)
+		if errors.Is(err, controller.ErrCollectionNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+		} else if errors.Is(err, controller.ErrInvalidFilter) || errors.Is(err, controller.ErrInvalidSort) || errors.Is(err, db.ErrInvalidCursor) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// writeCollectionResult writes a changed collection, or the status for why it couldn't be changed
+func writeCollectionResult(w http.ResponseWriter, collection models.Collection, err error) {
+	if errors.Is(err, controller.ErrCollectionName) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(err.Error())
+	} else if errors.Is(err, controller.ErrNotCollectionOwner) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(err.Error())
+	} else if errors.Is(err, controller.ErrCollectionNotFound) || errors.Is(err, controller.ErrRecipeNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(collection)
+	}
+}
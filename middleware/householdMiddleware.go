@@ -2,14 +2,19 @@ package middleware
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"server/db"
+	"strconv"
 	"strings"
 
 	"server/controller"
 	"server/models"
 
 	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type HouseholdMiddleware struct {
@@ -18,10 +23,11 @@ type HouseholdMiddleware struct {
 	controller   controller.HouseholdControl
 	repository   db.HouseholdDB
 	calendarRepo db.CalendarDB
+	history      controller.HistoryControl
 }
 
-func NewHouseholdMiddleware(auth AuthMiddleware, um UserMiddleware, controller controller.HouseholdControl, r db.HouseholdDB, c db.CalendarDB) HouseholdMiddleware {
-	return HouseholdMiddleware{auth, um, controller, r, c}
+func NewHouseholdMiddleware(auth AuthMiddleware, um UserMiddleware, controller controller.HouseholdControl, r db.HouseholdDB, c db.CalendarDB, h controller.HistoryControl) HouseholdMiddleware {
+	return HouseholdMiddleware{auth, um, controller, r, c, h}
 }
 
 //CreateHousehold creates a new household in the database
@@ -92,6 +98,7 @@ func (hm HouseholdMiddleware) AddUserToHousehold(w http.ResponseWriter, r *http.
 	}
 }
 
+// GetCalendar gets the household's calendar for the startDate, ?expand=recipes fills in its recipes
 func (hm HouseholdMiddleware) GetCalendar(w http.ResponseWriter, r *http.Request) {
 	writeCommonHeaders(w)
 	w.Header().Set("Access-Control-Allow-Methods", "GET")
@@ -102,10 +109,12 @@ func (hm HouseholdMiddleware) GetCalendar(w http.ResponseWriter, r *http.Request
 		startDate := r.URL.Query().Get("startDate")
 		bearerToken := r.Header.Get("Authorization")
 		currentUser, _ := hm.um.repository.GetUserByAccessToken(strings.ReplaceAll(bearerToken, "Bearer ", ""))
-		payload, err := hm.controller.GetCalendar(currentUser.HouseholdId, startDate)
+		viewer, _ := hm.auth.CurrentViewer(r)
+		payload, err := hm.controller.GetCalendar(currentUser.HouseholdId, startDate, viewer, expandsRecipes(r.URL.Query()))
 		if err != nil {
 			w.WriteHeader(http.StatusNotFound)
 		} else {
+			w.Header().Set("ETag", etag(payload.Version))
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(payload)
 		}
@@ -119,19 +128,38 @@ func (hm HouseholdMiddleware) UpdateCalendar(w http.ResponseWriter, r *http.Requ
 	if userErr != nil {
 		json.NewEncoder(w).Encode(userErr.Error())
 	} else {
-		var updatedCalendar models.Calendar
-		_ = json.NewDecoder(r.Body).Decode(&updatedCalendar)
-		bearerToken := r.Header.Get("Authorization")
-		currentUser, _ := hm.um.repository.GetUserByAccessToken(strings.ReplaceAll(bearerToken, "Bearer ", ""))
-		payload, err := hm.controller.UpdateCalendar(currentUser.HouseholdId, updatedCalendar)
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
+		version, versionErr := ifMatchVersion(r)
+		if versionErr != nil {
+			writePreconditionError(w, versionErr)
 		} else {
-			json.NewEncoder(w).Encode(payload)
+			var updatedCalendar models.Calendar
+			_ = json.NewDecoder(r.Body).Decode(&updatedCalendar)
+			updatedCalendar.CalendarID, _ = primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+			updatedCalendar.Version = version
+			bearerToken := r.Header.Get("Authorization")
+			currentUser, _ := hm.um.repository.GetUserByAccessToken(strings.ReplaceAll(bearerToken, "Bearer ", ""))
+			payload, err := hm.controller.UpdateCalendar(currentUser.HouseholdId, updatedCalendar)
+			if errors.Is(err, db.ErrVersionConflict) {
+				w.Header().Set("ETag", etag(payload.Version))
+				w.WriteHeader(http.StatusPreconditionFailed)
+				json.NewEncoder(w).Encode(payload)
+			} else if errors.Is(err, controller.ErrCalendarNotFound) || errors.Is(err, controller.ErrNoHousehold) {
+				w.WriteHeader(http.StatusNotFound)
+			} else if errors.Is(err, controller.ErrSlotsNotSynced) {
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(err.Error())
+			} else if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+			} else {
+				w.Header().Set("ETag", etag(payload.Version))
+				json.NewEncoder(w).Encode(payload)
+			}
 		}
 	}
 }
 
+// CreateCalendar plans a week from the startDate and constraints in the body, e.g.
+// {"startDate": "2021.06.06", "constraints": {"weeknightMaxTotalTime": 30, "pinnedDays": {"friday": "..."}}}
 func (hm HouseholdMiddleware) CreateCalendar(w http.ResponseWriter, r *http.Request) {
 	writeCommonHeaders(w)
 	w.Header().Set("Access-Control-Allow-Methods", "GET")
@@ -141,15 +169,408 @@ func (hm HouseholdMiddleware) CreateCalendar(w http.ResponseWriter, r *http.Requ
 	} else {
 		bearerToken := r.Header.Get("Authorization")
 		user, _ := hm.um.repository.GetUserByAccessToken(strings.ReplaceAll(bearerToken, "Bearer ", ""))
-		// Should this be a separate model, something like CalendarCreateRequest that only has the StartDate?
-		var calendar models.Calendar
-		json.NewDecoder(r.Body).Decode(&calendar)
-		payload, err := hm.controller.CreateCalendar(calendar.StartDate, user.HouseholdId)
-		if err != nil {
+		var request models.CalendarRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		viewer, _ := hm.auth.CurrentViewer(r)
+		payload, err := hm.controller.CreateCalendar(request, user.HouseholdId, viewer)
+		if errors.Is(err, controller.ErrInvalidPlan) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if errors.Is(err, controller.ErrNoHousehold) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if errors.Is(err, controller.ErrCalendarExists) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if errors.Is(err, controller.ErrSlotsNotSynced) {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 		} else {
+			w.Header().Set("ETag", etag(payload.Version))
 			w.WriteHeader(http.StatusCreated)
 			json.NewEncoder(w).Encode(payload)
 		}
 	}
 }
+
+// GetCalendars pages through the household's calendars, e.g. ?from=2021.05.01&to=2021.07.01&pageSize=4&pageCount=0,
+// ?expand=recipes fills in their recipes
+func (hm HouseholdMiddleware) GetCalendars(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := hm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		query, queryErr := calendarQueryFromQuery(r.URL.Query())
+		if queryErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			currentUser, _ := hm.auth.CurrentUser(r)
+			viewer, _ := hm.auth.CurrentViewer(r)
+			payload, err := hm.controller.GetCalendars(currentUser.HouseholdId, query, viewer, expandsRecipes(r.URL.Query()))
+			if errors.Is(err, controller.ErrInvalidFilter) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(err.Error())
+			} else if errors.Is(err, controller.ErrNoHousehold) {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(err.Error())
+			} else if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			} else {
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(payload)
+			}
+		}
+	}
+}
+
+// DeleteCalendar deletes one of the household's calendars
+func (hm HouseholdMiddleware) DeleteCalendar(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "DELETE")
+	userErr := hm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		currentUser, _ := hm.auth.CurrentUser(r)
+		err := hm.controller.DeleteCalendar(currentUser.HouseholdId, params["id"])
+		if errors.Is(err, controller.ErrNoHousehold) || errors.Is(err, controller.ErrCalendarNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// CopyCalendar plans a calendar's recipes again for the next week, or for the week in the body as in
+// {"startDate": "2021.06.13"}
+func (hm HouseholdMiddleware) CopyCalendar(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+	userErr := hm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		var request models.CalendarRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		currentUser, _ := hm.auth.CurrentUser(r)
+		payload, err := hm.controller.CopyCalendar(currentUser.HouseholdId, params["id"], request.StartDate)
+		if errors.Is(err, controller.ErrInvalidStartDate) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if errors.Is(err, controller.ErrNoHousehold) || errors.Is(err, controller.ErrCalendarNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if errors.Is(err, controller.ErrCalendarExists) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.Header().Set("ETag", etag(payload.Version))
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// SetCalendarDay plans a recipe on a day of a calendar, e.g. {"recipeID": "..."}
+func (hm HouseholdMiddleware) SetCalendarDay(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "PUT")
+	userErr := hm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		var request models.CalendarDayRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		currentUser, _ := hm.auth.CurrentUser(r)
+		viewer, _ := hm.auth.CurrentViewer(r)
+		payload, err := hm.controller.SetCalendarDay(currentUser.HouseholdId, params["id"], params["day"], request.RecipeID, viewer)
+		writeCalendarDay(w, payload, err)
+	}
+}
+
+// RerollCalendarDay plans another random recipe on a day of a calendar, narrowed down by the same query as
+// random recipes, e.g. ?tags=dinner&maxTotalTime=30&weightByRating=true
+func (hm HouseholdMiddleware) RerollCalendarDay(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+	userErr := hm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		filter, filterErr := recipeFilterFromQuery(r.URL.Query())
+		if filterErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			params := mux.Vars(r)
+			currentUser, _ := hm.auth.CurrentUser(r)
+			viewer, _ := hm.auth.CurrentViewer(r)
+			payload, err := hm.controller.RerollCalendarDay(currentUser.HouseholdId, params["id"], params["day"], models.RandomRecipeRequest{
+				Tags:             queryList(r.URL.Query(), "tags"),
+				Filter:           filter,
+				ExcludeRecipeIDs: queryList(r.URL.Query(), "exclude"),
+				WeightByRating:   r.URL.Query().Get("weightByRating") == "true",
+				Viewer:           viewer,
+			})
+			writeCalendarDay(w, payload, err)
+		}
+	}
+}
+
+// SwapCalendarDays swaps what is planned on a day of a calendar with another day, e.g. {"otherDay": "friday"}
+func (hm HouseholdMiddleware) SwapCalendarDays(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+	userErr := hm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		var request models.CalendarDayRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		currentUser, _ := hm.auth.CurrentUser(r)
+		payload, err := hm.controller.SwapCalendarDays(currentUser.HouseholdId, params["id"], params["day"], request.OtherDay)
+		writeCalendarDay(w, payload, err)
+	}
+}
+
+// ClearCalendarDay removes what is planned on a day of a calendar
+func (hm HouseholdMiddleware) ClearCalendarDay(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "DELETE")
+	userErr := hm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		currentUser, _ := hm.auth.CurrentUser(r)
+		payload, err := hm.controller.ClearCalendarDay(currentUser.HouseholdId, params["id"], params["day"])
+		writeCalendarDay(w, payload, err)
+	}
+}
+
+// GetShoppingList gets the merged shopping list for a calendar
+func (hm HouseholdMiddleware) GetShoppingList(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := hm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		_, payload, ok := hm.currentShoppingList(w, r)
+		if ok {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// EmailShoppingList sends a calendar's shopping list to the current user through the basket email
+func (hm HouseholdMiddleware) EmailShoppingList(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+	userErr := hm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		currentUser, shoppingList, ok := hm.currentShoppingList(w, r)
+		if ok {
+			bearerToken := strings.ReplaceAll(r.Header.Get("Authorization"), "Bearer ", "")
+			basket := controller.ShoppingListToBasket(shoppingList, currentUser.UserName)
+			emailErr := hm.um.Controller.EmailUser(basket, bearerToken, hm.um.repository)
+			if emailErr != nil {
+				fmt.Println("Error Sending Email")
+				fmt.Println(emailErr)
+				w.WriteHeader(http.StatusInternalServerError)
+			} else {
+				w.WriteHeader(http.StatusOK)
+			}
+		}
+	}
+}
+
+// currentShoppingList gets the shopping list for a calendar of the current user's household, writing the status
+// for why it couldn't when it returns false
+func (hm HouseholdMiddleware) currentShoppingList(w http.ResponseWriter, r *http.Request) (models.User, models.ShoppingList, bool) {
+	currentUser, err := hm.auth.CurrentUser(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(err.Error())
+		return models.User{}, models.ShoppingList{}, false
+	}
+	viewer, err := hm.auth.CurrentViewer(r)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return models.User{}, models.ShoppingList{}, false
+	}
+	shoppingList, err := hm.controller.GetShoppingList(currentUser.HouseholdId, mux.Vars(r)["id"], viewer)
+	if errors.Is(err, controller.ErrNoHousehold) || errors.Is(err, controller.ErrCalendarNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(err.Error())
+		return models.User{}, models.ShoppingList{}, false
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return models.User{}, models.ShoppingList{}, false
+	}
+	return currentUser, shoppingList, true
+}
+
+// MarkCooked records that the household cooked what was planned on a day of a calendar, with optional notes and rating
+func (hm HouseholdMiddleware) MarkCooked(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+	userErr := hm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		var entry models.CookedEntry
+		_ = json.NewDecoder(r.Body).Decode(&entry)
+		currentUser, _ := hm.auth.CurrentUser(r)
+		payload, err := hm.history.MarkCooked(currentUser.HouseholdId, params["id"], params["day"], entry, currentUser.UserName)
+		writeCookedEntry(w, payload, err)
+	}
+}
+
+// LogCooked records that the household cooked a recipe that wasn't on one of its calendars
+func (hm HouseholdMiddleware) LogCooked(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+	userErr := hm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		var entry models.CookedEntry
+		_ = json.NewDecoder(r.Body).Decode(&entry)
+		currentUser, _ := hm.auth.CurrentUser(r)
+		viewer, _ := hm.auth.CurrentViewer(r)
+		payload, err := hm.history.LogCooked(currentUser.HouseholdId, entry, viewer)
+		writeCookedEntry(w, payload, err)
+	}
+}
+
+// GetHistory lists what the household cooked, newest first, e.g. ?from=2021-05-01&to=2021-06-01 for last
+// month or ?recipeID=<id>&limit=1 for when a recipe was last made
+func (hm HouseholdMiddleware) GetHistory(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := hm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		query, queryErr := historyQueryFromQuery(r.URL.Query())
+		if queryErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			currentUser, _ := hm.auth.CurrentUser(r)
+			payload, err := hm.history.GetHistory(currentUser.HouseholdId, query)
+			if errors.Is(err, controller.ErrInvalidFilter) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(err.Error())
+			} else if errors.Is(err, controller.ErrNoHousehold) {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(err.Error())
+			} else if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			} else {
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(payload)
+			}
+		}
+	}
+}
+
+// writeCookedEntry writes a recorded cooked entry, or the status for why it couldn't be recorded
+func writeCookedEntry(w http.ResponseWriter, entry models.CookedEntry, err error) {
+	if errors.Is(err, controller.ErrInvalidCookedEntry) || errors.Is(err, controller.ErrUnknownDay) || errors.Is(err, controller.ErrNothingPlanned) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(err.Error())
+	} else if errors.Is(err, controller.ErrNoHousehold) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(err.Error())
+	} else if errors.Is(err, controller.ErrCalendarNotFound) || errors.Is(err, controller.ErrRecipeNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(entry)
+	}
+}
+
+// writeCalendarDay writes a calendar after one of its days changed, or the status for why it couldn't be changed
+func writeCalendarDay(w http.ResponseWriter, calendar models.Calendar, err error) {
+	if errors.Is(err, controller.ErrUnknownDay) || errors.Is(err, controller.ErrInvalidFilter) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(err.Error())
+	} else if errors.Is(err, controller.ErrNoHousehold) || errors.Is(err, controller.ErrCalendarNotFound) ||
+		errors.Is(err, controller.ErrRecipeNotFound) || errors.Is(err, controller.ErrNoRerollRecipe) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(err.Error())
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		w.Header().Set("ETag", etag(calendar.Version))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(calendar)
+	}
+}
+
+// historyQueryFromQuery reads a CookedHistoryQuery from query parameters of the same name
+func historyQueryFromQuery(query url.Values) (models.CookedHistoryQuery, error) {
+	historyQuery := models.CookedHistoryQuery{
+		From:     query.Get("from"),
+		To:       query.Get("to"),
+		RecipeID: query.Get("recipeID"),
+	}
+	if limit := query.Get("limit"); limit != "" {
+		converted, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return models.CookedHistoryQuery{}, err
+		}
+		historyQuery.Limit = converted
+	}
+	return historyQuery, nil
+}
+
+// calendarQueryFromQuery reads a CalendarQuery from query parameters of the same name
+func calendarQueryFromQuery(query url.Values) (models.CalendarQuery, error) {
+	calendarQuery := models.CalendarQuery{From: query.Get("from"), To: query.Get("to")}
+	if pageSize := query.Get("pageSize"); pageSize != "" {
+		converted, err := strconv.ParseInt(pageSize, 10, 64)
+		if err != nil {
+			return models.CalendarQuery{}, err
+		}
+		calendarQuery.PageSize = converted
+	}
+	if pageCount := query.Get("pageCount"); pageCount != "" {
+		converted, err := strconv.Atoi(pageCount)
+		if err != nil {
+			return models.CalendarQuery{}, err
+		}
+		calendarQuery.PageCount = converted
+	}
+	return calendarQuery, nil
+}
+
+// expandsRecipes reads whether the query asks for the recipes a calendar refers to, as in ?expand=recipes
+func expandsRecipes(query url.Values) bool {
+	for _, expand := range queryList(query, "expand") {
+		if strings.EqualFold(expand, "recipes") {
+			return true
+		}
+	}
+	return false
+}
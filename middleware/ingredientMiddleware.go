@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"server/db"
 
@@ -32,7 +33,10 @@ func (im IngredientMiddleware) CreateIngredient(w http.ResponseWriter, r *http.R
 		var requestedIngredient models.Ingredient
 		_ = json.NewDecoder(r.Body).Decode(&requestedIngredient)
 		payload, err := im.controller.CreateIngredient(requestedIngredient, im.repository)
-		if err != nil {
+		if errors.Is(err, controller.ErrInvalidNutrition) || errors.Is(err, controller.ErrInvalidLabels) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 		} else {
 			w.WriteHeader(http.StatusCreated)
@@ -91,3 +95,51 @@ func (im IngredientMiddleware) DeleteIngredient(w http.ResponseWriter, r *http.R
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// UpdateNutrition sets the nutrition facts of an ingredient, e.g. {"amount": 100, "measurement": "g", "nutrients": {...}}
+func (im IngredientMiddleware) UpdateNutrition(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "PUT")
+	userErr := im.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		var nutrition models.NutritionFacts
+		_ = json.NewDecoder(r.Body).Decode(&nutrition)
+		payload, err := im.controller.UpdateNutrition(params["id"], nutrition, im.repository)
+		if errors.Is(err, controller.ErrInvalidNutrition) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// UpdateLabels sets the allergens and diets of an ingredient, e.g. {"allergens": ["dairy"], "diets": ["vegetarian"]}
+func (im IngredientMiddleware) UpdateLabels(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "PUT")
+	userErr := im.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		var labels models.DietaryLabels
+		_ = json.NewDecoder(r.Body).Decode(&labels)
+		payload, err := im.controller.UpdateLabels(params["id"], labels, im.repository)
+		if errors.Is(err, controller.ErrInvalidLabels) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"server/controller"
+	"server/models"
+
+	"github.com/gorilla/mux"
+)
+
+type MealMiddleware struct {
+	auth       AuthMiddleware
+	controller controller.MealControl
+}
+
+func NewMealMiddleware(auth AuthMiddleware, controller controller.MealControl) MealMiddleware {
+	return MealMiddleware{auth, controller}
+}
+
+// GetMealSlots lists the household's meal slots, e.g. ?from=2021.06.01&to=2021.07.01&mealType=dinner
+func (mm MealMiddleware) GetMealSlots(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := mm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		query := r.URL.Query()
+		currentUser, _ := mm.auth.CurrentUser(r)
+		payload, err := mm.controller.GetMealSlots(currentUser.HouseholdId, models.MealSlotQuery{
+			From:     query.Get("from"),
+			To:       query.Get("to"),
+			MealType: query.Get("mealType"),
+		})
+		if errors.Is(err, controller.ErrInvalidFilter) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if errors.Is(err, controller.ErrNoHousehold) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// SaveMealSlot plans a meal, e.g. {"recipes": [{"recipeID": "...", "servings": 6}], "notes": "..."}
+func (mm MealMiddleware) SaveMealSlot(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "PUT")
+	userErr := mm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		var slot models.MealSlot
+		_ = json.NewDecoder(r.Body).Decode(&slot)
+		currentUser, _ := mm.auth.CurrentUser(r)
+		viewer, _ := mm.auth.CurrentViewer(r)
+		payload, err := mm.controller.SaveMealSlot(currentUser.HouseholdId, params["date"], params["mealType"], slot, viewer)
+		if errors.Is(err, controller.ErrInvalidMealSlot) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if errors.Is(err, controller.ErrNoHousehold) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// DeleteMealSlot clears a planned meal
+func (mm MealMiddleware) DeleteMealSlot(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "DELETE")
+	userErr := mm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		currentUser, _ := mm.auth.CurrentUser(r)
+		err := mm.controller.DeleteMealSlot(currentUser.HouseholdId, params["date"], params["mealType"])
+		if errors.Is(err, controller.ErrInvalidMealSlot) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if errors.Is(err, controller.ErrNoHousehold) || errors.Is(err, controller.ErrMealSlotNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// MigrateCalendars copies the household's weekly calendars into meal slots
+func (mm MealMiddleware) MigrateCalendars(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+	userErr := mm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		currentUser, _ := mm.auth.CurrentUser(r)
+		payload, err := mm.controller.MigrateCalendars(currentUser.HouseholdId)
+		if errors.Is(err, controller.ErrNoHousehold) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
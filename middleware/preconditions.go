@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	errMissingIfMatch = errors.New("updates need an If-Match header with the ETag of the version being changed")
+	errInvalidIfMatch = errors.New("the If-Match header has to be the ETag of one version")
+)
+
+// etag is the ETag of a document at a version
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion reads the version an update was made from out of its If-Match header. A wildcard isn't
+// accepted since it would let the update overwrite changes it never saw.
+func ifMatchVersion(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, errMissingIfMatch
+	}
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil || version < 0 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// writePreconditionError answers an update whose If-Match couldn't be used
+func writePreconditionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errMissingIfMatch) {
+		w.WriteHeader(http.StatusPreconditionRequired)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(err.Error())
+}
@@ -2,8 +2,18 @@ package middleware
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"server/db"
+	"server/export"
+	"server/importer"
+	"server/patch"
 	"strconv"
+	"strings"
 
 	"server/controller"
 	"server/models"
@@ -12,12 +22,17 @@ import (
 )
 
 type RecipeMiddleware struct {
-	auth       AuthMiddleware
-	controller controller.RecipeControl
+	auth        AuthMiddleware
+	controller  controller.RecipeControl
+	reviews     controller.ReviewControl
+	collections controller.CollectionControl
 }
 
-func NewRecipeMiddleware(auth AuthMiddleware, controller controller.RecipeController) RecipeMiddleware {
-	return RecipeMiddleware{auth, controller}
+func NewRecipeMiddleware(auth AuthMiddleware,
+	controller controller.RecipeController,
+	reviews controller.ReviewController,
+	collections controller.CollectionController) RecipeMiddleware {
+	return RecipeMiddleware{auth, controller, reviews, collections}
 }
 
 // PostPaginateRecipes controller POST request
@@ -30,8 +45,13 @@ func (rm RecipeMiddleware) PostPaginatedRecipes(w http.ResponseWriter, r *http.R
 	} else {
 		var paginatedRequest models.PaginatedRecipeRequest
 		_ = json.NewDecoder(r.Body).Decode(&paginatedRequest)
+		// an unknown viewer only sees public recipes
+		paginatedRequest.Viewer, _ = rm.auth.CurrentViewer(r)
 		payload, err := rm.controller.PostPaginatedRecipes(paginatedRequest)
-		if err != nil {
+		if errors.Is(err, controller.ErrInvalidFilter) || errors.Is(err, controller.ErrInvalidSort) || errors.Is(err, db.ErrInvalidCursor) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 		} else {
 			w.WriteHeader(http.StatusOK)
@@ -49,17 +69,50 @@ func (rm RecipeMiddleware) GetRecipe(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(userErr.Error())
 	} else {
 		params := mux.Vars(r)
-		payload, err := rm.controller.GetRecipe(params["id"])
+		viewer, _ := rm.auth.CurrentViewer(r)
+		payload, err := rm.controller.GetRecipe(params["id"], viewer)
 		if err != nil {
 			w.WriteHeader(http.StatusNotFound)
 		} else {
+			w.Header().Set("ETag", etag(payload.Version))
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(payload)
 		}
 	}
 }
 
-// GetRandomRecipes gets a random number of recipes
+// ScaleRecipe gets a recipe by ID rescaled to the requested number of servings
+func (rm RecipeMiddleware) ScaleRecipe(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := rm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		servings, convertErr := strconv.Atoi(r.URL.Query().Get("servings"))
+		if convertErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			viewer, _ := rm.auth.CurrentViewer(r)
+			payload, err := rm.controller.ScaleRecipe(params["id"], servings, viewer)
+			if errors.Is(err, controller.ErrCannotScale) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(err.Error())
+			} else if err != nil {
+				w.WriteHeader(http.StatusNotFound)
+			} else {
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(payload)
+			}
+		}
+	}
+}
+
+// GetRandomRecipes gets a random number of recipes. The query can narrow down the recipes with
+// tags, the numeric fields of a RecipeFilter and comma separated ingredients, and exclude a comma
+// separated list of recipe IDs, e.g. ?tags=dinner,quick&maxTotalTime=30&exclude=<id>,<id>.
+// With weightByRating=true better rated recipes come up more often.
 func (rm RecipeMiddleware) GetRandomRecipes(w http.ResponseWriter, r *http.Request) {
 	writeCommonHeaders(w)
 	w.Header().Set("Access-Control-Allow-Methods", "GET")
@@ -69,11 +122,23 @@ func (rm RecipeMiddleware) GetRandomRecipes(w http.ResponseWriter, r *http.Reque
 	} else {
 		params := mux.Vars(r)
 		i, convertErr := strconv.Atoi(params["numberOfRecipes"])
-		if convertErr != nil {
+		filter, filterErr := recipeFilterFromQuery(r.URL.Query())
+		if convertErr != nil || filterErr != nil {
 			w.WriteHeader(http.StatusBadRequest)
 		} else {
-			payload, err := rm.controller.GetRandomRecipes(i)
-			if err != nil {
+			viewer, _ := rm.auth.CurrentViewer(r)
+			payload, err := rm.controller.GetRandomRecipes(models.RandomRecipeRequest{
+				NumberOfRecipes:  i,
+				Tags:             queryList(r.URL.Query(), "tags"),
+				Filter:           filter,
+				ExcludeRecipeIDs: queryList(r.URL.Query(), "exclude"),
+				WeightByRating:   r.URL.Query().Get("weightByRating") == "true",
+				Viewer:           viewer,
+			})
+			if errors.Is(err, controller.ErrInvalidFilter) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(err.Error())
+			} else if err != nil {
 				w.WriteHeader(http.StatusNotFound)
 			} else {
 				w.WriteHeader(http.StatusOK)
@@ -93,6 +158,8 @@ func (rm RecipeMiddleware) CreateRecipe(w http.ResponseWriter, r *http.Request)
 	} else {
 		var recipe models.Recipe
 		_ = json.NewDecoder(r.Body).Decode(&recipe)
+		user, _ := rm.auth.CurrentUser(r)
+		recipe.UserName = user.UserName
 		payload, invalidFields, err := rm.controller.CreateRecipe(recipe)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
@@ -100,18 +167,99 @@ func (rm RecipeMiddleware) CreateRecipe(w http.ResponseWriter, r *http.Request)
 				json.NewEncoder(w).Encode(invalidFields)
 			}
 		} else {
+			w.Header().Set("ETag", etag(payload.Version))
 			w.WriteHeader(http.StatusCreated)
 			json.NewEncoder(w).Encode(payload)
 		}
 	}
 }
 
+// ImportRecipe parses a recipe from JSON-LD or pasted text, returning it as a draft or creating it when asked to save
+func (rm RecipeMiddleware) ImportRecipe(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+	userErr := rm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		var importRequest models.RecipeImportRequest
+		_ = json.NewDecoder(r.Body).Decode(&importRequest)
+		user, _ := rm.auth.CurrentUser(r)
+		payload, invalidFields, err := rm.controller.ImportRecipe(importRequest, user.UserName)
+		if errors.Is(err, importer.ErrUnrecognizedRecipe) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			if len(invalidFields) > 0 {
+				json.NewEncoder(w).Encode(invalidFields)
+			}
+		} else if importRequest.Save {
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(payload)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// ExportRecipe downloads a recipe as Markdown, printable HTML or JSON-LD, picked by ?format= or else the Accept header
+func (rm RecipeMiddleware) ExportRecipe(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := rm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		format, formatErr := exportFormat(r)
+		if formatErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(formatErr.Error())
+		} else {
+			viewer, _ := rm.auth.CurrentViewer(r)
+			document, err := rm.controller.ExportRecipe(params["id"], format, viewer)
+			if err != nil {
+				w.WriteHeader(http.StatusNotFound)
+			} else {
+				writeDocument(w, document, "inline")
+			}
+		}
+	}
+}
+
+// ExportRecipes downloads a zip of all of the user's own recipes in one format
+func (rm RecipeMiddleware) ExportRecipes(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := rm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		format, formatErr := exportFormat(r)
+		if formatErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(formatErr.Error())
+		} else {
+			viewer, _ := rm.auth.CurrentViewer(r)
+			document, err := rm.controller.ExportRecipes(format, viewer)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			} else {
+				writeDocument(w, document, "attachment")
+			}
+		}
+	}
+}
+
 // UpdateRecipe controller PUT request
 func (rm RecipeMiddleware) UpdateRecipe(w http.ResponseWriter, r *http.Request) {
 	writeCommonHeaders(w)
 	params := mux.Vars(r)
 	w.Header().Set("Access-Control-Allow-Methods", "PUT")
-	getPayload, getErr := rm.controller.GetRecipe(params["id"])
+	viewer, _ := rm.auth.CurrentViewer(r)
+	getPayload, getErr := rm.controller.GetRecipe(params["id"], viewer)
 	if getErr != nil {
 		w.WriteHeader(http.StatusNotFound)
 	}
@@ -119,23 +267,253 @@ func (rm RecipeMiddleware) UpdateRecipe(w http.ResponseWriter, r *http.Request)
 	if userErr != nil {
 		json.NewEncoder(w).Encode(userErr.Error())
 	} else {
-		var recipe models.Recipe
-		json.NewDecoder(r.Body).Decode(&recipe)
-		payload, err := rm.controller.UpdateRecipe(params["id"], recipe)
-		if err != nil {
+		version, versionErr := ifMatchVersion(r)
+		if versionErr != nil {
+			writePreconditionError(w, versionErr)
+		} else {
+			var recipe models.Recipe
+			json.NewDecoder(r.Body).Decode(&recipe)
+			recipe.Version = version
+			user, _ := rm.auth.CurrentUser(r)
+			payload, invalidFields, err := rm.controller.UpdateRecipe(params["id"], recipe, user.UserName)
+			if errors.Is(err, db.ErrVersionConflict) {
+				w.Header().Set("ETag", etag(payload.Version))
+				w.WriteHeader(http.StatusPreconditionFailed)
+				json.NewEncoder(w).Encode(payload)
+			} else if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				if len(invalidFields) > 0 {
+					json.NewEncoder(w).Encode(invalidFields)
+				}
+			} else {
+				w.Header().Set("ETag", etag(payload.Version))
+				json.NewEncoder(w).Encode(payload)
+			}
+		}
+	}
+}
+
+// PatchRecipe partially updates a recipe with a JSON Merge Patch (application/merge-patch+json) or a
+// JSON Patch (application/json-patch+json), made from the version in If-Match
+func (rm RecipeMiddleware) PatchRecipe(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	params := mux.Vars(r)
+	w.Header().Set("Access-Control-Allow-Methods", "PATCH")
+	viewer, _ := rm.auth.CurrentViewer(r)
+	getPayload, getErr := rm.controller.GetRecipe(params["id"], viewer)
+	if getErr != nil {
+		w.WriteHeader(http.StatusNotFound)
+	}
+	userErr := rm.auth.AuthenticateSpecificUser(w, r, getPayload.UserName)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		version, versionErr := ifMatchVersion(r)
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		body, readErr := io.ReadAll(r.Body)
+		if versionErr != nil {
+			writePreconditionError(w, versionErr)
+		} else if readErr != nil {
 			w.WriteHeader(http.StatusBadRequest)
 		} else {
+			payload, invalidFields, err := rm.controller.PatchRecipe(params["id"], mediaType, body, version, viewer.UserName)
+			if errors.Is(err, db.ErrVersionConflict) {
+				w.Header().Set("ETag", etag(payload.Version))
+				w.WriteHeader(http.StatusPreconditionFailed)
+				json.NewEncoder(w).Encode(payload)
+			} else if errors.Is(err, patch.ErrUnsupportedType) {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				json.NewEncoder(w).Encode(err.Error())
+			} else if errors.Is(err, patch.ErrTestFailed) {
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(err.Error())
+			} else if errors.Is(err, patch.ErrInvalidPatch) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(err.Error())
+			} else if errors.Is(err, controller.ErrRecipeNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+			} else if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				if len(invalidFields) > 0 {
+					json.NewEncoder(w).Encode(invalidFields)
+				}
+			} else {
+				w.Header().Set("ETag", etag(payload.Version))
+				json.NewEncoder(w).Encode(payload)
+			}
+		}
+	}
+}
+
+// ForkRecipe copies a recipe the user can see into a recipe of their own
+func (rm RecipeMiddleware) ForkRecipe(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+	userErr := rm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		viewer, _ := rm.auth.CurrentViewer(r)
+		payload, err := rm.controller.ForkRecipe(params["id"], viewer)
+		if errors.Is(err, controller.ErrRecipeNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.Header().Set("ETag", etag(payload.Version))
+			w.WriteHeader(http.StatusCreated)
 			json.NewEncoder(w).Encode(payload)
 		}
 	}
 }
 
+// GetForks pages through the copies of a recipe, e.g. ?pageSize=10&cursor=<nextCursor>
+func (rm RecipeMiddleware) GetForks(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := rm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		paginatedRequest, requestErr := paginationFromQuery(r.URL.Query())
+		if requestErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			paginatedRequest.Viewer, _ = rm.auth.CurrentViewer(r)
+			payload, err := rm.controller.GetForks(params["id"], paginatedRequest)
+			if errors.Is(err, controller.ErrRecipeNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+			} else if errors.Is(err, db.ErrInvalidCursor) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(err.Error())
+			} else if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			} else {
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(payload)
+			}
+		}
+	}
+}
+
+// GetNutrition works out a recipe's nutrition from the current ingredient catalog
+func (rm RecipeMiddleware) GetNutrition(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := rm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		viewer, _ := rm.auth.CurrentViewer(r)
+		payload, err := rm.controller.GetNutrition(params["id"], viewer)
+		if errors.Is(err, controller.ErrRecipeNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// GetRevisions lists every saved version of a recipe
+func (rm RecipeMiddleware) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := rm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		viewer, _ := rm.auth.CurrentViewer(r)
+		payload, err := rm.controller.GetRevisions(params["id"], viewer)
+		if errors.Is(err, controller.ErrRecipeNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// DiffRevisions compares two revisions of a recipe, e.g. ?from=1&to=3
+func (rm RecipeMiddleware) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := rm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		from, fromErr := strconv.Atoi(r.URL.Query().Get("from"))
+		to, toErr := strconv.Atoi(r.URL.Query().Get("to"))
+		if fromErr != nil || toErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			viewer, _ := rm.auth.CurrentViewer(r)
+			payload, err := rm.controller.DiffRevisions(params["id"], from, to, viewer)
+			if err != nil {
+				w.WriteHeader(http.StatusNotFound)
+			} else {
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(payload)
+			}
+		}
+	}
+}
+
+// RevertRecipe puts an earlier revision of a recipe back, only the recipe's owner can revert it
+func (rm RecipeMiddleware) RevertRecipe(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	params := mux.Vars(r)
+	w.Header().Set("Access-Control-Allow-Methods", "POST")
+	viewer, _ := rm.auth.CurrentViewer(r)
+	getPayload, getErr := rm.controller.GetRecipe(params["id"], viewer)
+	if getErr != nil {
+		w.WriteHeader(http.StatusNotFound)
+	}
+	userErr := rm.auth.AuthenticateSpecificUser(w, r, getPayload.UserName)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		number, convertErr := strconv.Atoi(params["revision"])
+		version, versionErr := ifMatchVersion(r)
+		if convertErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		} else if versionErr != nil {
+			writePreconditionError(w, versionErr)
+		} else {
+			payload, err := rm.controller.RevertRecipe(params["id"], number, version, viewer.UserName)
+			if errors.Is(err, db.ErrVersionConflict) {
+				w.Header().Set("ETag", etag(payload.Version))
+				w.WriteHeader(http.StatusPreconditionFailed)
+				json.NewEncoder(w).Encode(payload)
+			} else if errors.Is(err, controller.ErrRevisionNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+			} else if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			} else {
+				w.Header().Set("ETag", etag(payload.Version))
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(payload)
+			}
+		}
+	}
+}
+
 // DeleteRecipe controller DELETE request
 func (rm RecipeMiddleware) DeleteRecipe(w http.ResponseWriter, r *http.Request) {
 	writeCommonHeaders(w)
 	params := mux.Vars(r)
 	w.Header().Set("Access-Control-Allow-Methods", "DELETE")
-	getPayload, getErr := rm.controller.GetRecipe(params["id"])
+	viewer, _ := rm.auth.CurrentViewer(r)
+	getPayload, getErr := rm.controller.GetRecipe(params["id"], viewer)
 	if getErr != nil {
 		w.WriteHeader(http.StatusNotFound)
 	}
@@ -146,6 +524,177 @@ func (rm RecipeMiddleware) DeleteRecipe(w http.ResponseWriter, r *http.Request)
 		err := rm.controller.DeleteRecipe(params["id"])
 		if err != nil {
 			w.WriteHeader(http.StatusNotFound)
+		} else {
+			if reviewErr := rm.reviews.DeleteRecipeReviews(params["id"]); reviewErr != nil {
+				fmt.Println("Could not delete recipe reviews")
+				fmt.Println(reviewErr)
+			}
+			if collectionErr := rm.collections.RemoveRecipeFromCollections(params["id"]); collectionErr != nil {
+				fmt.Println("Could not remove the recipe from collections")
+				fmt.Println(collectionErr)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// recipeFilterFromQuery reads the criteria of a RecipeFilter from query parameters of the same name
+func recipeFilterFromQuery(query url.Values) (models.RecipeFilter, error) {
+	filter := models.RecipeFilter{
+		Author:             query.Get("author"),
+		UserName:           query.Get("userName"),
+		IncludeIngredients: queryList(query, "includeIngredients"),
+		ExcludeIngredients: queryList(query, "excludeIngredients"),
+		CreatedAfter:       query.Get("createdAfter"),
+		CreatedBefore:      query.Get("createdBefore"),
+		UpdatedAfter:       query.Get("updatedAfter"),
+		UpdatedBefore:      query.Get("updatedBefore"),
+		ExcludeAllergens:   queryList(query, "excludeAllergens"),
+		Diets:              queryList(query, "diets"),
+	}
+	if respectRestrictions := query.Get("respectRestrictions"); respectRestrictions != "" {
+		converted, err := strconv.ParseBool(respectRestrictions)
+		if err != nil {
+			return models.RecipeFilter{}, err
+		}
+		filter.RespectRestrictions = converted
+	}
+	numbers := map[string]*int{
+		"maxPrepTime": &filter.MaxPrepTime, "maxCookTime": &filter.MaxCookTime, "maxTotalTime": &filter.MaxTotalTime,
+		"minCalories": &filter.MinCalories, "maxCalories": &filter.MaxCalories,
+		"minServings": &filter.MinServings, "maxServings": &filter.MaxServings,
+	}
+	for name, number := range numbers {
+		if value := query.Get(name); value != "" {
+			converted, err := strconv.Atoi(value)
+			if err != nil {
+				return models.RecipeFilter{}, err
+			}
+			*number = converted
+		}
+	}
+	if minRating := query.Get("minRating"); minRating != "" {
+		converted, err := strconv.ParseFloat(minRating, 64)
+		if err != nil {
+			return models.RecipeFilter{}, err
+		}
+		filter.MinRating = converted
+	}
+	return filter, nil
+}
+
+// paginationFromQuery reads the pageSize, pageCount and cursor of a paginated request from query parameters
+func paginationFromQuery(query url.Values) (models.PaginatedRecipeRequest, error) {
+	request := models.PaginatedRecipeRequest{Cursor: query.Get("cursor")}
+	if pageSize := query.Get("pageSize"); pageSize != "" {
+		converted, err := strconv.ParseInt(pageSize, 10, 64)
+		if err != nil {
+			return models.PaginatedRecipeRequest{}, err
+		}
+		request.PageSize = converted
+	}
+	if pageCount := query.Get("pageCount"); pageCount != "" {
+		converted, err := strconv.Atoi(pageCount)
+		if err != nil {
+			return models.PaginatedRecipeRequest{}, err
+		}
+		request.PageCount = converted
+	}
+	return request, nil
+}
+
+// queryList reads a query parameter that is either repeated or a comma separated list
+func queryList(query url.Values, name string) []string {
+	var values []string
+	for _, value := range query[name] {
+		for _, item := range strings.Split(value, ",") {
+			if trimmed := strings.TrimSpace(item); trimmed != "" {
+				values = append(values, trimmed)
+			}
+		}
+	}
+	return values
+}
+
+// exportFormat is the format asked for in the query, or else the one the Accept header prefers
+func exportFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return export.ParseFormat(format)
+	}
+	return export.Negotiate(r.Header.Get("Accept")), nil
+}
+
+func writeDocument(w http.ResponseWriter, document export.Document, disposition string) {
+	w.Header().Set("Content-Type", document.ContentType)
+	w.Header().Set("Content-Disposition", disposition+`; filename="`+document.FileName+`"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(document.Body)
+}
+
+// GetReviews lists the reviews of a recipe
+func (rm RecipeMiddleware) GetReviews(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := rm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		viewer, _ := rm.auth.CurrentViewer(r)
+		payload, err := rm.reviews.GetReviews(params["id"], viewer)
+		if errors.Is(err, controller.ErrRecipeNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// SaveReview rates and reviews a recipe as the user, editing their review if they already have one
+func (rm RecipeMiddleware) SaveReview(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "PUT")
+	userErr := rm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		var review models.Review
+		_ = json.NewDecoder(r.Body).Decode(&review)
+		viewer, _ := rm.auth.CurrentViewer(r)
+		payload, err := rm.reviews.SaveReview(params["id"], review, viewer)
+		if errors.Is(err, controller.ErrInvalidRating) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if errors.Is(err, controller.ErrRecipeNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// DeleteReview removes the user's review of a recipe
+func (rm RecipeMiddleware) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "DELETE")
+	userErr := rm.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		params := mux.Vars(r)
+		viewer, _ := rm.auth.CurrentViewer(r)
+		err := rm.reviews.DeleteReview(params["id"], viewer)
+		if errors.Is(err, controller.ErrRecipeNotFound) || errors.Is(err, controller.ErrReviewNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
 		} else {
 			w.WriteHeader(http.StatusNoContent)
 		}
@@ -19,16 +19,17 @@ func (sm ServerMiddleware) HealthCheck(w http.ResponseWriter, r *http.Request) {
 }
 
 func writeCommonHeaders(w http.ResponseWriter) {
-	acceptedHeaders := "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token"
+	acceptedHeaders := "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token, If-Match"
 	w.Header().Set("Context-Type", "application/x-www-form-urlencoded")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Headers", acceptedHeaders)
+	w.Header().Set("Access-Control-Expose-Headers", "ETag")
 }
 
 //Options eats options requests
 func Options(w http.ResponseWriter, r *http.Request) {
 	writeCommonHeaders(w)
-	w.Header().Set("Access-Control-Allow-Methods", "GET, DELETE, PUT, POST")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, DELETE, PUT, PATCH, POST")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode("")
 }
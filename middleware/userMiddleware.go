@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"server/db"
@@ -113,6 +114,49 @@ func (um UserMiddleware) GenerateUserToken(w http.ResponseWriter, r *http.Reques
 	}
 }
 
+// GetRestrictions gets the dietary restrictions of the user making the request
+func (um UserMiddleware) GetRestrictions(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "GET")
+	userErr := um.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		user, _ := um.auth.CurrentUser(r)
+		payload, err := um.Controller.GetRestrictions(user.UserName, um.repository)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
+// UpdateRestrictions replaces the dietary restrictions of the user making the request,
+// e.g. {"allergens": ["nuts"], "diets": ["vegetarian"]}
+func (um UserMiddleware) UpdateRestrictions(w http.ResponseWriter, r *http.Request) {
+	writeCommonHeaders(w)
+	w.Header().Set("Access-Control-Allow-Methods", "PUT")
+	userErr := um.auth.AuthenticateUser(w, r, false)
+	if userErr != nil {
+		json.NewEncoder(w).Encode(userErr.Error())
+	} else {
+		user, _ := um.auth.CurrentUser(r)
+		var restrictions models.DietaryRestrictions
+		_ = json.NewDecoder(r.Body).Decode(&restrictions)
+		payload, err := um.Controller.UpdateRestrictions(user.UserName, restrictions, um.repository)
+		if errors.Is(err, controller.ErrInvalidLabels) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+		} else if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(payload)
+		}
+	}
+}
+
 func (um UserMiddleware) EmailUser(w http.ResponseWriter, r *http.Request) {
 	bearerToken := strings.ReplaceAll(r.Header.Get("Authorization"), "Bearer ", "")
 	writeCommonHeaders(w)
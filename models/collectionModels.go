@@ -0,0 +1,15 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Collection is a named list of recipes a user has saved, like "Weeknight" or "Holiday". Shared
+// collections can be seen and added to by the rest of the owner's household.
+type Collection struct {
+	CollectionID    primitive.ObjectID   `json:"_id,omitempty" bson:"_id,omitempty"`
+	Name            string               `json:"name,omitempty"`
+	UserName        string               `json:"userName,omitempty"`
+	Shared          bool                 `json:"shared"`
+	RecipeIDs       []primitive.ObjectID `json:"recipeIDs"`
+	CreatedDate     string               `json:"createdDate,omitempty"`
+	LastUpdatedDate string               `json:"lastUpdatedDate,omitempty"`
+}
@@ -0,0 +1,49 @@
+package models
+
+// The allergens ingredients are labeled with
+const (
+	AllergenGluten    = "gluten"
+	AllergenDairy     = "dairy"
+	AllergenEggs      = "eggs"
+	AllergenNuts      = "nuts"
+	AllergenPeanuts   = "peanuts"
+	AllergenSoy       = "soy"
+	AllergenFish      = "fish"
+	AllergenShellfish = "shellfish"
+	AllergenSesame    = "sesame"
+)
+
+// The diets ingredients are labeled with. Every vegan ingredient is also vegetarian, and every
+// vegetarian one is also pescatarian.
+const (
+	DietVegan       = "vegan"
+	DietVegetarian  = "vegetarian"
+	DietPescatarian = "pescatarian"
+)
+
+var Allergens = []string{AllergenGluten, AllergenDairy, AllergenEggs, AllergenNuts, AllergenPeanuts,
+	AllergenSoy, AllergenFish, AllergenShellfish, AllergenSesame}
+
+var Diets = []string{DietVegan, DietVegetarian, DietPescatarian}
+
+// DietaryLabels are the allergens a catalog ingredient contains and the diets it fits. An ingredient
+// without labels is unknown, which is not the same as one labeled with no allergens.
+type DietaryLabels struct {
+	Allergens []string `json:"allergens"`
+	Diets     []string `json:"diets"`
+}
+
+// RecipeLabels are worked out from the labels of the recipe's ingredients when it is saved. Allergens
+// are only those of the labeled ingredients, and a recipe only fits a diet when every ingredient is
+// labeled with it, so a recipe with UnlabeledIngredients fits no diets.
+type RecipeLabels struct {
+	Allergens            []string `json:"allergens"`
+	Diets                []string `json:"diets"`
+	UnlabeledIngredients []string `json:"unlabeledIngredients,omitempty"`
+}
+
+// DietaryRestrictions are the allergens a user can't eat and the diets they keep
+type DietaryRestrictions struct {
+	Allergens []string `json:"allergens"`
+	Diets     []string `json:"diets"`
+}
@@ -12,15 +12,100 @@ type RequestedHouseholdUpdate struct {
 	UserIdToAdd string `json:"userIdToAdd,omitempty"`
 }
 
+// Calendar plans a recipe for each day of the week starting on StartDate. Every change to a calendar is copied
+// onto the dinner slots of its week, but changes made to those slots aren't copied back.
 type Calendar struct {
 	CalendarID  primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
 	HouseholdID primitive.ObjectID `json:"householdID,omitempty" bson:"householdID,omitempty"`
 	StartDate   string             `json:"startDate,omitempty"`
-	Monday      Recipe             `json:"monday,omitempty" bson:"monday,omitempty"`
-	Tuesday     Recipe             `json:"tuesday,omitempty" bson:"tuesday,omitempty"`
-	Wednesday   Recipe             `json:"wednesday,omitempty" bson:"wednesday,omitempty"`
-	Thursday    Recipe             `json:"thursday,omitempty" bson:"thursday,omitempty"`
-	Friday      Recipe             `json:"friday,omitempty" bson:"friday,omitempty"`
-	Saturday    Recipe             `json:"saturday,omitempty" bson:"saturday,omitempty"`
-	Sunday      Recipe             `json:"sunday,omitempty" bson:"sunday,omitempty"`
+	Monday      PlannedRecipe      `json:"monday,omitempty" bson:"monday,omitempty"`
+	Tuesday     PlannedRecipe      `json:"tuesday,omitempty" bson:"tuesday,omitempty"`
+	Wednesday   PlannedRecipe      `json:"wednesday,omitempty" bson:"wednesday,omitempty"`
+	Thursday    PlannedRecipe      `json:"thursday,omitempty" bson:"thursday,omitempty"`
+	Friday      PlannedRecipe      `json:"friday,omitempty" bson:"friday,omitempty"`
+	Saturday    PlannedRecipe      `json:"saturday,omitempty" bson:"saturday,omitempty"`
+	Sunday      PlannedRecipe      `json:"sunday,omitempty" bson:"sunday,omitempty"`
+	// Version works the same as a recipe's, it is sent back in If-Match to update the calendar
+	Version int `json:"version"`
+	// Unsatisfied lists the constraints the planner couldn't meet when it generated the calendar, it isn't saved
+	Unsatisfied []UnsatisfiedConstraint `json:"unsatisfiedConstraints,omitempty" bson:"-"`
+}
+
+// CalendarQuery narrows down a household's calendars to those starting From up to but not including To, both days
+// like "2021.06.06". PageCount counts pages from 0 like a PaginatedRecipeRequest and a PageSize of 0 gets every one.
+type CalendarQuery struct {
+	From      string
+	To        string
+	PageSize  int64
+	PageCount int
+}
+
+// CalendarPage is a page of a household's calendars
+type CalendarPage struct {
+	Calendars         []Calendar `json:"calendars"`
+	NumberOfCalendars int64      `json:"numberOfCalendars"`
+	PageCount         int        `json:"pageCount,omitempty"`
+	PageSize          int64      `json:"pageSize,omitempty"`
+}
+
+// CalendarDayRequest changes a day of a calendar, planning the recipe with RecipeID on it or swapping it with OtherDay
+type CalendarDayRequest struct {
+	RecipeID string `json:"recipeID,omitempty"`
+	OtherDay string `json:"otherDay,omitempty"`
+}
+
+// PlannedRecipe refers to the recipe planned on a day of a calendar, only the reference is saved so the calendar
+// follows changes to the recipe. Reading a calendar with its recipes expanded fills in Recipe, or Unavailable when
+// the recipe has been deleted or the viewer can't see it. Older calendars embedded the whole recipe, which reads
+// back as its reference.
+type PlannedRecipe struct {
+	RecipeID    primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	RecipeName  string             `json:"recipeName,omitempty"`
+	Recipe      *Recipe            `json:"recipe,omitempty" bson:"-"`
+	Unavailable bool               `json:"unavailable,omitempty" bson:"-"`
+}
+
+// ShoppingList is every ingredient needed for a calendar's recipes, merged and grouped by category
+type ShoppingList struct {
+	CalendarID primitive.ObjectID     `json:"calendarID,omitempty"`
+	StartDate  string                 `json:"startDate,omitempty"`
+	Categories []ShoppingListCategory `json:"categories"`
+}
+
+type ShoppingListCategory struct {
+	Category string             `json:"category"`
+	Items    []ShoppingListItem `json:"items"`
+}
+
+// ShoppingListItem is the total amount of one ingredient along with the recipes that need it
+type ShoppingListItem struct {
+	Name        string   `json:"name"`
+	Amount      float32  `json:"amount,omitempty"`
+	Measurement string   `json:"measurement,omitempty"`
+	Recipes     []string `json:"recipes,omitempty"`
+}
+
+// CookedEntry records a household actually cooking a recipe, either from a day of one of its calendars
+// or logged on its own. Rating is 0 when the cook didn't rate it, otherwise from 1 to 5.
+type CookedEntry struct {
+	EntryID     primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	HouseholdID primitive.ObjectID `json:"householdID,omitempty"`
+	CalendarID  primitive.ObjectID `json:"calendarID,omitempty" bson:"calendarid,omitempty"`
+	Day         string             `json:"day,omitempty" bson:"day,omitempty"`
+	RecipeID    primitive.ObjectID `json:"recipeID,omitempty"`
+	RecipeName  string             `json:"recipeName,omitempty"`
+	CookedDate  string             `json:"cookedDate,omitempty"`
+	UserName    string             `json:"userName,omitempty"`
+	Notes       string             `json:"notes,omitempty"`
+	Rating      int                `json:"rating,omitempty"`
+	CreatedDate string             `json:"createdDate,omitempty"`
+}
+
+// CookedHistoryQuery narrows down a household's cooking history. From is inclusive and To is exclusive,
+// both are days like "2021.06.01" or "2021-06-01". Limit 0 returns every match.
+type CookedHistoryQuery struct {
+	From     string
+	To       string
+	RecipeID string
+	Limit    int64
 }
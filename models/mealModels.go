@@ -0,0 +1,53 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// The meals of a day recipes can be planned for
+const (
+	MealBreakfast = "breakfast"
+	MealLunch     = "lunch"
+	MealDinner    = "dinner"
+	MealSnack     = "snack"
+)
+
+var MealTypes = []string{MealBreakfast, MealLunch, MealDinner, MealSnack}
+
+// MealSlot is what a household plans to eat for one meal on one date, a household has at most one slot per
+// date and meal type. Unlike a Calendar it isn't tied to a week, so any range of dates can be planned.
+type MealSlot struct {
+	SlotID      primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	HouseholdID primitive.ObjectID `json:"householdID,omitempty"`
+	// Date is a day like "2021.06.01"
+	Date     string       `json:"date"`
+	MealType string       `json:"mealType"`
+	Recipes  []SlotRecipe `json:"recipes"`
+	Notes    string       `json:"notes,omitempty"`
+	// CalendarID is the weekly calendar the slot was copied from, if it was. The slot follows changes to that
+	// calendar until it is saved as a meal slot itself.
+	CalendarID      primitive.ObjectID `json:"calendarID,omitempty" bson:"calendarid,omitempty"`
+	LastUpdatedDate string             `json:"lastUpdatedDate,omitempty"`
+}
+
+// SlotRecipe is a recipe planned for a meal. Servings overrides the recipe's own servings when it isn't 0.
+type SlotRecipe struct {
+	RecipeID   primitive.ObjectID `json:"recipeID"`
+	RecipeName string             `json:"recipeName,omitempty"`
+	Servings   int                `json:"servings,omitempty"`
+	Notes      string             `json:"notes,omitempty"`
+}
+
+// MealSlotQuery narrows down a household's meal slots. From is inclusive and To is exclusive like a
+// CookedHistoryQuery, and MealType picks out one meal of the day.
+type MealSlotQuery struct {
+	From     string
+	To       string
+	MealType string
+}
+
+// CalendarMigration reports how a household's weekly calendars were moved into meal slots. Calendars
+// whose StartDate isn't a date can't be placed and are listed in Skipped.
+type CalendarMigration struct {
+	Calendars int      `json:"calendars"`
+	Slots     int64    `json:"slots"`
+	Skipped   []string `json:"skipped,omitempty"`
+}
@@ -12,39 +12,102 @@ type Recipe struct {
 	Author          string             `json:"author,omitempty"`
 	PrepTime        int                `json:"prepTime"`
 	CookTime        int                `json:"cookTime"`
-	Steps           []step             `json:"steps,omitempty"`
+	Steps           []Step             `json:"steps,omitempty"`
 	Tags            []string           `json:"tags,omitempty"`
 	Servings        int                `json:"servings"`
 	Calories        int                `json:"calories"`
 	UserName        string             `json:"userName,omitempty"`
 	Private         bool               `json:"private,omitempty"`
+	Visibility      string             `json:"visibility,omitempty"`
+	// Popularity counts how many times the recipe has been planned on a calendar
+	Popularity int `json:"popularity"`
+	// Version goes up by one on every update, updates have to be made from the current version
+	Version int `json:"version"`
+	// ForkedFrom is the recipe this one was copied from, if it is a copy
+	ForkedFrom *RecipeReference `json:"forkedFrom,omitempty" bson:"forkedfrom,omitempty"`
+	// AverageRating and RatingCount summarize the recipe's reviews, they are kept up to date by the server
+	AverageRating float64 `json:"averageRating"`
+	RatingCount   int     `json:"ratingCount"`
+	// Nutrition is worked out from the ingredient catalog, Calories is still the figure entered with the recipe
+	Nutrition *RecipeNutrition `json:"nutrition,omitempty" bson:"nutrition,omitempty"`
+	Labels    *RecipeLabels    `json:"labels,omitempty" bson:"labels,omitempty"`
+}
+
+// Review is one user's rating of a recipe from 1 to 5 with an optional written review. Users have at most
+// one review per recipe, reviewing again edits it.
+type Review struct {
+	ReviewID        primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	RecipeID        primitive.ObjectID `json:"recipeID,omitempty"`
+	UserName        string             `json:"userName,omitempty"`
+	Rating          int                `json:"rating"`
+	Text            string             `json:"text,omitempty"`
+	CreatedDate     string             `json:"createdDate,omitempty"`
+	LastUpdatedDate string             `json:"lastUpdatedDate,omitempty"`
+}
+
+// The range of a review's rating
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// RecipeReference points at another recipe as it was when it was referenced
+type RecipeReference struct {
+	RecipeID   primitive.ObjectID `json:"recipeID,omitempty"`
+	RecipeName string             `json:"recipeName,omitempty"`
+	UserName   string             `json:"userName,omitempty"`
+	Author     string             `json:"author,omitempty"`
+}
+
+// Who can see a recipe besides its owner. Recipes saved before visibilities existed are private
+// when Private is set and public otherwise. Updates without a visibility keep the recipe's unless they set Private.
+const (
+	VisibilityPrivate   = "private"
+	VisibilityHousehold = "household"
+	VisibilityPublic    = "public"
+)
+
+// Viewer is the user reading recipes, along with the user names of everyone in their household.
+// HouseholdRestrictions combine the dietary restrictions of the whole household, the viewer's included.
+type Viewer struct {
+	UserName              string
+	HouseholdMembers      []string
+	Restrictions          DietaryRestrictions
+	HouseholdRestrictions DietaryRestrictions
 }
 
 // Ingredient is a component of a recipe consisting of the name, amount, and the measurement for that amount (cups, tbsp, lbs, etc)
+// Measurement is normalized to a canonical unit name when a recipe is saved. Quantity accepts a written amount
+// like "1 1/2" in place of Amount and is parsed into Amount on save.
 type Ingredient struct {
 	IngredientID primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
 	Name         string             `json:"name,omitempty"`
 	Amount       float32            `json:"amount,omitempty"`
+	Quantity     string             `json:"quantity,omitempty" bson:"quantity,omitempty"`
 	Measurement  string             `json:"measurement,omitempty"`
 	Category     string             `json:"category,omitempty"`
+	// Nutrition and Labels are only set on ingredients in the catalog
+	Nutrition *NutritionFacts `json:"nutrition,omitempty" bson:"nutrition,omitempty"`
+	Labels    *DietaryLabels  `json:"labels,omitempty" bson:"labels,omitempty"`
 }
 
 // Step is what to do in order for a recipe
-type step struct {
+type Step struct {
 	Number int    `json:"number,omitempty"`
 	Text   string `json:"text,omitempty"`
 }
 
 // User is the data representation of a user
 type User struct {
-	UserID       primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
-	UserName     string             `json:"userName,omitempty"`
-	PasswordHash string             `json:"passwordHash,omitempty"`
-	AccessToken  string             `json:"accessToken,omitempty"`
-	ExpiryDate   string             `json:"expiryDate,omitempty"`
-	UserType     string             `json:"userType,omitempty"`
-	Email        string             `json:"email,omitempty"`
-	HouseholdId  string             `json:"householdId,omitempty"`
+	UserID       primitive.ObjectID  `json:"_id,omitempty" bson:"_id,omitempty"`
+	UserName     string              `json:"userName,omitempty"`
+	PasswordHash string              `json:"passwordHash,omitempty"`
+	AccessToken  string              `json:"accessToken,omitempty"`
+	ExpiryDate   string              `json:"expiryDate,omitempty"`
+	UserType     string              `json:"userType,omitempty"`
+	Email        string              `json:"email,omitempty"`
+	HouseholdId  string              `json:"householdId,omitempty"`
+	Restrictions DietaryRestrictions `json:"restrictions"`
 }
 
 // RequestedUser is what is needed to create a user
@@ -75,18 +138,138 @@ type UpdatedPassword struct {
 }
 
 // PaginatedRequest
+// Search is a full text search over recipe names, ingredients, steps and tags supporting "quoted phrases"
+// and -negated terms. A QueryRecipe.RecipeName is searched the same way when there is no Search.
+// Cursor is a nextCursor or prevCursor from a previous response and takes the place of PageCount,
+// it has to be used with the same sort and search it came from.
 type PaginatedRecipeRequest struct {
-	PageSize    int64  `json:"pageSize,omitempty"`
-	PageCount   int    `json:"pageCount,omitempty"`
-	QueryRecipe Recipe `json:"queryRecipe,omitempty"`
+	PageSize    int64        `json:"pageSize,omitempty"`
+	PageCount   int          `json:"pageCount,omitempty"`
+	Cursor      string       `json:"cursor,omitempty"`
+	Search      string       `json:"search,omitempty"`
+	QueryRecipe Recipe       `json:"queryRecipe,omitempty"`
+	Filter      RecipeFilter `json:"filter,omitempty"`
+	Sort        []RecipeSort `json:"sort,omitempty"`
+	// Viewer is set by the server to the user making the request, only recipes they can see are returned
+	Viewer Viewer `json:"-"`
+}
+
+// RandomRecipeRequest picks random recipes matching the tags and filter, ExcludeRecipeIDs are never
+// picked so recipes that are already planned can be left out
+type RandomRecipeRequest struct {
+	NumberOfRecipes  int          `json:"numberOfRecipes,omitempty"`
+	Tags             []string     `json:"tags,omitempty"`
+	Filter           RecipeFilter `json:"filter,omitempty"`
+	ExcludeRecipeIDs []string     `json:"excludeRecipeIDs,omitempty"`
+	// WeightByRating picks better rated recipes more often, unrated recipes count as a 3
+	WeightByRating bool   `json:"weightByRating,omitempty"`
+	Viewer         Viewer `json:"-"`
+}
+
+// RecipeRevision is a snapshot of a recipe saved every time it is created or updated. Numbers count up from 1.
+type RecipeRevision struct {
+	RevisionID  primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
+	RecipeID    primitive.ObjectID `json:"recipeID,omitempty"`
+	Number      int                `json:"number"`
+	UserName    string             `json:"userName,omitempty"`
+	CreatedDate string             `json:"createdDate,omitempty"`
+	Recipe      Recipe             `json:"recipe"`
+}
+
+// RecipeDiff lists what changed between two revisions of a recipe
+type RecipeDiff struct {
+	RecipeID primitive.ObjectID `json:"recipeID,omitempty"`
+	From     int                `json:"from"`
+	To       int                `json:"to"`
+	Changes  []FieldChange      `json:"changes"`
+}
+
+// FieldChange is one field that was "added", "removed" or "changed". Ingredients are matched by name,
+// like "ingredients[flour].amount", and steps by their position, like "steps[2]".
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Change string      `json:"change"`
+	From   interface{} `json:"from,omitempty"`
+	To     interface{} `json:"to,omitempty"`
+}
+
+// RecipeImportRequest is a recipe copied from somewhere else, either a schema.org Recipe in JSON-LD ("jsonld")
+// or pasted text ("text"). The format is guessed when it is left out. Imports are returned as a draft to
+// review unless Save is set.
+type RecipeImportRequest struct {
+	Format     string `json:"format,omitempty"`
+	Content    string `json:"content,omitempty"`
+	Save       bool   `json:"save,omitempty"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+// RecipeSort orders recipes by one of name, createdDate, lastUpdatedDate, totalTime, calories, popularity,
+// rating, ratingCount or relevance (only when searching). Direction is "asc" or "desc", when it is left out
+// relevance, popularity and ratings sort with the highest first and everything else sorts ascending. Later
+// sorts break ties in earlier ones.
+type RecipeSort struct {
+	Field     string `json:"field"`
+	Direction string `json:"direction,omitempty"`
+}
+
+// RecipeFilter narrows down which recipes are returned, every criteria that is set has to match.
+// Times are in minutes. Dates use the same format as a recipe's dates, "2006.01.02 15:04:05",
+// or just "2006.01.02" for a whole day. After is inclusive and Before is exclusive.
+// Ingredients match by whole word regardless of case, so "chicken" matches "Chicken Thighs".
+type RecipeFilter struct {
+	MaxPrepTime        int      `json:"maxPrepTime,omitempty"`
+	MaxCookTime        int      `json:"maxCookTime,omitempty"`
+	MaxTotalTime       int      `json:"maxTotalTime,omitempty"`
+	MinCalories        int      `json:"minCalories,omitempty"`
+	MaxCalories        int      `json:"maxCalories,omitempty"`
+	MinServings        int      `json:"minServings,omitempty"`
+	MaxServings        int      `json:"maxServings,omitempty"`
+	Author             string   `json:"author,omitempty"`
+	UserName           string   `json:"userName,omitempty"`
+	IncludeIngredients []string `json:"includeIngredients,omitempty"`
+	ExcludeIngredients []string `json:"excludeIngredients,omitempty"`
+	CreatedAfter       string   `json:"createdAfter,omitempty"`
+	CreatedBefore      string   `json:"createdBefore,omitempty"`
+	UpdatedAfter       string   `json:"updatedAfter,omitempty"`
+	UpdatedBefore      string   `json:"updatedBefore,omitempty"`
+	// ForkedFrom is the ID of a recipe, only copies of it match
+	ForkedFrom string `json:"forkedFrom,omitempty"`
+	// MinRating is the lowest average rating to match, recipes nobody has rated yet don't match
+	MinRating float64 `json:"minRating,omitempty"`
+	// RecipeIDs limits the recipes to a list the server already has, like a collection's. A list
+	// that is empty rather than nil matches nothing.
+	RecipeIDs []primitive.ObjectID `json:"-"`
+	// ExcludeAllergens leaves out recipes with any of the allergens and Diets keeps only recipes that fit
+	// all of the diets. RespectRestrictions adds the viewer's own dietary restrictions to both.
+	ExcludeAllergens    []string `json:"excludeAllergens,omitempty"`
+	Diets               []string `json:"diets,omitempty"`
+	RespectRestrictions bool     `json:"respectRestrictions,omitempty"`
 }
 
 // PaginatedResponse
 type PaginatedRecipeResponse struct {
-	PageSize        int64    `json:"pageSize,omitempty"`
-	PageCount       int      `json:"pageCount,omitempty"`
-	NumberOfRecipes int64    `json:"numberOfRecipes,omitempty"`
-	Recipes         []Recipe `json:"recipes,omitempty"`
+	PageSize        int64             `json:"pageSize,omitempty"`
+	PageCount       int               `json:"pageCount,omitempty"`
+	NumberOfRecipes int64             `json:"numberOfRecipes,omitempty"`
+	Recipes         []Recipe          `json:"recipes,omitempty"`
+	Highlights      []RecipeHighlight `json:"highlights,omitempty"`
+	NextCursor      string            `json:"nextCursor,omitempty"`
+	PrevCursor      string            `json:"prevCursor,omitempty"`
+}
+
+// RecipePage is one page of recipes along with the cursors to the pages either side of it,
+// a cursor is empty when there is no page in that direction
+type RecipePage struct {
+	Recipes    []Recipe
+	NextCursor string
+	PrevCursor string
+}
+
+// RecipeHighlight is a snippet of a recipe field matching a search, with the matches wrapped in <mark> tags
+type RecipeHighlight struct {
+	RecipeID primitive.ObjectID `json:"recipeID"`
+	Field    string             `json:"field"`
+	Snippet  string             `json:"snippet"`
 }
 
 type Basket struct {
@@ -0,0 +1,33 @@
+package models
+
+// Nutrients are the amounts of each nutrient in some food. Calories are in kcal, sodium in milligrams
+// and everything else in grams.
+type Nutrients struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Fat      float64 `json:"fat"`
+	Carbs    float64 `json:"carbs"`
+	Fiber    float64 `json:"fiber"`
+	Sodium   float64 `json:"sodium"`
+	Sugar    float64 `json:"sugar"`
+}
+
+// NutritionFacts are the nutrients in an amount of a catalog ingredient, like 100 g or 1 cup. Density is
+// in grams per milliliter and lets recipes measure by volume an ingredient whose facts are by weight, or
+// the other way around.
+type NutritionFacts struct {
+	Amount      float64   `json:"amount"`
+	Measurement string    `json:"measurement,omitempty"`
+	Density     float64   `json:"density,omitempty"`
+	Nutrients   Nutrients `json:"nutrients"`
+}
+
+// RecipeNutrition is worked out from the recipe's ingredients whenever it is saved. It is incomplete when
+// some ingredients aren't in the catalog, have no nutrition facts, or are measured in a unit their facts
+// can't be converted from, those are listed in MissingIngredients and left out of the totals.
+type RecipeNutrition struct {
+	Total              Nutrients `json:"total"`
+	PerServing         Nutrients `json:"perServing"`
+	Complete           bool      `json:"complete"`
+	MissingIngredients []string  `json:"missingIngredients,omitempty"`
+}
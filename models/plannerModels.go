@@ -0,0 +1,48 @@
+package models
+
+// The constraints a generated calendar can be asked to meet, named after their PlanConstraints fields
+const (
+	ConstraintWeeknightMaxTotalTime = "weeknightMaxTotalTime"
+	ConstraintMaxDailyCalories      = "maxDailyCalories"
+	ConstraintNoRepeatWeeks         = "noRepeatWeeks"
+	ConstraintRequiredTags          = "requiredTags"
+	ConstraintMaxDaysPerTag         = "maxDaysPerTag"
+	ConstraintReuseIngredients      = "reuseIngredients"
+)
+
+// CalendarRequest asks for a week to be planned starting on StartDate, a Sunday
+type CalendarRequest struct {
+	StartDate   string          `json:"startDate,omitempty"`
+	Constraints PlanConstraints `json:"constraints,omitempty"`
+}
+
+// PlanConstraints are what a household wants from a generated week, all of them are optional. The planner
+// tries to meet every one and reports those it couldn't rather than failing.
+type PlanConstraints struct {
+	// WeeknightMaxTotalTime limits the prep and cook time of the recipes planned Monday to Friday, in minutes
+	WeeknightMaxTotalTime int `json:"weeknightMaxTotalTime,omitempty"`
+	// MaxDailyCalories limits the calories per serving of each day's recipe
+	MaxDailyCalories int `json:"maxDailyCalories,omitempty"`
+	// NoRepeatWeeks keeps out recipes planned on the household's calendars in that many weeks before this one
+	NoRepeatWeeks int              `json:"noRepeatWeeks,omitempty"`
+	RequiredTags  []TagRequirement `json:"requiredTags,omitempty"`
+	// MaxDaysPerTag limits how many days can have recipes with the same tag
+	MaxDaysPerTag int `json:"maxDaysPerTag,omitempty"`
+	// ReuseIngredients prefers recipes that use ingredients left over from the day before
+	ReuseIngredients bool `json:"reuseIngredients,omitempty"`
+	// PinnedDays are recipe IDs by the name of the day they have to be planned on, e.g. {"friday": "..."}
+	PinnedDays map[string]string `json:"pinnedDays,omitempty"`
+}
+
+// TagRequirement asks for recipes with the tag on at least MinDays days of the week
+type TagRequirement struct {
+	Tag     string `json:"tag"`
+	MinDays int    `json:"minDays"`
+}
+
+// UnsatisfiedConstraint is a constraint a generated calendar doesn't meet, on one Day or the whole week
+type UnsatisfiedConstraint struct {
+	Constraint string `json:"constraint"`
+	Day        string `json:"day,omitempty"`
+	Detail     string `json:"detail"`
+}
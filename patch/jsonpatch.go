@@ -0,0 +1,254 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Operation is one step of an RFC 6902 JSON Patch
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatch applies an RFC 6902 JSON Patch. Operations are applied in order and the patch stops at the
+// first one that fails, leaving the document unchanged.
+func JSONPatch(document []byte, patch []byte) ([]byte, error) {
+	var operations []Operation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	var target interface{}
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, err
+	}
+	for i, operation := range operations {
+		var err error
+		if target, err = applyOperation(target, operation); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func applyOperation(target interface{}, operation Operation) (interface{}, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch operation.Op {
+	case "add", "replace", "test":
+		var value interface{}
+		if len(operation.Value) == 0 {
+			return nil, fmt.Errorf("%w: %s needs a value", ErrInvalidPatch, operation.Op)
+		}
+		if err := json.Unmarshal(operation.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		if operation.Op == "add" {
+			return add(target, path, value)
+		}
+		if operation.Op == "replace" {
+			return replace(target, path, value)
+		}
+		current, err := get(target, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("%w at %q", ErrTestFailed, operation.Path)
+		}
+		return target, nil
+	case "remove":
+		return remove(target, path)
+	case "move", "copy":
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(target, from)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == "copy" {
+			return add(target, path, deepCopy(value))
+		}
+		if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+			return nil, fmt.Errorf("%w: cannot move %q into itself", ErrInvalidPatch, operation.From)
+		}
+		if target, err = remove(target, from); err != nil {
+			return nil, err
+		}
+		return add(target, path, value)
+	}
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, operation.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer like "/steps/0/text" into its unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q has to start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func get(target interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch typed := target.(type) {
+		case map[string]interface{}:
+			value, ok := typed[token]
+			if !ok {
+				return nil, pathNotFound(token)
+			}
+			target = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(typed)-1)
+			if err != nil {
+				return nil, err
+			}
+			target = typed[index]
+		default:
+			return nil, pathNotFound(token)
+		}
+	}
+	return target, nil
+}
+
+func add(target interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(target, path, func(container interface{}, token string) (interface{}, error) {
+		switch typed := container.(type) {
+		case map[string]interface{}:
+			typed[token] = value
+			return typed, nil
+		case []interface{}:
+			index := len(typed)
+			if token != "-" {
+				var err error
+				if index, err = arrayIndex(token, len(typed)); err != nil {
+					return nil, err
+				}
+			}
+			typed = append(typed, nil)
+			copy(typed[index+1:], typed[index:])
+			typed[index] = value
+			return typed, nil
+		}
+		return nil, pathNotFound(token)
+	})
+}
+
+func remove(target interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	return update(target, path, func(container interface{}, token string) (interface{}, error) {
+		switch typed := container.(type) {
+		case map[string]interface{}:
+			if _, ok := typed[token]; !ok {
+				return nil, pathNotFound(token)
+			}
+			delete(typed, token)
+			return typed, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(typed)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(typed[:index], typed[index+1:]...), nil
+		}
+		return nil, pathNotFound(token)
+	})
+}
+
+func replace(target interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(target, path, func(container interface{}, token string) (interface{}, error) {
+		switch typed := container.(type) {
+		case map[string]interface{}:
+			if _, ok := typed[token]; !ok {
+				return nil, pathNotFound(token)
+			}
+			typed[token] = value
+			return typed, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(typed)-1)
+			if err != nil {
+				return nil, err
+			}
+			typed[index] = value
+			return typed, nil
+		}
+		return nil, pathNotFound(token)
+	})
+}
+
+// update walks down to the container holding the last token of the path and swaps it for what change
+// returns, arrays can grow or shrink so every container on the way is put back into its parent
+func update(target interface{}, path []string, change func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return change(target, path[0])
+	}
+	token := path[0]
+	switch typed := target.(type) {
+	case map[string]interface{}:
+		child, ok := typed[token]
+		if !ok {
+			return nil, pathNotFound(token)
+		}
+		updated, err := update(child, path[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		typed[token] = updated
+		return typed, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(typed)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := update(typed[index], path[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		typed[index] = updated
+		return typed, nil
+	}
+	return nil, pathNotFound(token)
+}
+
+// arrayIndex reads an array index from a pointer token, it can be at most max
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	return index, nil
+}
+
+func pathNotFound(token string) error {
+	return fmt.Errorf("%w: %q not found", ErrInvalidPatch, token)
+}
+
+func deepCopy(value interface{}) interface{} {
+	encoded, _ := json.Marshal(value)
+	var copied interface{}
+	json.Unmarshal(encoded, &copied)
+	return copied
+}
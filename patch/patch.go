@@ -0,0 +1,63 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// The media types of the patch formats that can be applied
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported patch type")
+	ErrInvalidPatch    = errors.New("invalid patch")
+	// ErrTestFailed is returned when a JSON Patch test operation doesn't match the document
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+// Apply patches a JSON document with a patch of the media type
+func Apply(mediaType string, document []byte, patch []byte) ([]byte, error) {
+	switch mediaType {
+	case MergePatchType:
+		return MergePatch(document, patch)
+	case JSONPatchType:
+		return JSONPatch(document, patch)
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnsupportedType, mediaType)
+}
+
+// MergePatch applies an RFC 7396 merge patch. Objects in the patch are merged into the document, nulls
+// remove members and anything else, arrays included, replaces what is there.
+func MergePatch(document []byte, patch []byte) ([]byte, error) {
+	var target, changes interface{}
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(mergeValue(target, changes))
+}
+
+func mergeValue(target interface{}, changes interface{}) interface{} {
+	changedObject, ok := changes.(map[string]interface{})
+	if !ok {
+		return changes
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for key, value := range changedObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergeValue(targetObject[key], value)
+		}
+	}
+	return targetObject
+}
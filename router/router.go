@@ -12,14 +12,18 @@ type TastyBoiRouter struct {
 	im middleware.IngredientMiddleware
 	sm middleware.ServerMiddleware
 	hm middleware.HouseholdMiddleware
+	cm middleware.CollectionMiddleware
+	mm middleware.MealMiddleware
 }
 
 func NewTastyBoiRouter(um middleware.UserMiddleware,
 	rm middleware.RecipeMiddleware,
 	im middleware.IngredientMiddleware,
 	sm middleware.ServerMiddleware,
-	hm middleware.HouseholdMiddleware) TastyBoiRouter {
-	return TastyBoiRouter{um, rm, im, sm, hm}
+	hm middleware.HouseholdMiddleware,
+	cm middleware.CollectionMiddleware,
+	mm middleware.MealMiddleware) TastyBoiRouter {
+	return TastyBoiRouter{um, rm, im, sm, hm, cm, mm}
 }
 
 // Route is exported and used in main.go
@@ -29,17 +33,69 @@ func (r TastyBoiRouter) Route() *mux.Router {
 	router.HandleFunc("/api/recipes", r.rm.PostPaginatedRecipes).Methods("POST")
 	router.HandleFunc("/api/recipes", middleware.Options).Methods("OPTIONS")
 
+	router.HandleFunc("/api/recipes/export", r.rm.ExportRecipes).Methods("GET")
+	router.HandleFunc("/api/recipes/export", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/recipe/import", r.rm.ImportRecipe).Methods("POST")
+	router.HandleFunc("/api/recipe/import", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/recipe/{id}", r.rm.ScaleRecipe).Queries("servings", "{servings}").Methods("GET")
 	router.HandleFunc("/api/recipe/{id}", r.rm.GetRecipe).Methods("GET")
 	router.HandleFunc("/api/recipe/{id}", r.rm.DeleteRecipe).Methods("DELETE")
 	router.HandleFunc("/api/recipe/{id}", r.rm.UpdateRecipe).Methods("PUT")
+	router.HandleFunc("/api/recipe/{id}", r.rm.PatchRecipe).Methods("PATCH")
 	router.HandleFunc("/api/recipe/{id}", middleware.Options).Methods("OPTIONS")
 
+	router.HandleFunc("/api/recipe/{id}/export", r.rm.ExportRecipe).Methods("GET")
+	router.HandleFunc("/api/recipe/{id}/export", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/recipe/{id}/revisions", r.rm.GetRevisions).Methods("GET")
+	router.HandleFunc("/api/recipe/{id}/revisions", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/recipe/{id}/revisions/diff", r.rm.DiffRevisions).Methods("GET")
+	router.HandleFunc("/api/recipe/{id}/revisions/diff", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/recipe/{id}/revisions/{revision}/revert", r.rm.RevertRecipe).Methods("POST")
+	router.HandleFunc("/api/recipe/{id}/revisions/{revision}/revert", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/recipe/{id}/fork", r.rm.ForkRecipe).Methods("POST")
+	router.HandleFunc("/api/recipe/{id}/fork", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/recipe/{id}/forks", r.rm.GetForks).Methods("GET")
+	router.HandleFunc("/api/recipe/{id}/forks", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/recipe/{id}/nutrition", r.rm.GetNutrition).Methods("GET")
+	router.HandleFunc("/api/recipe/{id}/nutrition", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/recipe/{id}/reviews", r.rm.GetReviews).Methods("GET")
+	router.HandleFunc("/api/recipe/{id}/reviews", r.rm.SaveReview).Methods("PUT")
+	router.HandleFunc("/api/recipe/{id}/reviews", r.rm.DeleteReview).Methods("DELETE")
+	router.HandleFunc("/api/recipe/{id}/reviews", middleware.Options).Methods("OPTIONS")
+
 	router.HandleFunc("/api/recipe", r.rm.CreateRecipe).Methods("POST")
 	router.HandleFunc("/api/recipe", middleware.Options).Methods("OPTIONS")
 
 	router.HandleFunc("/api/randomRecipe/{numberOfRecipes}", r.rm.GetRandomRecipes).Methods("GET")
 	router.HandleFunc("/api/randomRecipe/{numberOfRecipes}", middleware.Options).Methods("OPTIONS")
 
+	router.HandleFunc("/api/collections", r.cm.GetCollections).Methods("GET")
+	router.HandleFunc("/api/collections", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/collection", r.cm.CreateCollection).Methods("POST")
+	router.HandleFunc("/api/collection", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/collection/{id}", r.cm.GetCollection).Methods("GET")
+	router.HandleFunc("/api/collection/{id}", r.cm.UpdateCollection).Methods("PUT")
+	router.HandleFunc("/api/collection/{id}", r.cm.DeleteCollection).Methods("DELETE")
+	router.HandleFunc("/api/collection/{id}", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/collection/{id}/recipes", r.cm.GetCollectionRecipes).Methods("POST")
+	router.HandleFunc("/api/collection/{id}/recipes", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/collection/{id}/recipe/{recipeID}", r.cm.AddRecipe).Methods("PUT")
+	router.HandleFunc("/api/collection/{id}/recipe/{recipeID}", r.cm.RemoveRecipe).Methods("DELETE")
+	router.HandleFunc("/api/collection/{id}/recipe/{recipeID}", middleware.Options).Methods("OPTIONS")
+
 	router.HandleFunc("/api/ingredients", r.im.QueryIngredient).Queries(
 		"prefixIngredient", "{prefixIngredient}",
 	).Methods("GET")
@@ -52,6 +108,12 @@ func (r TastyBoiRouter) Route() *mux.Router {
 	router.HandleFunc("/api/ingredient/{id}", r.im.DeleteIngredient).Methods("DELETE")
 	router.HandleFunc("/api/ingredient/{id}", middleware.Options).Methods("OPTIONS")
 
+	router.HandleFunc("/api/ingredient/{id}/nutrition", r.im.UpdateNutrition).Methods("PUT")
+	router.HandleFunc("/api/ingredient/{id}/nutrition", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/ingredient/{id}/labels", r.im.UpdateLabels).Methods("PUT")
+	router.HandleFunc("/api/ingredient/{id}/labels", middleware.Options).Methods("OPTIONS")
+
 	router.HandleFunc("/api/ingredient", r.im.CreateIngredient).Methods("POST")
 	router.HandleFunc("/api/ingredient", middleware.Options).Methods("OPTIONS")
 
@@ -62,6 +124,10 @@ func (r TastyBoiRouter) Route() *mux.Router {
 	router.HandleFunc("/api/users", r.um.GetUsers).Methods("GET")
 	router.HandleFunc("/api/users", middleware.Options).Methods("OPTIONS")
 
+	router.HandleFunc("/api/user/restrictions", r.um.GetRestrictions).Methods("GET")
+	router.HandleFunc("/api/user/restrictions", r.um.UpdateRestrictions).Methods("PUT")
+	router.HandleFunc("/api/user/restrictions", middleware.Options).Methods("OPTIONS")
+
 	router.HandleFunc("/api/user/{userName}", r.um.DeleteUser).Methods("DELETE")
 	router.HandleFunc("/api/user/{userName}", middleware.Options).Methods("OPTIONS")
 
@@ -86,7 +152,43 @@ func (r TastyBoiRouter) Route() *mux.Router {
 
 	router.HandleFunc("/api/calendar", r.hm.CreateCalendar).Methods("POST")
 	router.HandleFunc("/api/calendar/{id}", r.hm.UpdateCalendar).Methods("PUT")
+	router.HandleFunc("/api/calendar/{id}", r.hm.DeleteCalendar).Methods("DELETE")
 	router.HandleFunc("/api/calendar/{id}", middleware.Options).Methods("OPTIONS")
 
+	router.HandleFunc("/api/calendars", r.hm.GetCalendars).Methods("GET")
+	router.HandleFunc("/api/calendars", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/calendar/{id}/copy", r.hm.CopyCalendar).Methods("POST")
+	router.HandleFunc("/api/calendar/{id}/copy", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/calendar/{id}/shoppingList", r.hm.GetShoppingList).Methods("GET")
+	router.HandleFunc("/api/calendar/{id}/shoppingList", r.hm.EmailShoppingList).Methods("POST")
+	router.HandleFunc("/api/calendar/{id}/shoppingList", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/calendar/{id}/{day}", r.hm.SetCalendarDay).Methods("PUT")
+	router.HandleFunc("/api/calendar/{id}/{day}", r.hm.ClearCalendarDay).Methods("DELETE")
+	router.HandleFunc("/api/calendar/{id}/{day}", middleware.Options).Methods("OPTIONS")
+	router.HandleFunc("/api/calendar/{id}/{day}/reroll", r.hm.RerollCalendarDay).Methods("POST")
+	router.HandleFunc("/api/calendar/{id}/{day}/reroll", middleware.Options).Methods("OPTIONS")
+	router.HandleFunc("/api/calendar/{id}/{day}/swap", r.hm.SwapCalendarDays).Methods("POST")
+	router.HandleFunc("/api/calendar/{id}/{day}/swap", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/calendar/{id}/{day}/cooked", r.hm.MarkCooked).Methods("POST")
+	router.HandleFunc("/api/calendar/{id}/{day}/cooked", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/history", r.hm.GetHistory).Methods("GET")
+	router.HandleFunc("/api/history", r.hm.LogCooked).Methods("POST")
+	router.HandleFunc("/api/history", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/meals", r.mm.GetMealSlots).Methods("GET")
+	router.HandleFunc("/api/meals", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/meals/migrate", r.mm.MigrateCalendars).Methods("POST")
+	router.HandleFunc("/api/meals/migrate", middleware.Options).Methods("OPTIONS")
+
+	router.HandleFunc("/api/meals/{date}/{mealType}", r.mm.SaveMealSlot).Methods("PUT")
+	router.HandleFunc("/api/meals/{date}/{mealType}", r.mm.DeleteMealSlot).Methods("DELETE")
+	router.HandleFunc("/api/meals/{date}/{mealType}", middleware.Options).Methods("OPTIONS")
+
 	return router
 }
@@ -0,0 +1,142 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	snippetLead  = 30
+	snippetTrail = 60
+	markOpen     = "<mark>"
+	markClose    = "</mark>"
+)
+
+type token struct {
+	word  string
+	start int
+	end   int
+}
+
+type span struct {
+	start int
+	end   int
+}
+
+// Highlight finds the query's terms and phrases in text and returns a short snippet around the first
+// match with every match wrapped in <mark> tags. Everything else in the snippet is HTML escaped.
+func Highlight(text string, q Query) (string, bool) {
+	matches := findMatches(tokenize(text), q)
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	start := wordStart(text, matches[0].start-snippetLead)
+	end := wordEnd(text, matches[0].end+snippetTrail)
+	var snippet strings.Builder
+	if start > 0 {
+		snippet.WriteString("…")
+	}
+	position := start
+	for _, match := range matches {
+		if match.start < position || match.end > end {
+			continue
+		}
+		snippet.WriteString(html.EscapeString(text[position:match.start]))
+		snippet.WriteString(markOpen + html.EscapeString(text[match.start:match.end]) + markClose)
+		position = match.end
+	}
+	snippet.WriteString(html.EscapeString(text[position:end]))
+	if end < len(text) {
+		snippet.WriteString("…")
+	}
+	return snippet.String(), true
+}
+
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		isWordRune := unicode.IsLetter(r) || unicode.IsNumber(r)
+		if isWordRune && start < 0 {
+			start = i
+		} else if !isWordRune && start >= 0 {
+			tokens = append(tokens, token{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return tokens
+}
+
+// findMatches returns the spans of text matching a phrase or term, in order and without overlaps
+func findMatches(tokens []token, q Query) []span {
+	var matches []span
+	for i := 0; i < len(tokens); i++ {
+		length := matchLength(tokens[i:], q)
+		if length > 0 {
+			matches = append(matches, span{tokens[i].start, tokens[i+length-1].end})
+			i += length - 1
+		}
+	}
+	return matches
+}
+
+// matchLength is how many of the tokens, starting with the first, match a phrase or term
+func matchLength(tokens []token, q Query) int {
+	for _, phrase := range q.Phrases {
+		phraseWords := strings.Fields(phrase)
+		if len(phraseWords) > len(tokens) {
+			continue
+		}
+		matched := true
+		for j, word := range phraseWords {
+			if !wordMatches(tokens[j].word, word) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return len(phraseWords)
+		}
+	}
+	for _, term := range q.Terms {
+		if wordMatches(tokens[0].word, term) {
+			return 1
+		}
+	}
+	return 0
+}
+
+// wordMatches loosely follows the stemming a text index does, so "onion" matches "onions" and the other way round
+func wordMatches(word string, term string) bool {
+	return strings.HasPrefix(word, term) || (strings.HasPrefix(term, word) && len(term)-len(word) <= 2 && len(word) > 2)
+}
+
+func wordStart(text string, position int) int {
+	if position <= 0 {
+		return 0
+	}
+	for position > 0 && !isSpace(text[position-1]) {
+		position--
+	}
+	return position
+}
+
+func wordEnd(text string, position int) int {
+	if position >= len(text) {
+		return len(text)
+	}
+	for position < len(text) && !isSpace(text[position]) {
+		position++
+	}
+	return position
+}
+
+// isSpace only looks at ASCII whitespace so it never splits a multi-byte character
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"server/models"
+	"strings"
+	"unicode"
+)
+
+// Query is a parsed search such as `chicken "sour cream" -mushroom`. Recipes match any of the terms,
+// must contain every phrase and must not contain any of the excluded terms.
+type Query struct {
+	Terms    []string
+	Phrases  []string
+	Excluded []string
+}
+
+// ParseQuery splits search text into terms, "quoted phrases" and -negated terms. Punctuation is
+// treated as a word separator so special characters can never break the search.
+func ParseQuery(text string) Query {
+	query := Query{}
+	remaining := text
+	for {
+		start := strings.Index(remaining, `"`)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(remaining[start+1:], `"`)
+		if end < 0 {
+			remaining = remaining[:start] + " " + remaining[start+1:]
+			break
+		}
+		phrase := strings.Join(words(remaining[start+1:start+1+end]), " ")
+		if phrase != "" {
+			query.Phrases = append(query.Phrases, phrase)
+		}
+		remaining = remaining[:start] + " " + remaining[start+end+2:]
+	}
+
+	for _, field := range strings.Fields(remaining) {
+		excluded := strings.HasPrefix(field, "-")
+		for _, word := range words(field) {
+			if excluded {
+				query.Excluded = append(query.Excluded, word)
+			} else {
+				query.Terms = append(query.Terms, word)
+			}
+		}
+	}
+	return query
+}
+
+// IsEmpty is true when there is nothing to search for. A query made only of exclusions is empty
+// since a text search has to match something before it can exclude anything.
+func (q Query) IsEmpty() bool {
+	return len(q.Terms) == 0 && len(q.Phrases) == 0
+}
+
+// String renders the query in MongoDB $text search syntax
+func (q Query) String() string {
+	var parts []string
+	for _, phrase := range q.Phrases {
+		parts = append(parts, `"`+phrase+`"`)
+	}
+	parts = append(parts, q.Terms...)
+	for _, excluded := range q.Excluded {
+		parts = append(parts, "-"+excluded)
+	}
+	return strings.Join(parts, " ")
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// ForRequest parses the search of a paginated request, falling back on the query recipe's name
+// for clients that search by name
+func ForRequest(request models.PaginatedRecipeRequest) Query {
+	if request.Search != "" {
+		return ParseQuery(request.Search)
+	}
+	return ParseQuery(request.QueryRecipe.RecipeName)
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"errors"
+	"server/controller"
+	"server/db"
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type mockCollectionDB struct {
+	db.CollectionDB
+	collection models.Collection
+	renamed    *string
+}
+
+func (m mockCollectionDB) GetCollection(collectionID string) (models.Collection, error) {
+	return m.collection, nil
+}
+
+func (m mockCollectionDB) UpdateCollection(collectionID string, name string, shared bool, date string) (models.Collection, error) {
+	*m.renamed = name
+	m.collection.Name = name
+	m.collection.Shared = shared
+	return m.collection, nil
+}
+
+func TestCollectionSharing(t *testing.T) {
+	var renamed string
+	collection := models.Collection{CollectionID: primitive.NewObjectID(), Name: "Holiday", UserName: "chef", Shared: true}
+	cc := controller.NewCollectionController(mockCollectionDB{collection: collection, renamed: &renamed}, nil)
+	id := collection.CollectionID.Hex()
+
+	roommate := models.Viewer{UserName: "roommate", HouseholdMembers: []string{"chef", "roommate"}}
+	if _, err := cc.GetCollection(id, roommate); err != nil {
+		t.Fatalf("A shared collection should be visible to the household, got %v", err)
+	}
+	if _, err := cc.GetCollection(id, models.Viewer{UserName: "stranger"}); !errors.Is(err, controller.ErrCollectionNotFound) {
+		t.Fatalf("Expected ErrCollectionNotFound but got %v", err)
+	}
+	if _, err := cc.UpdateCollection(id, models.Collection{Name: "Mine now"}, roommate); !errors.Is(err, controller.ErrNotCollectionOwner) {
+		t.Fatalf("Only the owner should rename a collection, got %v", err)
+	}
+	if _, err := cc.UpdateCollection(id, models.Collection{Name: "  "}, models.Viewer{UserName: "chef"}); !errors.Is(err, controller.ErrCollectionName) {
+		t.Fatalf("Expected ErrCollectionName but got %v", err)
+	}
+	updated, err := cc.UpdateCollection(id, models.Collection{Name: " Weeknight "}, models.Viewer{UserName: "chef"})
+	if err != nil || renamed != "Weeknight" || updated.Shared {
+		t.Fatalf("Expected an unshared Weeknight collection but got %+v, %v", updated, err)
+	}
+}
+
+func TestGetCollectionRecipes(t *testing.T) {
+	recipeID := primitive.NewObjectID()
+	collections := map[string]models.Collection{
+		"saved": {CollectionID: primitive.NewObjectID(), UserName: "chef", RecipeIDs: []primitive.ObjectID{recipeID}},
+		"empty": {CollectionID: primitive.NewObjectID(), UserName: "chef"},
+	}
+	for name, collection := range collections {
+		var requests []models.PaginatedRecipeRequest
+		rc := controller.NewRecipeController(mockRecipeCounter{requests: &requests}, nil, nil)
+		cc := controller.NewCollectionController(mockCollectionDB{collection: collection}, rc)
+		_, err := cc.GetCollectionRecipes(collection.CollectionID.Hex(), models.PaginatedRecipeRequest{PageSize: 10, Viewer: models.Viewer{UserName: "chef"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		recipeIDs := requests[0].Filter.RecipeIDs
+		if recipeIDs == nil || len(recipeIDs) != len(collection.RecipeIDs) {
+			t.Errorf("%s: expected only the collection's recipes but got %v", name, recipeIDs)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"server/export"
+	"server/importer"
+	"server/models"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var exportedRecipe = models.Recipe{
+	RecipeName: "Garlic Butter Shrimp",
+	Author:     "Sam",
+	PrepTime:   10,
+	CookTime:   80,
+	Servings:   4,
+	Calories:   320,
+	Tags:       []string{"dinner", "seafood"},
+	Ingredients: []models.Ingredient{
+		{Name: "shrimp", Amount: 1, Measurement: "lb"},
+		{Name: "butter", Amount: 1.5, Measurement: "tbsp"},
+		{Name: "salt", Measurement: "to taste"},
+	},
+	Steps: []models.Step{
+		{Number: 1, Text: "Melt the butter."},
+		{Number: 2, Text: "Cook the shrimp & serve."},
+	},
+}
+
+func TestExportMarkdown(t *testing.T) {
+	expected := `# Garlic Butter Shrimp
+
+*By Sam*
+
+Prep 10 min · Cook 80 min · Serves 4 · 320 calories per serving
+
+Tags: dinner, seafood
+
+## Ingredients
+
+- 1 lb shrimp
+- 1 1/2 tbsp butter
+- salt, to taste
+
+## Steps
+
+1. Melt the butter.
+2. Cook the shrimp & serve.
+`
+	if markdown := export.Markdown(exportedRecipe); markdown != expected {
+		t.Fatalf("Expected %q but got %q", expected, markdown)
+	}
+}
+
+func TestExportHTMLEscapes(t *testing.T) {
+	body, err := export.HTML(exportedRecipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(body)
+	for _, expected := range []string{"<title>Garlic Butter Shrimp</title>", "<li>1 1/2 tbsp butter</li>", "Cook the shrimp &amp; serve.", "@media print"} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Expected the card to contain %q", expected)
+		}
+	}
+}
+
+func TestExportJSONLDImportsBack(t *testing.T) {
+	document, err := export.Render(exportedRecipe, export.FormatJSONLD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if document.ContentType != "application/ld+json" {
+		t.Fatalf("Unexpected content type %q", document.ContentType)
+	}
+	imported, err := importer.FromJSONLD(document.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(imported, exportedRecipe) {
+		t.Fatalf("Expected %+v but got %+v", exportedRecipe, imported)
+	}
+}
+
+func TestExportZip(t *testing.T) {
+	id := primitive.NewObjectID()
+	second := exportedRecipe
+	second.RecipeID = id
+	document, err := export.Zip([]models.Recipe{exportedRecipe, second}, export.FormatMarkdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(document.Body), int64(len(document.Body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	expected := []string{"garlic-butter-shrimp.md", "garlic-butter-shrimp-" + id.Hex() + ".md"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected %v but got %v", expected, names)
+	}
+}
+
+func TestExportFormat(t *testing.T) {
+	if format, _ := export.ParseFormat("MD"); format != export.FormatMarkdown {
+		t.Fatalf("Expected markdown but got %q", format)
+	}
+	if _, err := export.ParseFormat("pdf"); !errors.Is(err, export.ErrUnknownFormat) {
+		t.Fatalf("Expected ErrUnknownFormat but got %v", err)
+	}
+	if format := export.Negotiate("application/json, text/html;q=0.9"); format != export.FormatHTML {
+		t.Fatalf("Expected html but got %q", format)
+	}
+	if format := export.Negotiate("*/*"); format != export.FormatMarkdown {
+		t.Fatalf("Expected markdown but got %q", format)
+	}
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"errors"
+	"server/controller"
+	"server/db"
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type mockHistoryDB struct {
+	db.HistoryDB
+	saved   *[]models.CookedEntry
+	queries *[]models.CookedHistoryQuery
+}
+
+func (m mockHistoryDB) SaveCalendarCookedEntry(entry *models.CookedEntry) error {
+	*m.saved = append(*m.saved, *entry)
+	return nil
+}
+
+func (m mockHistoryDB) GetCookedEntries(householdID string, query models.CookedHistoryQuery) ([]models.CookedEntry, error) {
+	*m.queries = append(*m.queries, query)
+	return []models.CookedEntry{}, nil
+}
+
+func TestMarkCooked(t *testing.T) {
+	householdID, _ := primitive.ObjectIDFromHex("111111111111111111111111")
+	recipeID := primitive.NewObjectID()
+	calendar := models.Calendar{
+		CalendarID:  primitive.NewObjectID(),
+		HouseholdID: householdID,
+		StartDate:   "2021.06.06",
+		Tuesday:     models.PlannedRecipe{RecipeID: recipeID, RecipeName: "Tacos"},
+	}
+	var saved []models.CookedEntry
+	hc := controller.NewHistoryController(mockHistoryDB{saved: &saved}, mockCalendarGetter{calendar: calendar}, nil)
+
+	// the entry's ID is the server's to choose, not the client's
+	sent := models.CookedEntry{EntryID: primitive.NewObjectID(), Notes: " Extra spicy ", Rating: 4}
+	entry, err := hc.MarkCooked("111111111111111111111111", "calendar", "Tuesday", sent, "chef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := models.CookedEntry{
+		HouseholdID: householdID,
+		CalendarID:  calendar.CalendarID,
+		Day:         "tuesday",
+		RecipeID:    recipeID,
+		RecipeName:  "Tacos",
+		CookedDate:  "2021.06.08",
+		UserName:    "chef",
+		Notes:       "Extra spicy",
+		Rating:      4,
+	}
+	entry.CreatedDate = ""
+	if entry != expected || len(saved) != 1 {
+		t.Fatalf("Expected %+v but got %+v", expected, entry)
+	}
+
+	failures := []struct {
+		householdID string
+		day         string
+		entry       models.CookedEntry
+		expected    error
+	}{
+		{"222222222222222222222222", "tuesday", models.CookedEntry{}, controller.ErrCalendarNotFound},
+		{"", "tuesday", models.CookedEntry{}, controller.ErrNoHousehold},
+		{"111111111111111111111111", "caturday", models.CookedEntry{}, controller.ErrUnknownDay},
+		{"111111111111111111111111", "monday", models.CookedEntry{}, controller.ErrNothingPlanned},
+		{"111111111111111111111111", "tuesday", models.CookedEntry{Rating: 6}, controller.ErrInvalidCookedEntry},
+		{"111111111111111111111111", "tuesday", models.CookedEntry{CookedDate: "yesterday"}, controller.ErrInvalidCookedEntry},
+	}
+	for _, failure := range failures {
+		if _, err := hc.MarkCooked(failure.householdID, "calendar", failure.day, failure.entry, "chef"); !errors.Is(err, failure.expected) {
+			t.Errorf("%s on %s: expected %v but got %v", failure.householdID, failure.day, failure.expected, err)
+		}
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	var queries []models.CookedHistoryQuery
+	hc := controller.NewHistoryController(mockHistoryDB{queries: &queries}, nil, nil)
+	_, err := hc.GetHistory("111111111111111111111111", models.CookedHistoryQuery{From: "2021-05-01", To: "2021.06.01 00:00:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queries[0].From != "2021.05.01" || queries[0].To != "2021.06.01" {
+		t.Fatalf("Dates were not normalized to days %+v", queries[0])
+	}
+
+	_, err = hc.GetHistory("111111111111111111111111", models.CookedHistoryQuery{From: "last month", RecipeID: "pancakes"})
+	if !errors.Is(err, controller.ErrInvalidFilter) || err.Error() != "invalid filter: from, recipeID" {
+		t.Fatalf("Expected an invalid filter but got %v", err)
+	}
+}
@@ -1,8 +1,12 @@
 package test
 
 import (
+	"errors"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"reflect"
 	"server/controller"
+	"server/db"
 	"server/models"
 	"testing"
 )
@@ -35,7 +39,7 @@ func (m mockUserUpdater) UpdateUser(user models.User) (models.User, error) {
 }
 
 func TestUpdateUserHousehold(t *testing.T) {
-	h := controller.NewHouseholdController(nil, nil, nil)
+	h := controller.NewHouseholdController(nil, nil, mockMealSync{}, nil)
 	updater := mockUserUpdater{}
 	currentUser, _ := updater.GetUser("SuccessfulUser", "")
 	if currentUser.HouseholdId != "OriginalID" {
@@ -55,7 +59,7 @@ func (m mockCalendarDB) CreateCalendar(calendar models.Calendar) (models.Calenda
 	panic("implement me")
 }
 
-func (m mockCalendarDB) DeleteCalendar(householdID string) error {
+func (m mockCalendarDB) DeleteCalendar(householdID string, calendarID string) error {
 	//TODO implement me
 	panic("implement me")
 }
@@ -85,20 +89,50 @@ func (m mockCalendarDB) GetCalendar(householdID string, startDate string) (model
 	if err2 != nil {
 
 	}
-	calendar := models.Calendar{Monday: models.Recipe{RecipeID: originalMonday}, CalendarID: originalID}
+	calendar := models.Calendar{Monday: models.PlannedRecipe{RecipeID: originalMonday}, CalendarID: originalID}
 	return calendar, nil
 }
 
+func (m mockCalendarDB) GetCalendarByID(calendarID string) (models.Calendar, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m mockCalendarDB) GetCalendars(householdID string, query models.CalendarQuery) ([]models.Calendar, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m mockCalendarDB) CountCalendars(householdID string, query models.CalendarQuery) (int64, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
 func (m mockCalendarDB) UpdateCalendar(updatedCalendar models.Calendar) (models.Calendar, error) {
 	return models.Calendar{CalendarID: updatedCalendar.CalendarID, Monday: updatedCalendar.Monday}, nil
 }
 
+func (m mockCalendarDB) SetCalendarDay(householdID string, calendarID string, day string, planned models.PlannedRecipe) (models.Calendar, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m mockCalendarDB) ClearCalendarDay(householdID string, calendarID string, day string) (models.Calendar, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m mockCalendarDB) SwapCalendarDays(householdID string, calendarID string, day string, otherDay string) (models.Calendar, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
 func TestUpdateCalendar(t *testing.T) {
-	hc := controller.NewHouseholdController(mockCalendarDB{}, nil, nil)
+	hc := controller.NewHouseholdController(mockCalendarDB{}, nil, mockMealSync{}, nil)
 	newMonday, _ := primitive.ObjectIDFromHex("333333333333333333333333")
 	calendarID, _ := primitive.ObjectIDFromHex("111111111111111111111111")
-	newCalendar := models.Calendar{CalendarID: calendarID, Monday: models.Recipe{RecipeID: newMonday}}
-	calendar, _ := hc.UpdateCalendar("testHousehold", newCalendar)
+	newCalendar := models.Calendar{CalendarID: calendarID, Monday: models.PlannedRecipe{RecipeID: newMonday}}
+	calendar, _ := hc.UpdateCalendar("444444444444444444444444", newCalendar)
 	if calendar.CalendarID.Hex() != "111111111111111111111111" {
 		t.Fatalf("Update changed calendarID, expected originalID but got %s", calendar.CalendarID.Hex())
 	}
@@ -111,3 +145,539 @@ func TestUpdateCalendar(t *testing.T) {
 		t.Fatalf("Expected NewMonday but got %s", calendar.Monday.RecipeID)
 	}
 }
+
+type mockCalendarGetter struct {
+	db.CalendarDB
+	calendar models.Calendar
+}
+
+func (m mockCalendarGetter) GetCalendarByID(calendarID string) (models.Calendar, error) {
+	return m.calendar, nil
+}
+
+func TestGetShoppingList(t *testing.T) {
+	householdID, _ := primitive.ObjectIDFromHex("111111111111111111111111")
+	pancakes := models.Recipe{RecipeID: primitive.NewObjectID(), RecipeName: "Pancakes", Ingredients: []models.Ingredient{
+		{Name: "Flour", Amount: 1, Measurement: "cup", Category: "pantry"},
+		{Name: "milk", Amount: 2, Measurement: "tbsp", Category: "Dairy"},
+		{Name: "eggs", Amount: 2, Category: "Protein"},
+	}}
+	bread := models.Recipe{RecipeID: primitive.NewObjectID(), RecipeName: "Bread", Ingredients: []models.Ingredient{
+		{Name: "flour", Amount: 0.5, Measurement: "cup", Category: "Pantry"},
+		{Name: "milk", Amount: 0.25, Measurement: "cup", Category: "Dairy"},
+		{Name: "salt", Measurement: "to taste"},
+	}}
+	calendar := models.Calendar{
+		HouseholdID: householdID,
+		Monday:      models.PlannedRecipe{RecipeID: pancakes.RecipeID, RecipeName: "Pancakes"},
+		Tuesday:     models.PlannedRecipe{RecipeID: bread.RecipeID, RecipeName: "Bread"},
+		Wednesday:   models.PlannedRecipe{RecipeID: primitive.NewObjectID(), RecipeName: "Deleted"},
+	}
+	rc := controller.NewRecipeController(mockPlannerRecipeDB{recipes: []models.Recipe{pancakes, bread}}, nil, nil)
+	hc := controller.NewHouseholdController(mockCalendarGetter{calendar: calendar}, nil, mockMealSync{}, rc)
+	list, err := hc.GetShoppingList("111111111111111111111111", "calendar", models.Viewer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []models.ShoppingListCategory{
+		{Category: "Protein", Items: []models.ShoppingListItem{{Name: "eggs", Amount: 2, Recipes: []string{"Pancakes"}}}},
+		{Category: "Dairy", Items: []models.ShoppingListItem{{Name: "milk", Amount: 0.375, Measurement: "cup", Recipes: []string{"Pancakes", "Bread"}}}},
+		{Category: "Pantry", Items: []models.ShoppingListItem{{Name: "flour", Amount: 1.5, Measurement: "cup", Recipes: []string{"Pancakes", "Bread"}}}},
+		{Category: "Other", Items: []models.ShoppingListItem{{Name: "salt", Measurement: "to taste", Recipes: []string{"Bread"}}}},
+	}
+	if !reflect.DeepEqual(list.Categories, expected) {
+		t.Fatalf("Expected %v but got %v", expected, list.Categories)
+	}
+
+	basket := controller.ShoppingListToBasket(list, "user")
+	if !reflect.DeepEqual(basket.Pantry, []string{"1 1/2 cup flour", "salt, to taste"}) {
+		t.Fatalf("Unexpected pantry %v", basket.Pantry)
+	}
+}
+
+func TestGetShoppingListForAnotherHousehold(t *testing.T) {
+	householdID, _ := primitive.ObjectIDFromHex("111111111111111111111111")
+	hc := controller.NewHouseholdController(mockCalendarGetter{calendar: models.Calendar{HouseholdID: householdID}}, nil, mockMealSync{}, nil)
+	_, err := hc.GetShoppingList("222222222222222222222222", "calendar", models.Viewer{})
+	if err != controller.ErrCalendarNotFound {
+		t.Fatal("Calendar from another household should not be found")
+	}
+}
+
+func TestGetShoppingListWithoutHousehold(t *testing.T) {
+	// a calendar saved without a household has the zero ID, which a user without one must not match
+	hc := controller.NewHouseholdController(mockCalendarGetter{calendar: models.Calendar{}}, nil, mockMealSync{}, nil)
+	if _, err := hc.GetShoppingList("", "calendar", models.Viewer{}); !errors.Is(err, controller.ErrNoHousehold) {
+		t.Fatalf("Expected ErrNoHousehold but got %v", err)
+	}
+}
+
+type mockConflictingCalendarDB struct {
+	mockCalendarGetter
+}
+
+func (m mockConflictingCalendarDB) UpdateCalendar(updatedCalendar models.Calendar) (models.Calendar, error) {
+	return models.Calendar{}, db.ErrVersionConflict
+}
+
+func TestUpdateCalendarConflict(t *testing.T) {
+	householdID, _ := primitive.ObjectIDFromHex("111111111111111111111111")
+	current := models.Calendar{HouseholdID: householdID, StartDate: "2021.06.06", Version: 4}
+	hc := controller.NewHouseholdController(mockConflictingCalendarDB{mockCalendarGetter{calendar: current}}, nil, mockMealSync{}, nil)
+	calendar, err := hc.UpdateCalendar("111111111111111111111111", models.Calendar{Version: 3})
+	if !errors.Is(err, db.ErrVersionConflict) || calendar.Version != 4 {
+		t.Fatalf("Expected a conflict with the current calendar but got %v %+v", err, calendar)
+	}
+
+	_, err = hc.UpdateCalendar("222222222222222222222222", models.Calendar{Version: 3})
+	if err != controller.ErrCalendarNotFound {
+		t.Fatal("A conflict should not show the calendar of another household")
+	}
+}
+
+// mockStoredCalendarDB replaces its calendar only when the _id, household and version all match, like the repository
+type mockStoredCalendarDB struct {
+	mockCalendarGetter
+	stored *models.Calendar
+}
+
+func (m mockStoredCalendarDB) UpdateCalendar(updatedCalendar models.Calendar) (models.Calendar, error) {
+	if updatedCalendar.CalendarID != m.stored.CalendarID || updatedCalendar.HouseholdID != m.stored.HouseholdID ||
+		updatedCalendar.Version != m.stored.Version {
+		return models.Calendar{}, db.ErrVersionConflict
+	}
+	updatedCalendar.Version++
+	*m.stored = updatedCalendar
+	return updatedCalendar, nil
+}
+
+func TestUpdateCalendarOfAnotherHousehold(t *testing.T) {
+	stored := householdCalendars("111111111111111111111111", "2021.06.06")[0]
+	hc := controller.NewHouseholdController(mockStoredCalendarDB{mockCalendarGetter{calendar: stored}, &stored}, nil, mockMealSync{}, nil)
+
+	_, err := hc.UpdateCalendar("222222222222222222222222", models.Calendar{CalendarID: stored.CalendarID, Version: stored.Version})
+	if err != controller.ErrCalendarNotFound || stored.HouseholdID.Hex() != "111111111111111111111111" || stored.Version != 2 {
+		t.Fatalf("Another household should not replace the calendar, got %v %+v", err, stored)
+	}
+	_, err = hc.UpdateCalendar("", models.Calendar{CalendarID: stored.CalendarID, Version: stored.Version})
+	if err != controller.ErrNoHousehold || stored.Version != 2 {
+		t.Fatalf("A user without a household should not replace the calendar, got %v", err)
+	}
+
+	updated, err := hc.UpdateCalendar("111111111111111111111111", models.Calendar{CalendarID: stored.CalendarID, StartDate: "2021.06.06", Version: 2})
+	if err != nil || updated.Version != 3 {
+		t.Fatalf("Expected the household to update its own calendar but got %v %+v", err, updated)
+	}
+}
+
+func TestGetCalendarExpandsRecipes(t *testing.T) {
+	soup := models.Recipe{RecipeID: primitive.NewObjectID(), RecipeName: "Tomato soup", UserName: "chef"}
+	secret := models.Recipe{RecipeID: primitive.NewObjectID(), RecipeName: "Secret sauce", UserName: "chef", Visibility: models.VisibilityPrivate}
+	calendar := models.Calendar{
+		Sunday:  models.PlannedRecipe{RecipeID: soup.RecipeID, RecipeName: "Soup"},
+		Monday:  models.PlannedRecipe{RecipeID: secret.RecipeID, RecipeName: "Secret sauce"},
+		Tuesday: models.PlannedRecipe{RecipeID: primitive.NewObjectID(), RecipeName: "Deleted"},
+	}
+	rc := controller.NewRecipeController(mockPlannerRecipeDB{recipes: []models.Recipe{soup, secret}}, nil, nil)
+	hc := controller.NewHouseholdController(mockWeekCalendarGetter{calendar: calendar}, nil, mockMealSync{}, rc)
+
+	references, err := hc.GetCalendar("111111111111111111111111", "2021.06.06", models.Viewer{UserName: "roommate"}, false)
+	if err != nil || references.Sunday.Recipe != nil || references.Sunday.RecipeName != "Soup" {
+		t.Fatalf("Only the references should be read without expanding %+v", references.Sunday)
+	}
+
+	expanded, err := hc.GetCalendar("111111111111111111111111", "2021.06.06", models.Viewer{UserName: "roommate"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expanded.Sunday.Recipe == nil || expanded.Sunday.Recipe.RecipeID != soup.RecipeID || expanded.Sunday.RecipeName != "Tomato soup" {
+		t.Fatalf("Expected the soup to be expanded with its current name %+v", expanded.Sunday)
+	}
+	if expanded.Monday.Recipe != nil || !expanded.Monday.Unavailable || expanded.Tuesday.Recipe != nil || !expanded.Tuesday.Unavailable {
+		t.Fatalf("Hidden and deleted recipes should be unavailable %+v %+v", expanded.Monday, expanded.Tuesday)
+	}
+	if expanded.Wednesday.Unavailable {
+		t.Fatal("A day with nothing planned is not unavailable")
+	}
+}
+
+type mockWeekCalendarGetter struct {
+	db.CalendarDB
+	calendar models.Calendar
+}
+
+func (m mockWeekCalendarGetter) GetCalendar(householdID string, startDate string) (models.Calendar, error) {
+	return m.calendar, nil
+}
+
+// mockHouseholdCalendars keeps the calendars of every household, in start date order
+type mockHouseholdCalendars struct {
+	db.CalendarDB
+	calendars []models.Calendar
+	created   *[]models.Calendar
+}
+
+func (m mockHouseholdCalendars) GetCalendars(householdID string, query models.CalendarQuery) ([]models.Calendar, error) {
+	var found []models.Calendar
+	for _, calendar := range m.calendars {
+		if calendar.HouseholdID.Hex() == householdID && (query.From == "" || calendar.StartDate >= query.From) &&
+			(query.To == "" || calendar.StartDate < query.To) {
+			found = append(found, calendar)
+		}
+	}
+	if query.PageSize == 0 {
+		return found, nil
+	}
+	start := int64(query.PageCount) * query.PageSize
+	if start > int64(len(found)) {
+		return []models.Calendar{}, nil
+	}
+	end := start + query.PageSize
+	if end > int64(len(found)) {
+		end = int64(len(found))
+	}
+	return found[start:end], nil
+}
+
+func (m mockHouseholdCalendars) CountCalendars(householdID string, query models.CalendarQuery) (int64, error) {
+	found, _ := m.GetCalendars(householdID, models.CalendarQuery{From: query.From, To: query.To})
+	return int64(len(found)), nil
+}
+
+func (m mockHouseholdCalendars) GetCalendar(householdID string, startDate string) (models.Calendar, error) {
+	for _, calendar := range m.calendars {
+		if calendar.HouseholdID.Hex() == householdID && calendar.StartDate == startDate {
+			return calendar, nil
+		}
+	}
+	return models.Calendar{}, mongo.ErrNoDocuments
+}
+
+func (m mockHouseholdCalendars) GetCalendarByID(calendarID string) (models.Calendar, error) {
+	for _, calendar := range m.calendars {
+		if calendar.CalendarID.Hex() == calendarID {
+			return calendar, nil
+		}
+	}
+	return models.Calendar{}, mongo.ErrNoDocuments
+}
+
+func (m mockHouseholdCalendars) DeleteCalendar(householdID string, calendarID string) error {
+	for _, calendar := range m.calendars {
+		if calendar.CalendarID.Hex() == calendarID && calendar.HouseholdID.Hex() == householdID {
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (m mockHouseholdCalendars) CreateCalendar(calendar models.Calendar) (models.Calendar, error) {
+	calendar.CalendarID = primitive.NewObjectID()
+	*m.created = append(*m.created, calendar)
+	return calendar, nil
+}
+
+func householdCalendars(householdID string, startDates ...string) []models.Calendar {
+	household, _ := primitive.ObjectIDFromHex(householdID)
+	var calendars []models.Calendar
+	for _, startDate := range startDates {
+		calendars = append(calendars, models.Calendar{CalendarID: primitive.NewObjectID(), HouseholdID: household, StartDate: startDate, Version: 2})
+	}
+	return calendars
+}
+
+func TestGetCalendars(t *testing.T) {
+	calendars := append(householdCalendars("111111111111111111111111", "2021.05.23", "2021.05.30", "2021.06.06", "2021.06.13"),
+		householdCalendars("222222222222222222222222", "2021.06.06")...)
+	hc := controller.NewHouseholdController(mockHouseholdCalendars{calendars: calendars}, nil, mockMealSync{}, nil)
+
+	page, err := hc.GetCalendars("111111111111111111111111", models.CalendarQuery{From: "2021-05-30", PageSize: 2, PageCount: 1}, models.Viewer{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.NumberOfCalendars != 3 || len(page.Calendars) != 1 || page.Calendars[0].StartDate != "2021.06.13" {
+		t.Fatalf("Expected the second page of the household's calendars since may 30th but got %+v", page)
+	}
+
+	_, err = hc.GetCalendars("111111111111111111111111", models.CalendarQuery{To: "june", PageSize: -1}, models.Viewer{}, false)
+	if !errors.Is(err, controller.ErrInvalidFilter) || err.Error() != "invalid filter: to, pageSize" {
+		t.Fatalf("Expected an invalid filter but got %v", err)
+	}
+	_, err = hc.GetCalendars("", models.CalendarQuery{}, models.Viewer{}, false)
+	if err != controller.ErrNoHousehold {
+		t.Fatalf("Expected no household but got %v", err)
+	}
+}
+
+func TestDeleteCalendar(t *testing.T) {
+	calendars := householdCalendars("111111111111111111111111", "2021.06.06")
+	hc := controller.NewHouseholdController(mockHouseholdCalendars{calendars: calendars}, nil, mockMealSync{}, nil)
+
+	if err := hc.DeleteCalendar("111111111111111111111111", calendars[0].CalendarID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	if err := hc.DeleteCalendar("222222222222222222222222", calendars[0].CalendarID.Hex()); err != controller.ErrCalendarNotFound {
+		t.Fatalf("Another household should not delete the calendar, got %v", err)
+	}
+	if err := hc.DeleteCalendar("111111111111111111111111", "not an id"); err != controller.ErrCalendarNotFound {
+		t.Fatalf("Expected calendar not found but got %v", err)
+	}
+}
+
+func TestCopyCalendar(t *testing.T) {
+	soup := models.Recipe{RecipeID: primitive.NewObjectID(), RecipeName: "Soup"}
+	calendars := householdCalendars("111111111111111111111111", "2021.06.06", "2021.06.20")
+	calendars[0].Sunday = models.PlannedRecipe{RecipeID: soup.RecipeID, RecipeName: soup.RecipeName}
+	var created []models.Calendar
+	rc := controller.NewRecipeController(mockPlannerRecipeDB{recipes: []models.Recipe{soup}}, nil, nil)
+	hc := controller.NewHouseholdController(mockHouseholdCalendars{calendars: calendars, created: &created}, nil, mockMealSync{}, rc)
+
+	copied, err := hc.CopyCalendar("111111111111111111111111", calendars[0].CalendarID.Hex(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied.StartDate != "2021.06.13" || copied.Version != 1 || copied.CalendarID == calendars[0].CalendarID ||
+		copied.Sunday.RecipeID != soup.RecipeID || !copied.Monday.RecipeID.IsZero() || len(created) != 1 {
+		t.Fatalf("Expected the week to be planned again for the next week but got %+v", copied)
+	}
+
+	_, err = hc.CopyCalendar("111111111111111111111111", calendars[0].CalendarID.Hex(), "2021-06-20")
+	if !errors.Is(err, controller.ErrCalendarExists) {
+		t.Fatalf("Expected the week to be planned already but got %v", err)
+	}
+	_, err = hc.CopyCalendar("111111111111111111111111", calendars[0].CalendarID.Hex(), "next week")
+	if err != controller.ErrInvalidStartDate {
+		t.Fatalf("Expected an invalid start date but got %v", err)
+	}
+	_, err = hc.CopyCalendar("222222222222222222222222", calendars[0].CalendarID.Hex(), "")
+	if err != controller.ErrCalendarNotFound {
+		t.Fatalf("Another household should not copy the calendar, got %v", err)
+	}
+}
+
+// mockDayCalendarDB changes the days of a single calendar in place
+type mockDayCalendarDB struct {
+	db.CalendarDB
+	calendar *models.Calendar
+}
+
+func (m mockDayCalendarDB) GetCalendarByID(calendarID string) (models.Calendar, error) {
+	if m.calendar.CalendarID.Hex() != calendarID {
+		return models.Calendar{}, mongo.ErrNoDocuments
+	}
+	return *m.calendar, nil
+}
+
+func (m mockDayCalendarDB) SetCalendarDay(householdID string, calendarID string, day string, planned models.PlannedRecipe) (models.Calendar, error) {
+	return m.update(householdID, calendarID, func(days map[string]*models.PlannedRecipe) {
+		*days[day] = planned
+	})
+}
+
+func (m mockDayCalendarDB) ClearCalendarDay(householdID string, calendarID string, day string) (models.Calendar, error) {
+	return m.update(householdID, calendarID, func(days map[string]*models.PlannedRecipe) {
+		*days[day] = models.PlannedRecipe{}
+	})
+}
+
+func (m mockDayCalendarDB) SwapCalendarDays(householdID string, calendarID string, day string, otherDay string) (models.Calendar, error) {
+	return m.update(householdID, calendarID, func(days map[string]*models.PlannedRecipe) {
+		*days[day], *days[otherDay] = *days[otherDay], *days[day]
+	})
+}
+
+func (m mockDayCalendarDB) update(householdID string, calendarID string, change func(map[string]*models.PlannedRecipe)) (models.Calendar, error) {
+	if m.calendar.CalendarID.Hex() != calendarID || m.calendar.HouseholdID.Hex() != householdID {
+		return models.Calendar{}, mongo.ErrNoDocuments
+	}
+	change(map[string]*models.PlannedRecipe{
+		"sunday": &m.calendar.Sunday, "monday": &m.calendar.Monday, "tuesday": &m.calendar.Tuesday,
+		"wednesday": &m.calendar.Wednesday, "thursday": &m.calendar.Thursday, "friday": &m.calendar.Friday,
+		"saturday": &m.calendar.Saturday,
+	})
+	m.calendar.Version++
+	return *m.calendar, nil
+}
+
+func dayCalendar(recipes ...models.Recipe) *models.Calendar {
+	calendar := &householdCalendars("111111111111111111111111", "2021.06.06")[0]
+	days := []*models.PlannedRecipe{&calendar.Sunday, &calendar.Monday, &calendar.Tuesday}
+	for i, recipe := range recipes {
+		*days[i] = models.PlannedRecipe{RecipeID: recipe.RecipeID, RecipeName: recipe.RecipeName}
+	}
+	return calendar
+}
+
+func TestSetCalendarDay(t *testing.T) {
+	soup := models.Recipe{RecipeID: primitive.NewObjectID(), RecipeName: "Soup", UserName: "chef"}
+	secret := models.Recipe{RecipeID: primitive.NewObjectID(), RecipeName: "Secret sauce", UserName: "chef", Visibility: models.VisibilityPrivate}
+	calendar := dayCalendar()
+	rc := controller.NewRecipeController(mockPlannerRecipeDB{recipes: []models.Recipe{soup, secret}}, nil, nil)
+	hc := controller.NewHouseholdController(mockDayCalendarDB{calendar: calendar}, nil, mockMealSync{}, rc)
+	calendarID := calendar.CalendarID.Hex()
+
+	updated, err := hc.SetCalendarDay("111111111111111111111111", calendarID, "Monday", soup.RecipeID.Hex(), models.Viewer{UserName: "roommate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Monday.RecipeID != soup.RecipeID || updated.Monday.Recipe == nil || updated.Version != 3 {
+		t.Fatalf("Expected the soup on monday of the next version but got %+v", updated)
+	}
+
+	_, err = hc.SetCalendarDay("111111111111111111111111", calendarID, "monday", secret.RecipeID.Hex(), models.Viewer{UserName: "roommate"})
+	if err != controller.ErrRecipeNotFound {
+		t.Fatalf("A hidden recipe should not be planned, got %v", err)
+	}
+	_, err = hc.SetCalendarDay("111111111111111111111111", calendarID, "funday", soup.RecipeID.Hex(), models.Viewer{})
+	if !errors.Is(err, controller.ErrUnknownDay) {
+		t.Fatalf("Expected an unknown day but got %v", err)
+	}
+	_, err = hc.SetCalendarDay("222222222222222222222222", calendarID, "monday", soup.RecipeID.Hex(), models.Viewer{})
+	if err != controller.ErrCalendarNotFound {
+		t.Fatalf("Another household should not change the calendar, got %v", err)
+	}
+}
+
+func TestRerollCalendarDay(t *testing.T) {
+	soup := plannerRecipe("Soup", 30, 300, "dinner")
+	salad := plannerRecipe("Salad", 10, 250, "dinner")
+	curry := plannerRecipe("Curry", 30, 600, "dinner")
+	calendar := dayCalendar(soup, salad)
+	rc := controller.NewRecipeController(mockPlannerRecipeDB{recipes: []models.Recipe{soup, salad, curry}}, nil, nil)
+	hc := controller.NewHouseholdController(mockDayCalendarDB{calendar: calendar}, nil, mockMealSync{}, rc)
+	calendarID := calendar.CalendarID.Hex()
+
+	updated, err := hc.RerollCalendarDay("111111111111111111111111", calendarID, "sunday", models.RandomRecipeRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Sunday.RecipeID != curry.RecipeID {
+		t.Fatalf("Expected a recipe that isn't planned that week but got %s", updated.Sunday.RecipeName)
+	}
+
+	calendar = dayCalendar(soup, salad, curry)
+	hc = controller.NewHouseholdController(mockDayCalendarDB{calendar: calendar}, nil, mockMealSync{}, rc)
+	calendarID = calendar.CalendarID.Hex()
+	updated, err = hc.RerollCalendarDay("111111111111111111111111", calendarID, "sunday", models.RandomRecipeRequest{})
+	if err != nil || updated.Sunday.RecipeID != salad.RecipeID {
+		t.Fatalf("Expected a recipe from another day once every recipe is planned but got %v %s", err, updated.Sunday.RecipeName)
+	}
+
+	_, err = hc.RerollCalendarDay("111111111111111111111111", calendarID, "sunday", models.RandomRecipeRequest{
+		ExcludeRecipeIDs: []string{soup.RecipeID.Hex(), curry.RecipeID.Hex()},
+	})
+	if err != controller.ErrNoRerollRecipe {
+		t.Fatalf("Expected no other recipe but got %v", err)
+	}
+}
+
+func TestSwapAndClearCalendarDays(t *testing.T) {
+	soup := plannerRecipe("Soup", 30, 300)
+	salad := plannerRecipe("Salad", 10, 250)
+	calendar := dayCalendar(soup, salad)
+	hc := controller.NewHouseholdController(mockDayCalendarDB{calendar: calendar}, nil, mockMealSync{}, nil)
+	calendarID := calendar.CalendarID.Hex()
+
+	swapped, err := hc.SwapCalendarDays("111111111111111111111111", calendarID, "sunday", "tuesday")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !swapped.Sunday.RecipeID.IsZero() || swapped.Tuesday.RecipeID != soup.RecipeID || swapped.Monday.RecipeID != salad.RecipeID {
+		t.Fatalf("Expected the soup to move to tuesday but got %+v", swapped)
+	}
+	_, err = hc.SwapCalendarDays("111111111111111111111111", calendarID, "sunday", "")
+	if !errors.Is(err, controller.ErrUnknownDay) {
+		t.Fatalf("Expected an unknown day but got %v", err)
+	}
+
+	cleared, err := hc.ClearCalendarDay("111111111111111111111111", calendarID, "monday")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cleared.Monday.RecipeID.IsZero() || cleared.Tuesday.RecipeID != soup.RecipeID || cleared.Version != 4 {
+		t.Fatalf("Expected only monday to be cleared but got %+v", cleared)
+	}
+}
+
+// mockMealSync records the slots each calendar was last synced to
+type mockMealSync struct {
+	db.MealUpdater
+	synced map[primitive.ObjectID][]models.MealSlot
+}
+
+func (m mockMealSync) SyncCalendarSlots(calendarID primitive.ObjectID, slots []models.MealSlot) error {
+	if m.synced != nil {
+		m.synced[calendarID] = slots
+	}
+	return nil
+}
+
+func TestCalendarChangesSyncMealSlots(t *testing.T) {
+	soup := plannerRecipe("Soup", 30, 300)
+	salad := plannerRecipe("Salad", 10, 250)
+	calendar := dayCalendar(soup, salad)
+	sync := mockMealSync{synced: map[primitive.ObjectID][]models.MealSlot{}}
+	hc := controller.NewHouseholdController(mockDayCalendarDB{calendar: calendar}, nil, sync, nil)
+
+	if _, err := hc.ClearCalendarDay("111111111111111111111111", calendar.CalendarID.Hex(), "sunday"); err != nil {
+		t.Fatal(err)
+	}
+	slots := sync.synced[calendar.CalendarID]
+	if len(slots) != 1 || slots[0].Date != "2021.06.07" || slots[0].MealType != models.MealDinner ||
+		slots[0].Recipes[0].RecipeID != salad.RecipeID {
+		t.Fatalf("Expected only monday's dinner to be left but got %+v", slots)
+	}
+
+	calendars := householdCalendars("111111111111111111111111", "2021.06.13")
+	hc = controller.NewHouseholdController(mockHouseholdCalendars{calendars: calendars}, nil, sync, nil)
+	sync.synced[calendars[0].CalendarID] = slots
+	if err := hc.DeleteCalendar("111111111111111111111111", calendars[0].CalendarID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	if slots, ok := sync.synced[calendars[0].CalendarID]; !ok || len(slots) != 0 {
+		t.Fatalf("Expected the deleted calendar's slots to be removed but got %+v", slots)
+	}
+}
+
+// mockFailingMealSync can't sync any slots
+type mockFailingMealSync struct {
+	db.MealUpdater
+}
+
+func (m mockFailingMealSync) SyncCalendarSlots(calendarID primitive.ObjectID, slots []models.MealSlot) error {
+	return errors.New("slots are down")
+}
+
+func TestCalendarChangesReportUnsyncedSlots(t *testing.T) {
+	calendar := dayCalendar(plannerRecipe("Soup", 30, 300), plannerRecipe("Salad", 10, 250))
+	hc := controller.NewHouseholdController(mockDayCalendarDB{calendar: calendar}, nil, mockFailingMealSync{}, nil)
+	if _, err := hc.ClearCalendarDay("111111111111111111111111", calendar.CalendarID.Hex(), "sunday"); !errors.Is(err, controller.ErrSlotsNotSynced) {
+		t.Fatalf("Expected ErrSlotsNotSynced but got %v", err)
+	}
+
+	calendars := householdCalendars("111111111111111111111111", "2021.06.13")
+	hc = controller.NewHouseholdController(mockHouseholdCalendars{calendars: calendars}, nil, mockFailingMealSync{}, nil)
+	if err := hc.DeleteCalendar("111111111111111111111111", calendars[0].CalendarID.Hex()); !errors.Is(err, controller.ErrSlotsNotSynced) {
+		t.Fatalf("Expected ErrSlotsNotSynced but got %v", err)
+	}
+}
+
+// mockRacingCalendars has another copy of the week saved between the check for it and the insert
+type mockRacingCalendars struct {
+	mockHouseholdCalendars
+}
+
+func (m mockRacingCalendars) CreateCalendar(calendar models.Calendar) (models.Calendar, error) {
+	return models.Calendar{}, mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "duplicate key"}}}
+}
+
+func TestCopyCalendarRace(t *testing.T) {
+	calendars := householdCalendars("111111111111111111111111", "2021.06.06")
+	hc := controller.NewHouseholdController(mockRacingCalendars{mockHouseholdCalendars{calendars: calendars}}, nil, mockMealSync{}, nil)
+
+	_, err := hc.CopyCalendar("111111111111111111111111", calendars[0].CalendarID.Hex(), "")
+	if !errors.Is(err, controller.ErrCalendarExists) {
+		t.Fatalf("Expected the week to be planned already but got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"server/importer"
+	"server/models"
+	"testing"
+)
+
+func TestParseIngredient(t *testing.T) {
+	tests := map[string]models.Ingredient{
+		"1 1/2 cups flour, sifted":  {Name: "flour, sifted", Amount: 1.5, Measurement: "cup"},
+		"- 2 large eggs":            {Name: "large eggs", Amount: 2},
+		"½ tsp. of vanilla extract": {Name: "vanilla extract", Amount: 0.5, Measurement: "tsp"},
+		"8 fl oz milk":              {Name: "milk", Amount: 8, Measurement: "fl oz"},
+		"Salt, to taste":            {Name: "Salt", Measurement: "to taste"},
+		"Fresh basil":               {Name: "Fresh basil"},
+	}
+	for line, expected := range tests {
+		if ingredient := importer.ParseIngredient(line); !reflect.DeepEqual(ingredient, expected) {
+			t.Errorf("%q: expected %+v but got %+v", line, expected, ingredient)
+		}
+	}
+}
+
+func TestImportJSONLD(t *testing.T) {
+	document := `{
+		"@context": "https://schema.org",
+		"@graph": [
+			{"@type": "WebPage", "name": "Not a recipe"},
+			{
+				"@type": ["Recipe"],
+				"name": "Banana Bread",
+				"author": {"@type": "Person", "name": "Sam"},
+				"prepTime": "PT15M",
+				"cookTime": "PT1H",
+				"recipeYield": ["8", "8 slices"],
+				"nutrition": {"@type": "NutritionInformation", "calories": "240 calories"},
+				"keywords": "Baking, Breakfast",
+				"recipeIngredient": ["3 ripe bananas", "2 cups all-purpose flour"],
+				"recipeInstructions": [
+					{"@type": "HowToSection", "name": "Batter", "itemListElement": [
+						{"@type": "HowToStep", "text": "Mash the bananas."},
+						{"@type": "HowToStep", "text": "Stir in the <b>flour</b>."}
+					]},
+					"Bake for an hour."
+				]
+			}
+		]
+	}`
+	recipe, err := importer.Parse("", document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := models.Recipe{
+		RecipeName: "Banana Bread",
+		Author:     "Sam",
+		PrepTime:   15,
+		CookTime:   60,
+		Servings:   8,
+		Calories:   240,
+		Tags:       []string{"baking", "breakfast"},
+		Ingredients: []models.Ingredient{
+			{Name: "ripe bananas", Amount: 3},
+			{Name: "all-purpose flour", Amount: 2, Measurement: "cup"},
+		},
+		Steps: []models.Step{
+			{Number: 1, Text: "Mash the bananas."},
+			{Number: 2, Text: "Stir in the flour."},
+			{Number: 3, Text: "Bake for an hour."},
+		},
+	}
+	if !reflect.DeepEqual(recipe, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, recipe)
+	}
+}
+
+func TestImportText(t *testing.T) {
+	text := `Weeknight Chili
+Serves 6
+Prep time: 10 minutes
+Cook time: 1 hour 15 mins
+
+Ingredients:
+* 1 lb ground beef
+* 2 cans kidney beans
+* chili powder to taste
+
+Directions
+1. Brown the beef.
+2) Add everything else and simmer.`
+	recipe, err := importer.Parse(importer.FormatText, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := models.Recipe{
+		RecipeName: "Weeknight Chili",
+		Servings:   6,
+		PrepTime:   10,
+		CookTime:   75,
+		Ingredients: []models.Ingredient{
+			{Name: "ground beef", Amount: 1, Measurement: "lb"},
+			{Name: "kidney beans", Amount: 2, Measurement: "can"},
+			{Name: "chili powder", Measurement: "to taste"},
+		},
+		Steps: []models.Step{
+			{Number: 1, Text: "Brown the beef."},
+			{Number: 2, Text: "Add everything else and simmer."},
+		},
+	}
+	if !reflect.DeepEqual(recipe, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, recipe)
+	}
+}
+
+func TestImportUnrecognizedRecipe(t *testing.T) {
+	inputs := map[string]string{
+		"":                    "Just a note about dinner",
+		importer.FormatJSONLD: `{"@type": "Article"}`,
+		"xml":                 "<recipe/>",
+	}
+	for format, content := range inputs {
+		if _, err := importer.Parse(format, content); !errors.Is(err, importer.ErrUnrecognizedRecipe) {
+			t.Errorf("%q: expected ErrUnrecognizedRecipe but got %v", content, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"server/controller"
+	"server/db"
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type mockMealDB struct {
+	db.MealDB
+	saved   *[]models.MealSlot
+	queries *[]models.MealSlotQuery
+}
+
+func (m mockMealDB) GetMealSlots(householdID string, query models.MealSlotQuery) ([]models.MealSlot, error) {
+	*m.queries = append(*m.queries, query)
+	return []models.MealSlot{}, nil
+}
+
+func (m mockMealDB) SaveMealSlot(slot models.MealSlot) (models.MealSlot, error) {
+	*m.saved = append(*m.saved, slot)
+	return slot, nil
+}
+
+func (m mockMealDB) CreateMissingMealSlots(slots []models.MealSlot) (int64, error) {
+	*m.saved = append(*m.saved, slots...)
+	return int64(len(slots)), nil
+}
+
+func TestSaveMealSlot(t *testing.T) {
+	var saved []models.MealSlot
+	pancakes := models.Recipe{RecipeID: primitive.NewObjectID(), RecipeName: "Pancakes", UserName: "chef"}
+	rc := controller.NewRecipeController(mockPlannerRecipeDB{recipes: []models.Recipe{pancakes}}, nil, nil)
+	mc := controller.NewMealController(mockMealDB{saved: &saved}, nil, rc)
+
+	slot, err := mc.SaveMealSlot("111111111111111111111111", "2021-06-06", "Breakfast", models.MealSlot{
+		Recipes: []models.SlotRecipe{{RecipeID: pancakes.RecipeID, Servings: 6, Notes: "double the syrup"}},
+		Notes:   "brunch with the neighbours",
+	}, models.Viewer{UserName: "chef"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []models.SlotRecipe{{RecipeID: pancakes.RecipeID, RecipeName: "Pancakes", Servings: 6, Notes: "double the syrup"}}
+	if slot.Date != "2021.06.06" || slot.MealType != models.MealBreakfast || !reflect.DeepEqual(slot.Recipes, expected) {
+		t.Fatalf("Unexpected slot %+v", slot)
+	}
+
+	_, err = mc.SaveMealSlot("111111111111111111111111", "someday", "brunch", models.MealSlot{
+		Recipes: []models.SlotRecipe{{RecipeID: primitive.NewObjectID()}, {RecipeID: pancakes.RecipeID, Servings: -2}},
+	}, models.Viewer{UserName: "chef"})
+	if !errors.Is(err, controller.ErrInvalidMealSlot) {
+		t.Fatalf("Expected an invalid slot but got %v", err)
+	}
+	if err.Error() != "invalid meal slot: date, mealType, recipes[0].recipeID, recipes[1].servings" {
+		t.Fatalf("Unexpected error %q", err.Error())
+	}
+	if len(saved) != 1 {
+		t.Fatal("An invalid slot should not be saved")
+	}
+
+	if _, err := mc.SaveMealSlot("", "2021.06.06", "lunch", models.MealSlot{}, models.Viewer{}); err != controller.ErrNoHousehold {
+		t.Fatalf("Expected ErrNoHousehold but got %v", err)
+	}
+}
+
+func TestGetMealSlotsRange(t *testing.T) {
+	var queries []models.MealSlotQuery
+	mc := controller.NewMealController(mockMealDB{queries: &queries}, nil, nil)
+	_, err := mc.GetMealSlots("111111111111111111111111", models.MealSlotQuery{From: "2021-06-01", To: "2021.07.01", MealType: "Dinner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := models.MealSlotQuery{From: "2021.06.01", To: "2021.07.01", MealType: "dinner"}
+	if queries[0] != expected {
+		t.Fatalf("Expected %+v but got %+v", expected, queries[0])
+	}
+
+	_, err = mc.GetMealSlots("111111111111111111111111", models.MealSlotQuery{From: "june", MealType: "elevenses"})
+	if !errors.Is(err, controller.ErrInvalidFilter) || err.Error() != "invalid filter: from, mealType" {
+		t.Fatalf("Expected an invalid range but got %v", err)
+	}
+}
+
+type mockCalendarLister struct {
+	db.CalendarDB
+	calendars []models.Calendar
+}
+
+func (m mockCalendarLister) GetCalendars(householdID string, query models.CalendarQuery) ([]models.Calendar, error) {
+	return m.calendars, nil
+}
+
+func TestMigrateCalendars(t *testing.T) {
+	householdID, _ := primitive.ObjectIDFromHex("111111111111111111111111")
+	calendarID := primitive.NewObjectID()
+	tacos := models.PlannedRecipe{RecipeID: primitive.NewObjectID(), RecipeName: "Tacos"}
+	soup := models.PlannedRecipe{RecipeID: primitive.NewObjectID(), RecipeName: "Soup"}
+	calendars := []models.Calendar{
+		{CalendarID: calendarID, HouseholdID: householdID, StartDate: "2021.06.06", Sunday: tacos, Wednesday: soup},
+		{CalendarID: primitive.NewObjectID(), HouseholdID: householdID, StartDate: "first week of june", Monday: soup},
+	}
+	var saved []models.MealSlot
+	mc := controller.NewMealController(mockMealDB{saved: &saved}, mockCalendarLister{calendars: calendars}, nil)
+	migration, err := mc.MigrateCalendars("111111111111111111111111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migration.Calendars != 1 || migration.Slots != 2 || !reflect.DeepEqual(migration.Skipped, []string{calendars[1].CalendarID.Hex()}) {
+		t.Fatalf("Unexpected migration %+v", migration)
+	}
+
+	var migrated []string
+	for _, slot := range saved {
+		if slot.HouseholdID != householdID || slot.CalendarID != calendarID || slot.MealType != models.MealDinner {
+			t.Fatalf("Slot was not migrated from the calendar %+v", slot)
+		}
+		migrated = append(migrated, slot.Date+" "+slot.Recipes[0].RecipeName)
+	}
+	if !reflect.DeepEqual(migrated, []string{"2021.06.06 Tacos", "2021.06.09 Soup"}) {
+		t.Fatalf("Unexpected slots %v", migrated)
+	}
+}
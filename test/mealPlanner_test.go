@@ -0,0 +1,206 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"server/controller"
+	"server/db"
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// mockPlannerRecipeDB samples its recipes in order, leaving out the excluded ones
+type mockPlannerRecipeDB struct {
+	db.RecipeDB
+	recipes []models.Recipe
+}
+
+func (m mockPlannerRecipeDB) GetRandomRecipes(request models.RandomRecipeRequest) ([]models.Recipe, error) {
+	var sampled []models.Recipe
+	for _, recipe := range m.recipes {
+		excluded := false
+		for _, recipeID := range request.ExcludeRecipeIDs {
+			excluded = excluded || recipeID == recipe.RecipeID.Hex()
+		}
+		if !excluded && len(sampled) < request.NumberOfRecipes {
+			sampled = append(sampled, recipe)
+		}
+	}
+	return sampled, nil
+}
+
+func (m mockPlannerRecipeDB) GetRecipe(recipeID string) (models.Recipe, error) {
+	for _, recipe := range m.recipes {
+		if recipe.RecipeID.Hex() == recipeID {
+			return recipe, nil
+		}
+	}
+	return models.Recipe{}, errors.New("not found")
+}
+
+func (m mockPlannerRecipeDB) GetRecipesByID(recipeIDs []primitive.ObjectID) ([]models.Recipe, error) {
+	var found []models.Recipe
+	for _, recipe := range m.recipes {
+		for _, recipeID := range recipeIDs {
+			if recipe.RecipeID == recipeID {
+				found = append(found, recipe)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (m mockPlannerRecipeDB) IncrementPopularity(recipeIDs []primitive.ObjectID) error {
+	return nil
+}
+
+type mockPlannerCalendarDB struct {
+	db.CalendarDB
+	previous []models.Calendar
+}
+
+func (m mockPlannerCalendarDB) GetCalendars(householdID string, query models.CalendarQuery) ([]models.Calendar, error) {
+	return m.previous, nil
+}
+
+func (m mockPlannerCalendarDB) CreateCalendar(calendar models.Calendar) (models.Calendar, error) {
+	return calendar, nil
+}
+
+func plannerRecipe(name string, minutes int, calories int, tags ...string) models.Recipe {
+	return models.Recipe{RecipeID: primitive.NewObjectID(), RecipeName: name, CookTime: minutes, Calories: calories, Tags: tags}
+}
+
+func newPlanner(recipes []models.Recipe, previous []models.Calendar) controller.HouseholdController {
+	rc := controller.NewRecipeController(mockPlannerRecipeDB{recipes: recipes}, nil, nil)
+	return controller.NewHouseholdController(mockPlannerCalendarDB{previous: previous}, nil, mockMealSync{}, rc)
+}
+
+func TestCreateCalendarMeetsConstraints(t *testing.T) {
+	roast := plannerRecipe("Roast", 120, 900, "dinner")
+	lastWeek := plannerRecipe("Tacos", 20, 500, "dinner")
+	salmon := plannerRecipe("Salmon", 25, 450, "fish")
+	cod := plannerRecipe("Cod", 15, 400, "fish")
+	pizza := plannerRecipe("Pizza", 40, 700, "dinner")
+	recipes := []models.Recipe{roast, lastWeek, plannerRecipe("Soup", 30, 300), plannerRecipe("Salad", 10, 250),
+		plannerRecipe("Omelette", 10, 350), pizza, plannerRecipe("Curry", 30, 600), plannerRecipe("Stew", 150, 650),
+		salmon, cod}
+	previous := []models.Calendar{{StartDate: "2021.05.30", Monday: models.PlannedRecipe{RecipeID: lastWeek.RecipeID}}}
+
+	calendar, err := newPlanner(recipes, previous).CreateCalendar(models.CalendarRequest{
+		StartDate: "2021.06.06",
+		Constraints: models.PlanConstraints{
+			WeeknightMaxTotalTime: 30,
+			NoRepeatWeeks:         1,
+			RequiredTags:          []models.TagRequirement{{Tag: "Fish", MinDays: 2}},
+			PinnedDays:            map[string]string{"saturday": pizza.RecipeID.Hex()},
+		},
+	}, "111111111111111111111111", models.Viewer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calendar.Unsatisfied) > 0 {
+		t.Fatalf("Expected every constraint to be met but got %+v", calendar.Unsatisfied)
+	}
+	if calendar.Saturday.RecipeID != pizza.RecipeID {
+		t.Fatalf("Expected the pinned recipe on saturday but got %s", calendar.Saturday.RecipeName)
+	}
+	week := []models.PlannedRecipe{calendar.Sunday, calendar.Monday, calendar.Tuesday, calendar.Wednesday, calendar.Thursday, calendar.Friday, calendar.Saturday}
+	fish := 0
+	for i, recipe := range week {
+		if recipe.RecipeID.IsZero() || recipe.RecipeID == lastWeek.RecipeID {
+			t.Fatalf("Unexpected recipe %q on day %d", recipe.RecipeName, i)
+		}
+		if i >= 1 && i <= 5 && recipe.Recipe.CookTime > 30 {
+			t.Fatalf("%s takes too long for a weeknight", recipe.RecipeName)
+		}
+		if recipe.RecipeID == salmon.RecipeID || recipe.RecipeID == cod.RecipeID {
+			fish++
+		}
+	}
+	if fish != 2 {
+		t.Fatalf("Expected both fish recipes to be planned but got %d", fish)
+	}
+}
+
+func TestCreateCalendarReportsUnsatisfiedConstraints(t *testing.T) {
+	var recipes []models.Recipe
+	for _, name := range []string{"Lasagna", "Chili", "Brisket", "Ribs", "Paella", "Ramen", "Pho"} {
+		recipes = append(recipes, plannerRecipe(name, 45, 500, "dinner"))
+	}
+	calendar, err := newPlanner(recipes, nil).CreateCalendar(models.CalendarRequest{
+		StartDate: "2021.06.06",
+		Constraints: models.PlanConstraints{
+			WeeknightMaxTotalTime: 30,
+			RequiredTags:          []models.TagRequirement{{Tag: "vegetarian", MinDays: 1}},
+			MaxDaysPerTag:         6,
+		},
+	}, "111111111111111111111111", models.Viewer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var unsatisfied []string
+	for _, constraint := range calendar.Unsatisfied {
+		unsatisfied = append(unsatisfied, constraint.Constraint+" "+constraint.Day)
+	}
+	expected := []string{
+		"weeknightMaxTotalTime monday", "weeknightMaxTotalTime tuesday", "weeknightMaxTotalTime wednesday",
+		"weeknightMaxTotalTime thursday", "weeknightMaxTotalTime friday", "requiredTags ", "maxDaysPerTag ",
+	}
+	if !reflect.DeepEqual(unsatisfied, expected) {
+		t.Fatalf("Expected %v but got %v", expected, unsatisfied)
+	}
+}
+
+func TestCreateCalendarReusesIngredients(t *testing.T) {
+	roastChicken := plannerRecipe("Roast chicken", 60, 600)
+	roastChicken.Ingredients = []models.Ingredient{{Name: "chicken", Amount: 1}, {Name: "salt", Measurement: "to taste"}}
+	chickenSalad := plannerRecipe("Chicken salad", 10, 400)
+	chickenSalad.Ingredients = []models.Ingredient{{Name: "Chicken", Amount: 1, Measurement: "cup"}}
+	recipes := []models.Recipe{roastChicken}
+	for _, name := range []string{"Pancakes", "Waffles", "Toast", "Porridge", "Muffins", "Crepes"} {
+		recipe := plannerRecipe(name, 10, 300)
+		recipe.Ingredients = []models.Ingredient{{Name: "salt", Measurement: "to taste"}}
+		recipes = append(recipes, recipe)
+	}
+	recipes = append(recipes, chickenSalad)
+
+	calendar, err := newPlanner(recipes, nil).CreateCalendar(models.CalendarRequest{
+		StartDate:   "2021.06.06",
+		Constraints: models.PlanConstraints{ReuseIngredients: true},
+	}, "111111111111111111111111", models.Viewer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calendar.Sunday.RecipeID != roastChicken.RecipeID || calendar.Monday.RecipeID != chickenSalad.RecipeID {
+		t.Fatalf("Expected the leftover chicken to be used on monday but got %s", calendar.Monday.RecipeName)
+	}
+}
+
+func TestCreateCalendarInvalidConstraints(t *testing.T) {
+	hc := newPlanner(nil, nil)
+	_, err := hc.CreateCalendar(models.CalendarRequest{
+		StartDate: "next week",
+		Constraints: models.PlanConstraints{
+			NoRepeatWeeks: 2,
+			RequiredTags:  []models.TagRequirement{{Tag: "fish"}},
+			PinnedDays:    map[string]string{"funday": "111111111111111111111111", "monday": "222222222222222222222222"},
+		},
+	}, "111111111111111111111111", models.Viewer{})
+	if !errors.Is(err, controller.ErrInvalidPlan) {
+		t.Fatalf("Expected invalid constraints but got %v", err)
+	}
+	if err.Error() != "invalid plan constraints: startDate, constraints.requiredTags[0], constraints.pinnedDays.funday" {
+		t.Fatalf("Unexpected error %q", err.Error())
+	}
+
+	_, err = hc.CreateCalendar(models.CalendarRequest{
+		Constraints: models.PlanConstraints{PinnedDays: map[string]string{"monday": "222222222222222222222222"}},
+	}, "111111111111111111111111", models.Viewer{})
+	if err == nil || err.Error() != "invalid plan constraints: constraints.pinnedDays.monday" {
+		t.Fatalf("A recipe that can't be found should not be pinned, got %v", err)
+	}
+}
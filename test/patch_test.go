@@ -0,0 +1,133 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"server/controller"
+	"server/models"
+	"server/patch"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func assertJSON(t *testing.T, actual []byte, expected string) {
+	t.Helper()
+	var actualValue, expectedValue interface{}
+	json.Unmarshal(actual, &actualValue)
+	json.Unmarshal([]byte(expected), &expectedValue)
+	if !reflect.DeepEqual(actualValue, expectedValue) {
+		t.Fatalf("Expected %s but got %s", expected, actual)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	document := `{"a": "b", "c": {"d": "e", "f": "g"}, "list": [1, 2]}`
+	patched, err := patch.MergePatch([]byte(document), []byte(`{"a": "z", "c": {"f": null}, "list": [3]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, patched, `{"a": "z", "c": {"d": "e"}, "list": [3]}`)
+}
+
+func TestJSONPatch(t *testing.T) {
+	document := `{"steps": ["one", "two", "three"], "tags": {"a/b": 1}}`
+	operations := `[
+		{"op": "move", "from": "/steps/2", "path": "/steps/0"},
+		{"op": "add", "path": "/steps/-", "value": "four"},
+		{"op": "remove", "path": "/steps/1"},
+		{"op": "replace", "path": "/tags/a~1b", "value": 2},
+		{"op": "copy", "from": "/steps/0", "path": "/first"},
+		{"op": "test", "path": "/first", "value": "three"}
+	]`
+	patched, err := patch.JSONPatch([]byte(document), []byte(operations))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, patched, `{"steps": ["three", "two", "four"], "tags": {"a/b": 2}, "first": "three"}`)
+}
+
+func TestJSONPatchErrors(t *testing.T) {
+	document := []byte(`{"steps": ["one"]}`)
+	if _, err := patch.JSONPatch(document, []byte(`[{"op": "test", "path": "/steps/0", "value": "two"}]`)); !errors.Is(err, patch.ErrTestFailed) {
+		t.Fatalf("Expected ErrTestFailed but got %v", err)
+	}
+	invalid := []string{
+		`[{"op": "remove", "path": "/steps/1"}]`,
+		`[{"op": "add", "path": "/missing/0", "value": 1}]`,
+		`[{"op": "replace", "path": "/steps/0"}]`,
+		`[{"op": "move", "from": "/steps", "path": "/steps/0"}]`,
+		`[{"op": "shuffle", "path": "/steps"}]`,
+		`{"op": "remove"}`,
+	}
+	for _, operations := range invalid {
+		if _, err := patch.JSONPatch(document, []byte(operations)); !errors.Is(err, patch.ErrInvalidPatch) {
+			t.Errorf("%s: expected ErrInvalidPatch but got %v", operations, err)
+		}
+	}
+	if _, err := patch.Apply("application/json", document, []byte(`{}`)); !errors.Is(err, patch.ErrUnsupportedType) {
+		t.Fatalf("Expected ErrUnsupportedType but got %v", err)
+	}
+}
+
+func TestPatchRecipe(t *testing.T) {
+	id := primitive.NewObjectID()
+	stored := models.Recipe{
+		RecipeID:    id,
+		RecipeName:  "Pancakes",
+		UserName:    "chef",
+		CreatedDate: "2021.05.01 08:00:00",
+		Version:     2,
+		Steps:       []models.Step{{Number: 1, Text: "Mix."}, {Number: 2, Text: "Rest."}, {Number: 3, Text: "Fry."}},
+	}
+	var revisions []models.RecipeRevision
+	rc := controller.NewRecipeController(mockRecipeStore{recipe: &stored}, mockRevisionDB{revisions: &revisions}, mockIngredientCatalog{})
+
+	operations := `[{"op": "move", "from": "/steps/2", "path": "/steps/1"}, {"op": "remove", "path": "/steps/2"}]`
+	recipe, _, err := rc.PatchRecipe(id.Hex(), patch.JSONPatchType, []byte(operations), 2, "chef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedSteps := []models.Step{{Number: 1, Text: "Mix."}, {Number: 2, Text: "Fry."}}
+	if !reflect.DeepEqual(recipe.Steps, expectedSteps) || recipe.CreatedDate != "2021.05.01 08:00:00" || recipe.UserName != "chef" {
+		t.Fatalf("Unexpected patched recipe %+v", recipe)
+	}
+
+	_, invalidFields, err := rc.PatchRecipe(id.Hex(), patch.MergePatchType, []byte(`{"userName": "thief", "createdDate": null}`), stored.Version, "chef")
+	if !errors.Is(err, controller.ErrImmutableField) || !reflect.DeepEqual(invalidFields, []string{"createdDate", "userName"}) {
+		t.Fatalf("Expected the immutable fields to be refused but got %v %v", err, invalidFields)
+	}
+
+	forkedFrom := `{"forkedFrom": {"recipeID": "111111111111111111111111", "userName": "someone"}}`
+	_, invalidFields, err = rc.PatchRecipe(id.Hex(), patch.MergePatchType, []byte(forkedFrom), stored.Version, "chef")
+	if !errors.Is(err, controller.ErrImmutableField) || !reflect.DeepEqual(invalidFields, []string{"forkedFrom"}) {
+		t.Fatalf("Expected where the recipe was forked from to be refused but got %v %v", err, invalidFields)
+	}
+}
+
+func TestPatchRecipePrivate(t *testing.T) {
+	id := primitive.NewObjectID()
+	stored := models.Recipe{
+		RecipeID:   id,
+		RecipeName: "Pancakes",
+		UserName:   "chef",
+		Visibility: models.VisibilityHousehold,
+		Steps:      []models.Step{{Number: 1, Text: "Mix."}},
+	}
+	var revisions []models.RecipeRevision
+	rc := controller.NewRecipeController(mockRecipeStore{recipe: &stored}, mockRevisionDB{revisions: &revisions}, mockIngredientCatalog{})
+
+	recipe, _, err := rc.PatchRecipe(id.Hex(), patch.MergePatchType, []byte(`{"private": true}`), stored.Version, "chef")
+	if err != nil || recipe.Visibility != models.VisibilityPrivate || !recipe.Private {
+		t.Fatalf("Expected the recipe to be made private but got %v %+v", err, recipe)
+	}
+	recipe, _, err = rc.PatchRecipe(id.Hex(), patch.MergePatchType, []byte(`{"private": null}`), stored.Version, "chef")
+	if err != nil || recipe.Visibility != models.VisibilityPublic || recipe.Private {
+		t.Fatalf("Expected the recipe to be made public but got %v %+v", err, recipe)
+	}
+	recipe, _, err = rc.PatchRecipe(id.Hex(), patch.MergePatchType, []byte(`{"visibility": "household"}`), stored.Version, "chef")
+	if err != nil || recipe.Visibility != models.VisibilityHousehold || recipe.Private {
+		t.Fatalf("Expected the recipe to be shared with the household but got %v %+v", err, recipe)
+	}
+}
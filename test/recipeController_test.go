@@ -0,0 +1,300 @@
+package test
+
+import (
+	"errors"
+	"server/controller"
+	"server/db"
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Embedding the interface lets the mock only implement the methods a test needs,
+// anything else panics on a nil interface
+type mockRecipeGetter struct {
+	db.RecipeDB
+	recipe models.Recipe
+}
+
+func (m mockRecipeGetter) GetRecipe(recipeID string) (models.Recipe, error) {
+	return m.recipe, nil
+}
+
+func TestScaleRecipe(t *testing.T) {
+	rc := controller.NewRecipeController(mockRecipeGetter{recipe: models.Recipe{
+		Servings: 2,
+		Calories: 400,
+		Ingredients: []models.Ingredient{
+			{Name: "sugar", Amount: 4, Measurement: "tsp"},
+			{Name: "flour", Amount: 0.5, Measurement: "cup"},
+			{Name: "eggs", Amount: 1, Measurement: ""},
+			{Name: "salt", Measurement: "to taste"},
+		},
+	}}, nil, nil)
+	recipe, err := rc.ScaleRecipe("id", 24, models.Viewer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []models.Ingredient{
+		{Name: "sugar", Amount: 1, Measurement: "cup"},
+		{Name: "flour", Amount: 6, Measurement: "cup"},
+		{Name: "eggs", Amount: 12, Measurement: ""},
+		{Name: "salt", Measurement: "to taste"},
+	}
+	for i, ingredient := range recipe.Ingredients {
+		if ingredient != expected[i] {
+			t.Fatalf("Expected %v but got %v", expected[i], ingredient)
+		}
+	}
+	if recipe.Servings != 24 {
+		t.Fatalf("Expected 24 servings but got %d", recipe.Servings)
+	}
+	if recipe.Calories != 400 {
+		t.Fatalf("Expected 400 calories per serving but got %d", recipe.Calories)
+	}
+}
+
+func TestScaleRecipeRoundsToFractions(t *testing.T) {
+	rc := controller.NewRecipeController(mockRecipeGetter{recipe: models.Recipe{
+		Servings: 3,
+		Ingredients: []models.Ingredient{
+			{Name: "milk", Amount: 1, Measurement: "cup"},
+			{Name: "butter", Amount: 1, Measurement: "tbsp"},
+		},
+	}}, nil, nil)
+	recipe, _ := rc.ScaleRecipe("id", 1, models.Viewer{})
+	if recipe.Ingredients[0].Amount != float32(1.0/3.0) || recipe.Ingredients[0].Measurement != "cup" {
+		t.Fatalf("Expected 1/3 cup but got %v", recipe.Ingredients[0])
+	}
+	if recipe.Ingredients[1].Amount != 1 || recipe.Ingredients[1].Measurement != "tsp" {
+		t.Fatalf("Expected 1 tsp but got %v", recipe.Ingredients[1])
+	}
+}
+
+func TestScaleRecipeWithoutServings(t *testing.T) {
+	rc := controller.NewRecipeController(mockRecipeGetter{recipe: models.Recipe{}}, nil, nil)
+	_, err := rc.ScaleRecipe("id", 4, models.Viewer{})
+	if !errors.Is(err, controller.ErrCannotScale) {
+		t.Fatal("Recipe without servings should not scale")
+	}
+}
+
+type mockRecipeCounter struct {
+	db.RecipeDB
+	requests *[]models.PaginatedRecipeRequest
+}
+
+func (m mockRecipeCounter) GetFilteredRecipeCount(request models.PaginatedRecipeRequest) (int64, error) {
+	*m.requests = append(*m.requests, request)
+	return 0, nil
+}
+
+func TestPaginatedRecipesNormalizesFilterDates(t *testing.T) {
+	var requests []models.PaginatedRecipeRequest
+	rc := controller.NewRecipeController(mockRecipeCounter{requests: &requests}, nil, nil)
+	_, err := rc.PostPaginatedRecipes(models.PaginatedRecipeRequest{Filter: models.RecipeFilter{
+		CreatedAfter:  "2021-05-01",
+		UpdatedBefore: "2021.06.01 10:30:00",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := requests[0].Filter
+	if filter.CreatedAfter != "2021.05.01" || filter.UpdatedBefore != "2021.06.01 10:30:00" {
+		t.Fatalf("Dates were not normalized %v", filter)
+	}
+}
+
+func TestPaginatedRecipesInvalidFilter(t *testing.T) {
+	rc := controller.NewRecipeController(nil, nil, nil)
+	_, err := rc.PostPaginatedRecipes(models.PaginatedRecipeRequest{Filter: models.RecipeFilter{
+		MaxCalories:  -1,
+		CreatedAfter: "last tuesday",
+	}})
+	if !errors.Is(err, controller.ErrInvalidFilter) {
+		t.Fatal("Expected an invalid filter")
+	}
+	if err.Error() != "invalid filter: filter.createdAfter, filter.maxCalories" {
+		t.Fatalf("Unexpected error %q", err.Error())
+	}
+}
+
+func TestPaginatedRecipesInvalidSort(t *testing.T) {
+	rc := controller.NewRecipeController(nil, nil, nil)
+	_, err := rc.PostPaginatedRecipes(models.PaginatedRecipeRequest{Sort: []models.RecipeSort{
+		{Field: "calories", Direction: "desc"},
+		{Field: "color"},
+		{Field: "name", Direction: "sideways"},
+	}})
+	if !errors.Is(err, controller.ErrInvalidSort) {
+		t.Fatal("Expected an invalid sort")
+	}
+	if err.Error() != "invalid sort: sort[1].field, sort[2].direction" {
+		t.Fatalf("Unexpected error %q", err.Error())
+	}
+}
+
+type mockRandomRecipeGetter struct {
+	db.RecipeDB
+	requests *[]models.RandomRecipeRequest
+}
+
+func (m mockRandomRecipeGetter) GetRandomRecipes(request models.RandomRecipeRequest) ([]models.Recipe, error) {
+	*m.requests = append(*m.requests, request)
+	return []models.Recipe{{RecipeName: "Random"}}, nil
+}
+
+func TestGetRandomRecipes(t *testing.T) {
+	var requests []models.RandomRecipeRequest
+	rc := controller.NewRecipeController(mockRandomRecipeGetter{requests: &requests}, nil, nil)
+	recipes, err := rc.GetRandomRecipes(models.RandomRecipeRequest{
+		NumberOfRecipes:  3,
+		Filter:           models.RecipeFilter{CreatedAfter: "2021-01-01"},
+		ExcludeRecipeIDs: []string{"111111111111111111111111"},
+	})
+	if err != nil || len(recipes) != 1 {
+		t.Fatal("Expected the sampled recipes")
+	}
+	if requests[0].Filter.CreatedAfter != "2021.01.01" {
+		t.Fatal("Filter was not normalized before sampling")
+	}
+
+	_, err = rc.GetRandomRecipes(models.RandomRecipeRequest{NumberOfRecipes: 3, ExcludeRecipeIDs: []string{"not an id"}})
+	if !errors.Is(err, controller.ErrInvalidFilter) {
+		t.Fatal("Expected an invalid excluded recipe ID")
+	}
+	if len(requests) != 1 {
+		t.Fatal("Invalid request should not be sampled")
+	}
+}
+
+func TestGetRecipeVisibility(t *testing.T) {
+	owner := models.Viewer{UserName: "chef"}
+	householdMember := models.Viewer{UserName: "roommate", HouseholdMembers: []string{"chef", "roommate"}}
+	stranger := models.Viewer{UserName: "stranger"}
+	tests := []struct {
+		recipe   models.Recipe
+		viewer   models.Viewer
+		expected bool
+	}{
+		{models.Recipe{UserName: "chef", Visibility: models.VisibilityPrivate}, owner, true},
+		{models.Recipe{UserName: "chef", Visibility: models.VisibilityPrivate}, householdMember, false},
+		{models.Recipe{UserName: "chef", Visibility: models.VisibilityHousehold}, householdMember, true},
+		{models.Recipe{UserName: "chef", Visibility: models.VisibilityHousehold}, stranger, false},
+		{models.Recipe{UserName: "chef", Visibility: models.VisibilityPublic}, stranger, true},
+		{models.Recipe{UserName: "chef", Private: true}, stranger, false},
+		{models.Recipe{UserName: "chef"}, models.Viewer{}, true},
+	}
+	for i, test := range tests {
+		rc := controller.NewRecipeController(mockRecipeGetter{recipe: test.recipe}, nil, nil)
+		_, err := rc.GetRecipe("id", test.viewer)
+		if test.expected && err != nil {
+			t.Errorf("%d: expected the recipe but got %v", i, err)
+		}
+		if !test.expected && !errors.Is(err, controller.ErrRecipeNotFound) {
+			t.Errorf("%d: expected ErrRecipeNotFound but got %v", i, err)
+		}
+	}
+}
+
+type mockRecipeForker struct {
+	mockRecipeGetter
+	created *models.Recipe
+}
+
+func (m mockRecipeForker) CreateRecipe(recipe *models.Recipe) error {
+	recipe.RecipeID = primitive.NewObjectID()
+	*m.created = *recipe
+	return nil
+}
+
+func TestForkRecipe(t *testing.T) {
+	sourceID := primitive.NewObjectID()
+	source := models.Recipe{
+		RecipeID:    sourceID,
+		RecipeName:  "Lasagna",
+		Author:      "Nonna",
+		UserName:    "chef",
+		Visibility:  models.VisibilityHousehold,
+		Popularity:  12,
+		Ingredients: []models.Ingredient{{Name: "noodles", Amount: 1, Measurement: "lb"}},
+	}
+	var created models.Recipe
+	var revisions []models.RecipeRevision
+	rc := controller.NewRecipeController(mockRecipeForker{mockRecipeGetter{recipe: source}, &created}, mockRevisionDB{revisions: &revisions}, mockIngredientCatalog{})
+
+	roommate := models.Viewer{UserName: "roommate", HouseholdMembers: []string{"chef", "roommate"}}
+	fork, err := rc.ForkRecipe(sourceID.Hex(), roommate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedReference := models.RecipeReference{RecipeID: sourceID, RecipeName: "Lasagna", UserName: "chef", Author: "Nonna"}
+	if fork.RecipeID == sourceID || fork.UserName != "roommate" || fork.Popularity != 0 || fork.Visibility != models.VisibilityPrivate {
+		t.Fatalf("Fork was not made into a new recipe of the viewer's %+v", fork)
+	}
+	if fork.ForkedFrom == nil || *fork.ForkedFrom != expectedReference || created.ForkedFrom == nil {
+		t.Fatalf("Expected the fork to reference %+v but got %+v", expectedReference, fork.ForkedFrom)
+	}
+
+	if _, err := rc.ForkRecipe(sourceID.Hex(), models.Viewer{UserName: "stranger"}); !errors.Is(err, controller.ErrRecipeNotFound) {
+		t.Fatalf("A recipe the viewer can't see should not be forked, got %v", err)
+	}
+}
+
+func TestCreateRecipeResetsServerFields(t *testing.T) {
+	var created models.Recipe
+	var revisions []models.RecipeRevision
+	rc := controller.NewRecipeController(mockRecipeForker{created: &created}, mockRevisionDB{revisions: &revisions}, mockIngredientCatalog{})
+
+	recipe, _, err := rc.CreateRecipe(models.Recipe{
+		RecipeName:    "Toast",
+		UserName:      "chef",
+		Ingredients:   []models.Ingredient{{Name: "bread", Amount: 1, Measurement: "slice"}},
+		Steps:         []models.Step{{Number: 1, Text: "Toast."}},
+		Popularity:    40,
+		AverageRating: 5,
+		RatingCount:   1000,
+		ForkedFrom:    &models.RecipeReference{RecipeID: primitive.NewObjectID(), UserName: "someone"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipe.Popularity != 0 || recipe.AverageRating != 0 || recipe.RatingCount != 0 || created.RatingCount != 0 {
+		t.Fatalf("A new recipe should not keep the counts it was sent with %+v", recipe)
+	}
+	if recipe.ForkedFrom != nil || created.ForkedFrom != nil {
+		t.Fatalf("A new recipe should not claim to be a fork %+v", recipe.ForkedFrom)
+	}
+}
+
+// mockForkLister finds the source recipe and counts no forks
+type mockForkLister struct {
+	mockRecipeCounter
+	source models.Recipe
+}
+
+func (m mockForkLister) GetRecipe(recipeID string) (models.Recipe, error) {
+	return m.source, nil
+}
+
+func TestGetForks(t *testing.T) {
+	var requests []models.PaginatedRecipeRequest
+	sourceID := primitive.NewObjectID()
+	rc := controller.NewRecipeController(mockForkLister{mockRecipeCounter{requests: &requests}, models.Recipe{RecipeID: sourceID}}, nil, nil)
+	if _, err := rc.GetForks(sourceID.Hex(), models.PaginatedRecipeRequest{PageSize: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if requests[0].Filter.ForkedFrom != sourceID.Hex() {
+		t.Fatalf("Expected only forks of the recipe but got %+v", requests[0].Filter)
+	}
+}
+
+func TestPaginatedRecipesInvalidMinRating(t *testing.T) {
+	rc := controller.NewRecipeController(nil, nil, nil)
+	_, err := rc.PostPaginatedRecipes(models.PaginatedRecipeRequest{Filter: models.RecipeFilter{MinRating: 5.5}})
+	if !errors.Is(err, controller.ErrInvalidFilter) || err.Error() != "invalid filter: filter.minRating" {
+		t.Fatalf("Expected an invalid minRating but got %v", err)
+	}
+}
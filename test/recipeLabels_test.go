@@ -0,0 +1,103 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"server/controller"
+	"server/models"
+	"testing"
+)
+
+var labelCatalog = mockIngredientCatalog{ingredients: []models.Ingredient{
+	{Name: "flour", Labels: &models.DietaryLabels{Allergens: []string{"gluten"}, Diets: []string{"vegan", "vegetarian", "pescatarian"}}},
+	{Name: "egg", Labels: &models.DietaryLabels{Allergens: []string{"eggs"}, Diets: []string{"vegetarian", "pescatarian"}}},
+	{Name: "butter", Labels: &models.DietaryLabels{Allergens: []string{"dairy"}, Diets: []string{"vegetarian", "pescatarian"}}},
+	{Name: "salt", Labels: &models.DietaryLabels{Allergens: []string{}, Diets: []string{"vegan", "vegetarian", "pescatarian"}}},
+	{Name: "saffron"},
+}}
+
+func createLabeledRecipe(t *testing.T, ingredients []models.Ingredient) models.Recipe {
+	var created models.Recipe
+	var revisions []models.RecipeRevision
+	rc := controller.NewRecipeController(mockRecipeForker{created: &created}, mockRevisionDB{revisions: &revisions}, labelCatalog)
+	recipe, _, err := rc.CreateRecipe(models.Recipe{RecipeName: "Crepes", Servings: 2, Ingredients: ingredients})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Labels == nil {
+		t.Fatal("Labels should be saved with the recipe")
+	}
+	return recipe
+}
+
+func TestCreateRecipeLabels(t *testing.T) {
+	recipe := createLabeledRecipe(t, []models.Ingredient{
+		{Name: "Flour", Amount: 1, Measurement: "cup"},
+		{Name: "Eggs, beaten", Amount: 2},
+		{Name: "butter", Amount: 1, Measurement: "tbsp"},
+		{Name: "salt", Measurement: "to taste"},
+	})
+	expected := models.RecipeLabels{Allergens: []string{"gluten", "dairy", "eggs"}, Diets: []string{"vegetarian", "pescatarian"}}
+	if !reflect.DeepEqual(*recipe.Labels, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, *recipe.Labels)
+	}
+
+	recipe = createLabeledRecipe(t, []models.Ingredient{{Name: "flour", Amount: 1, Measurement: "cup"}, {Name: "saffron", Amount: 1}})
+	expected = models.RecipeLabels{Allergens: []string{"gluten"}, Diets: []string{}, UnlabeledIngredients: []string{"saffron"}}
+	if !reflect.DeepEqual(*recipe.Labels, expected) {
+		t.Fatalf("An unlabeled ingredient should leave the recipe without diets, got %+v", *recipe.Labels)
+	}
+}
+
+func TestIngredientLabelsImplyDiets(t *testing.T) {
+	var updated models.DietaryLabels
+	ic := controller.NewIngredientController()
+	_, err := ic.UpdateLabels("id", models.DietaryLabels{Allergens: []string{" Soy"}, Diets: []string{"VEGAN"}}, mockLabelUpdater{&updated})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := models.DietaryLabels{Allergens: []string{"soy"}, Diets: []string{"vegan", "vegetarian", "pescatarian"}}
+	if !reflect.DeepEqual(updated, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, updated)
+	}
+
+	_, err = ic.UpdateLabels("id", models.DietaryLabels{Allergens: []string{"cilantro"}, Diets: []string{"keto"}}, mockLabelUpdater{&updated})
+	if !errors.Is(err, controller.ErrInvalidLabels) || err.Error() != "invalid dietary labels: labels.allergens, labels.diets" {
+		t.Fatalf("Expected invalid labels but got %v", err)
+	}
+}
+
+type mockLabelUpdater struct {
+	updated *models.DietaryLabels
+}
+
+func (m mockLabelUpdater) UpdateIngredientNutrition(ingredientID string, nutrition models.NutritionFacts) (models.Ingredient, error) {
+	panic("implement me")
+}
+
+func (m mockLabelUpdater) UpdateIngredientLabels(ingredientID string, labels models.DietaryLabels) (models.Ingredient, error) {
+	*m.updated = labels
+	return models.Ingredient{Labels: &labels}, nil
+}
+
+func TestPaginatedRecipesRespectRestrictions(t *testing.T) {
+	var requests []models.PaginatedRecipeRequest
+	rc := controller.NewRecipeController(mockRecipeCounter{requests: &requests}, nil, nil)
+	viewer := models.Viewer{UserName: "chef", Restrictions: models.DietaryRestrictions{Allergens: []string{"nuts"}, Diets: []string{"vegetarian"}}}
+	_, err := rc.PostPaginatedRecipes(models.PaginatedRecipeRequest{
+		Filter: models.RecipeFilter{ExcludeAllergens: []string{"Dairy", "nuts"}, RespectRestrictions: true},
+		Viewer: viewer,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := requests[0].Filter
+	if !reflect.DeepEqual(filter.ExcludeAllergens, []string{"dairy", "nuts"}) || !reflect.DeepEqual(filter.Diets, []string{"vegetarian"}) {
+		t.Fatalf("Restrictions were not added to the filter %+v", filter)
+	}
+
+	_, err = rc.PostPaginatedRecipes(models.PaginatedRecipeRequest{Filter: models.RecipeFilter{Diets: []string{"carnivore"}}})
+	if !errors.Is(err, controller.ErrInvalidFilter) || err.Error() != "invalid filter: filter.diets" {
+		t.Fatalf("Expected an invalid diet but got %v", err)
+	}
+}
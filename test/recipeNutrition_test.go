@@ -0,0 +1,95 @@
+package test
+
+import (
+	"reflect"
+	"server/controller"
+	"server/db"
+	"server/models"
+	"strings"
+	"testing"
+)
+
+// mockIngredientCatalog finds the catalog ingredients with any of the names
+type mockIngredientCatalog struct {
+	db.IngredientGetter
+	ingredients []models.Ingredient
+}
+
+func (m mockIngredientCatalog) GetIngredientsByName(names []string) ([]models.Ingredient, error) {
+	var found []models.Ingredient
+	for _, ingredient := range m.ingredients {
+		for _, name := range names {
+			if strings.EqualFold(ingredient.Name, name) {
+				found = append(found, ingredient)
+			}
+		}
+	}
+	return found, nil
+}
+
+var nutritionCatalog = mockIngredientCatalog{ingredients: []models.Ingredient{
+	{Name: "Flour", Nutrition: &models.NutritionFacts{Amount: 100, Measurement: "g", Density: 0.53,
+		Nutrients: models.Nutrients{Calories: 364, Protein: 10, Fat: 1, Carbs: 76, Fiber: 2.7, Sugar: 0.3, Sodium: 2}}},
+	{Name: "egg", Nutrition: &models.NutritionFacts{Amount: 1,
+		Nutrients: models.Nutrients{Calories: 72, Protein: 6.3, Fat: 4.8, Carbs: 0.4, Sodium: 71, Sugar: 0.2}}},
+	{Name: "butter", Nutrition: &models.NutritionFacts{Amount: 1, Measurement: "tbsp",
+		Nutrients: models.Nutrients{Calories: 102, Fat: 11.5, Sodium: 91}}},
+	{Name: "saffron"},
+}}
+
+func TestCreateRecipeNutrition(t *testing.T) {
+	var created models.Recipe
+	var revisions []models.RecipeRevision
+	rc := controller.NewRecipeController(mockRecipeForker{created: &created}, mockRevisionDB{revisions: &revisions}, nutritionCatalog)
+	recipe, _, err := rc.CreateRecipe(models.Recipe{
+		RecipeName: "Crepes",
+		Servings:   2,
+		Ingredients: []models.Ingredient{
+			{Name: "flour", Amount: 1, Measurement: "cup"},
+			{Name: "Eggs, beaten", Amount: 2},
+			{Name: "butter", Amount: 0.5, Measurement: "oz"},
+			{Name: "saffron", Amount: 1, Measurement: "pinch"},
+			{Name: "salt", Measurement: "to taste"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a cup of flour is about 125 g, and half an ounce of butter can't be converted without a density
+	expected := models.RecipeNutrition{
+		Total:              models.Nutrients{Calories: 600.4, Protein: 25.1, Fat: 10.9, Carbs: 96.1, Fiber: 3.4, Sodium: 144.5, Sugar: 0.8},
+		PerServing:         models.Nutrients{Calories: 300.2, Protein: 12.6, Fat: 5.5, Carbs: 48.1, Fiber: 1.7, Sodium: 72.3, Sugar: 0.4},
+		MissingIngredients: []string{"butter", "saffron"},
+	}
+	if recipe.Nutrition == nil || !reflect.DeepEqual(*recipe.Nutrition, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, recipe.Nutrition)
+	}
+	if created.Nutrition == nil {
+		t.Fatal("Nutrition should be saved with the recipe")
+	}
+}
+
+func TestScaleRecipeNutrition(t *testing.T) {
+	nutrition := &models.RecipeNutrition{
+		Total:      models.Nutrients{Calories: 800, Protein: 40},
+		PerServing: models.Nutrients{Calories: 200, Protein: 10},
+		Complete:   true,
+	}
+	rc := controller.NewRecipeController(mockRecipeGetter{recipe: models.Recipe{Servings: 4, Nutrition: nutrition}}, nil, nil)
+	recipe, err := rc.ScaleRecipe("id", 6, models.Viewer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipe.Nutrition.Total.Calories != 1200 || recipe.Nutrition.PerServing.Calories != 200 || nutrition.Total.Calories != 800 {
+		t.Fatalf("Expected the total to scale and per serving to stay the same but got %+v", recipe.Nutrition)
+	}
+}
+
+func TestInvalidNutritionFacts(t *testing.T) {
+	ic := controller.NewIngredientController()
+	_, err := ic.UpdateNutrition("id", models.NutritionFacts{Measurement: "to taste", Nutrients: models.Nutrients{Fat: -1}}, nil)
+	if err == nil || err.Error() != "invalid nutrition: nutrition.amount, nutrition.measurement, nutrition.nutrients" {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package test
+
+import (
+	"reflect"
+	"server/db"
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRecipeFilterExcludesAllergens(t *testing.T) {
+	filters := db.RecipeFilter(models.PaginatedRecipeRequest{Filter: models.RecipeFilter{ExcludeAllergens: []string{"peanut"}}})["$and"].(bson.A)
+	expected := bson.M{
+		"labels":                      bson.M{"$ne": nil},
+		"labels.unlabeledingredients": bson.M{"$in": bson.A{nil, bson.A{}}},
+		"labels.allergens":            bson.M{"$nin": []string{"peanut"}},
+	}
+	if !reflect.DeepEqual(filters[len(filters)-1], expected) {
+		t.Fatalf("Recipes without labels or with unlabeled ingredients should not pass the allergy filter, got %v", filters)
+	}
+	// only who can see the recipe is left without allergens
+	if filters := db.RecipeFilter(models.PaginatedRecipeRequest{})["$and"].(bson.A); len(filters) != 1 {
+		t.Fatalf("Expected no allergen filter but got %v", filters)
+	}
+}
+
+// pageOf makes the page for the request from the first found recipes, as if its pipeline had found them
+func pageOf(t *testing.T, request models.PaginatedRecipeRequest, recipes []models.Recipe, values []bson.A, found int) models.RecipePage {
+	pager, err := db.NewRecipePager(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pager.Page(append([]models.Recipe{}, recipes[:found]...), append([]bson.A{}, values[:found]...))
+}
+
+// cursorMatch is the stage of the request's pipeline that starts the page at its cursor
+func cursorMatch(t *testing.T, request models.PaginatedRecipeRequest) interface{} {
+	pager, err := db.NewRecipePager(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stage := range pager.Pipeline()[1:] {
+		if stage[0].Key == "$match" {
+			return stage[0].Value
+		}
+	}
+	t.Fatalf("Expected the pipeline to start from the cursor %v", pager.Pipeline())
+	return nil
+}
+
+func TestRecipeCursorFilter(t *testing.T) {
+	request := models.PaginatedRecipeRequest{PageSize: 2, Sort: []models.RecipeSort{{Field: "rating"}, {Field: "name", Direction: "asc"}}}
+	recipes := []models.Recipe{{RecipeID: primitive.NewObjectID()}, {RecipeID: primitive.NewObjectID()}, {RecipeID: primitive.NewObjectID()}}
+	values := []bson.A{{5.0, "crepes"}, {4.5, "pancakes"}, {4.0, "waffles"}}
+	id := recipes[1].RecipeID
+
+	equal := func(name string, value interface{}) bson.M {
+		return bson.M{"$eq": bson.A{"$" + name, bson.M{"$literal": value}}}
+	}
+	compare := func(operator string, name string, value interface{}) bson.M {
+		return bson.M{operator: bson.A{"$" + name, bson.M{"$literal": value}}}
+	}
+	expected := func(ratingOperator string, nameOperator string, idOperator string) bson.M {
+		return bson.M{"$expr": bson.M{"$or": bson.A{
+			bson.M{"$and": bson.A{compare(ratingOperator, "sortkey0", 4.5)}},
+			bson.M{"$and": bson.A{equal("sortkey0", 4.5), compare(nameOperator, "sortkey1", "pancakes")}},
+			// the ID breaks ties between recipes with the same sort values
+			bson.M{"$and": bson.A{equal("sortkey0", 4.5), equal("sortkey1", "pancakes"), bson.M{idOperator: bson.A{"$_id", id}}}},
+		}}}
+	}
+
+	// rating sorts descending and name ascending, paging backwards flips every comparison
+	request.Cursor = pageOf(t, request, recipes, values, 3).NextCursor
+	if forward, want := cursorMatch(t, request), expected("$lt", "$gt", "$gt"); !reflect.DeepEqual(forward, want) {
+		t.Fatalf("Expected %v but got %v", want, forward)
+	}
+	request.Cursor = pageOf(t, request, recipes[1:], values[1:], 2).PrevCursor
+	if backward, want := cursorMatch(t, request), expected("$gt", "$lt", "$lt"); !reflect.DeepEqual(backward, want) {
+		t.Fatalf("Expected %v but got %v", want, backward)
+	}
+}
+
+func TestInvalidRecipeCursor(t *testing.T) {
+	request := models.PaginatedRecipeRequest{PageSize: 1, Sort: []models.RecipeSort{{Field: "rating"}, {Field: "name", Direction: "asc"}}}
+	recipes := []models.Recipe{{RecipeID: primitive.NewObjectID()}, {RecipeID: primitive.NewObjectID()}}
+	otherSort := request
+	otherSort.Sort = []models.RecipeSort{{Field: "rating", Direction: "asc"}, {Field: "name"}}
+	otherSearch := request
+	otherSearch.Search = "soup"
+	cursors := map[string]string{
+		"not base64":     "%%%",
+		"not a cursor":   "bm90IGJzb24",
+		"another sort":   pageOf(t, otherSort, recipes, []bson.A{{4.5, "pancakes"}, {4.0, "waffles"}}, 2).NextCursor,
+		"another search": pageOf(t, otherSearch, recipes, []bson.A{{4.5, "pancakes"}, {4.0, "waffles"}}, 2).NextCursor,
+		"too few values": pageOf(t, request, recipes, []bson.A{{4.5}, {4.0}}, 2).NextCursor,
+	}
+	for name, cursor := range cursors {
+		request.Cursor = cursor
+		if _, err := db.NewRecipePager(request); err != db.ErrInvalidCursor {
+			t.Fatalf("Expected %s to be an invalid cursor but got %v", name, err)
+		}
+	}
+}
+
+func TestRecipePageCursors(t *testing.T) {
+	var recipes []models.Recipe
+	var values []bson.A
+	for i := 0; i < 3; i++ {
+		recipes = append(recipes, models.Recipe{RecipeID: primitive.NewObjectID()})
+		values = append(values, bson.A{i})
+	}
+	sort := []models.RecipeSort{{Field: "calories"}}
+	// startsAfter is the cursor stage of a page that goes on from a recipe, or back from it
+	startsAfter := func(index int, operator string) bson.M {
+		return bson.M{"$expr": bson.M{"$or": bson.A{
+			bson.M{"$and": bson.A{bson.M{operator: bson.A{"$sortkey0", bson.M{"$literal": int32(index)}}}}},
+			bson.M{"$and": bson.A{
+				bson.M{"$eq": bson.A{"$sortkey0", bson.M{"$literal": int32(index)}}},
+				bson.M{operator: bson.A{"$_id", recipes[index].RecipeID}},
+			}},
+		}}}
+	}
+
+	firstRequest := models.PaginatedRecipeRequest{PageSize: 2, Sort: sort}
+	first := pageOf(t, firstRequest, recipes, values, 3)
+	if len(first.Recipes) != 2 || first.NextCursor == "" || first.PrevCursor != "" {
+		t.Fatalf("The first page should only lead to the next one %+v", first)
+	}
+	lastRequest := models.PaginatedRecipeRequest{PageSize: 2, Sort: sort, Cursor: first.NextCursor}
+	if next := cursorMatch(t, lastRequest); !reflect.DeepEqual(next, startsAfter(1, "$gt")) {
+		t.Fatalf("Expected the next page to continue after the last recipe but got %v", next)
+	}
+
+	last := pageOf(t, lastRequest, recipes[2:], values[2:], 1)
+	if len(last.Recipes) != 1 || last.NextCursor != "" || last.PrevCursor == "" {
+		t.Fatalf("The last page should only lead to the previous one %+v", last)
+	}
+	backRequest := models.PaginatedRecipeRequest{PageSize: 2, Sort: sort, Cursor: last.PrevCursor}
+	if prev := cursorMatch(t, backRequest); !reflect.DeepEqual(prev, startsAfter(2, "$lt")) {
+		t.Fatalf("Expected the previous page to go back from the first recipe but got %v", prev)
+	}
+
+	skipped := pageOf(t, models.PaginatedRecipeRequest{PageSize: 2, PageCount: 1, Sort: sort}, recipes, values, 1)
+	if skipped.NextCursor != "" || skipped.PrevCursor == "" {
+		t.Fatalf("A page found by skipping should lead back %+v", skipped)
+	}
+
+	// paging backwards finds the recipes in reverse, one past the page means there are more before it
+	reversed := []models.Recipe{recipes[1], recipes[0]}
+	back := pageOf(t, backRequest, append(reversed, models.Recipe{RecipeID: primitive.NewObjectID()}), []bson.A{{1}, {0}, {-1}}, 3)
+	if back.Recipes[0].RecipeID != recipes[0].RecipeID || back.NextCursor == "" || back.PrevCursor == "" {
+		t.Fatalf("Expected a page in order with both cursors %+v", back)
+	}
+	start := pageOf(t, backRequest, reversed, []bson.A{{1}, {0}}, 2)
+	if start.NextCursor == "" || start.PrevCursor != "" {
+		t.Fatalf("Going back to the first page should only lead to the next one %+v", start)
+	}
+
+	if all := pageOf(t, models.PaginatedRecipeRequest{Sort: sort}, recipes, values, 3); all.NextCursor != "" || all.PrevCursor != "" {
+		t.Fatalf("Every recipe on one page has no other pages %+v", all)
+	}
+}
+
+func TestRecipeFilterTotalTime(t *testing.T) {
+	filters := db.RecipeFilter(models.PaginatedRecipeRequest{Filter: models.RecipeFilter{MaxTotalTime: 30}})["$and"].(bson.A)
+	// a missing time would make the sum null, which is less than any time
+	totalTime := bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$preptime", 0}}, bson.M{"$ifNull": bson.A{"$cooktime", 0}}}}
+	expected := bson.M{"$expr": bson.M{"$lte": bson.A{totalTime, 30}}}
+	if !reflect.DeepEqual(filters[len(filters)-1], expected) {
+		t.Fatalf("Expected %v but got %v", expected, filters[len(filters)-1])
+	}
+}
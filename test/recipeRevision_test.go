@@ -0,0 +1,376 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"server/controller"
+	"server/db"
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type mockRecipeStore struct {
+	db.RecipeDB
+	recipe *models.Recipe
+}
+
+func (m mockRecipeStore) GetRecipe(recipeID string) (models.Recipe, error) {
+	return *m.recipe, nil
+}
+
+func (m mockRecipeStore) UpdateRecipe(recipeID string, updatedRecipe models.Recipe) (models.Recipe, error) {
+	updatedRecipe.Version++
+	*m.recipe = updatedRecipe
+	return updatedRecipe, nil
+}
+
+type mockRevisionDB struct {
+	revisions *[]models.RecipeRevision
+}
+
+func (m mockRevisionDB) GetRevisions(recipeID string) ([]models.RecipeRevision, error) {
+	return *m.revisions, nil
+}
+
+func (m mockRevisionDB) GetRevision(recipeID string, number int) (models.RecipeRevision, error) {
+	for _, revision := range *m.revisions {
+		if revision.Number == number {
+			return revision, nil
+		}
+	}
+	return models.RecipeRevision{}, errors.New("no revision")
+}
+
+func (m mockRevisionDB) CreateRevision(revision *models.RecipeRevision) error {
+	revision.Number = len(*m.revisions) + 1
+	*m.revisions = append(*m.revisions, *revision)
+	return nil
+}
+
+func (m mockRevisionDB) CreateFirstRevision(revision *models.RecipeRevision) error {
+	for _, saved := range *m.revisions {
+		if saved.Number == 1 {
+			return nil
+		}
+	}
+	revision.Number = 1
+	*m.revisions = append(*m.revisions, *revision)
+	return nil
+}
+
+func (m mockRevisionDB) DeleteRevision(recipeID string, number int) error {
+	kept := []models.RecipeRevision{}
+	for _, revision := range *m.revisions {
+		if revision.Number != number {
+			kept = append(kept, revision)
+		}
+	}
+	*m.revisions = kept
+	return nil
+}
+
+func (m mockRevisionDB) DeleteRevisions(recipeID string) error {
+	*m.revisions = nil
+	return nil
+}
+
+func TestUpdateRecipeKeepsRevisions(t *testing.T) {
+	id := primitive.NewObjectID()
+	stored := models.Recipe{
+		RecipeID:   id,
+		RecipeName: "Pancakes",
+		UserName:   "chef",
+		Servings:   2,
+		Tags:       []string{"breakfast"},
+		Ingredients: []models.Ingredient{
+			{Name: "flour", Amount: 1, Measurement: "cup"},
+			{Name: "milk", Amount: 1, Measurement: "cup"},
+		},
+		Steps: []models.Step{{Number: 1, Text: "Mix."}, {Number: 2, Text: "Fry."}},
+	}
+	var revisions []models.RecipeRevision
+	rc := controller.NewRecipeController(mockRecipeStore{recipe: &stored}, mockRevisionDB{revisions: &revisions}, mockIngredientCatalog{})
+
+	updated := stored
+	updated.Servings = 4
+	updated.Ingredients = []models.Ingredient{
+		{Name: "Flour", Amount: 2, Measurement: "cup"},
+		{Name: "eggs", Amount: 2},
+	}
+	updated.Steps = []models.Step{{Number: 1, Text: "Whisk."}}
+	if _, _, err := rc.UpdateRecipe(id.Hex(), updated, "roommate"); err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].UserName != "chef" || revisions[1].UserName != "roommate" {
+		t.Fatalf("Expected the original and the update as revisions but got %+v", revisions)
+	}
+
+	diff, err := rc.DiffRevisions(id.Hex(), 1, 2, models.Viewer{UserName: "chef"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []models.FieldChange{
+		{Field: "servings", Change: "changed", From: 2, To: 4},
+		{Field: "ingredients[flour].amount", Change: "changed", From: float32(1), To: float32(2)},
+		{Field: "ingredients[milk]", Change: "removed", From: models.Ingredient{Name: "milk", Amount: 1, Measurement: "cup"}},
+		{Field: "ingredients[eggs]", Change: "added", To: models.Ingredient{Name: "eggs", Amount: 2}},
+		{Field: "steps[1]", Change: "changed", From: "Mix.", To: "Whisk."},
+		{Field: "steps[2]", Change: "removed", From: "Fry."},
+	}
+	if !reflect.DeepEqual(diff.Changes, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, diff.Changes)
+	}
+
+	reverted, err := rc.RevertRecipe(id.Hex(), 1, stored.Version, "chef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverted.Servings != 2 || len(reverted.Ingredients) != 2 || len(revisions) != 3 {
+		t.Fatalf("Expected the first revision back as a new revision but got %+v", reverted)
+	}
+	if _, err := rc.RevertRecipe(id.Hex(), 9, stored.Version, "chef"); !errors.Is(err, controller.ErrRevisionNotFound) {
+		t.Fatalf("Expected ErrRevisionNotFound but got %v", err)
+	}
+}
+
+type mockConflictingRecipeStore struct {
+	mockRecipeStore
+}
+
+func (m mockConflictingRecipeStore) UpdateRecipe(recipeID string, updatedRecipe models.Recipe) (models.Recipe, error) {
+	return models.Recipe{}, db.ErrVersionConflict
+}
+
+func TestUpdateRecipeConflict(t *testing.T) {
+	id := primitive.NewObjectID()
+	current := models.Recipe{RecipeID: id, RecipeName: "Pancakes", Version: 7}
+	revisions := []models.RecipeRevision{{Number: 1}}
+	rc := controller.NewRecipeController(mockConflictingRecipeStore{mockRecipeStore{recipe: &current}}, mockRevisionDB{revisions: &revisions}, mockIngredientCatalog{})
+	waffles := models.Recipe{
+		RecipeName:  "Waffles",
+		Servings:    2,
+		Ingredients: []models.Ingredient{{Name: "flour", Amount: 1, Measurement: "cup"}},
+		Steps:       []models.Step{{Number: 1, Text: "Mix."}},
+		Version:     6,
+	}
+	recipe, _, err := rc.UpdateRecipe(id.Hex(), waffles, "chef")
+	if !errors.Is(err, db.ErrVersionConflict) || recipe.Version != 7 || recipe.RecipeName != "Pancakes" {
+		t.Fatalf("Expected a conflict with the current recipe but got %v %+v", err, recipe)
+	}
+	if len(revisions) != 1 {
+		t.Fatal("A conflicting update should not be kept as a revision")
+	}
+
+	// an update that loses the race after its revision was recorded takes the revision back out
+	waffles.Version = 7
+	if _, _, err := rc.UpdateRecipe(id.Hex(), waffles, "chef"); !errors.Is(err, db.ErrVersionConflict) {
+		t.Fatalf("Expected a conflict but got %v", err)
+	}
+	if len(revisions) != 1 {
+		t.Fatalf("A conflicting update should not be kept as a revision %+v", revisions)
+	}
+}
+
+type mockFailingRevisionDB struct {
+	mockRevisionDB
+}
+
+func (m mockFailingRevisionDB) CreateRevision(revision *models.RecipeRevision) error {
+	return errors.New("revisions are down")
+}
+
+func TestUpdateRecipeNeedsRevision(t *testing.T) {
+	id := primitive.NewObjectID()
+	stored := models.Recipe{
+		RecipeID:    id,
+		RecipeName:  "Pancakes",
+		Servings:    2,
+		Ingredients: []models.Ingredient{{Name: "flour", Amount: 1, Measurement: "cup"}},
+		Steps:       []models.Step{{Number: 1, Text: "Mix."}},
+		Version:     3,
+	}
+	revisions := []models.RecipeRevision{{Number: 1}}
+	rc := controller.NewRecipeController(mockRecipeStore{recipe: &stored}, mockFailingRevisionDB{mockRevisionDB{revisions: &revisions}}, mockIngredientCatalog{})
+
+	updated := stored
+	updated.RecipeName = "Waffles"
+	if _, _, err := rc.UpdateRecipe(id.Hex(), updated, "chef"); err == nil {
+		t.Fatal("Expected the update to fail without its revision")
+	}
+	if stored.RecipeName != "Pancakes" || stored.Version != 3 {
+		t.Fatalf("A recipe should not change without a revision %+v", stored)
+	}
+}
+
+func TestUpdateRecipeKeepsFirstRevision(t *testing.T) {
+	id := primitive.NewObjectID()
+	stored := models.Recipe{
+		RecipeID:        id,
+		RecipeName:      "Pancakes",
+		UserName:        "chef",
+		LastUpdatedDate: "2020.05.06 07:00:00",
+		Servings:        2,
+		Ingredients:     []models.Ingredient{{Name: "flour", Amount: 1, Measurement: "cup"}},
+		Steps:           []models.Step{{Number: 1, Text: "Mix."}},
+	}
+	var revisions []models.RecipeRevision
+	rc := controller.NewRecipeController(mockRecipeStore{recipe: &stored}, mockRevisionDB{revisions: &revisions}, mockIngredientCatalog{})
+
+	stale := stored
+	stale.Version = 4
+	if _, _, err := rc.UpdateRecipe(id.Hex(), stale, "roommate"); !errors.Is(err, db.ErrVersionConflict) || len(revisions) != 0 {
+		t.Fatalf("A conflicting update should not record revisions %v %+v", err, revisions)
+	}
+
+	updated := stored
+	updated.Servings = 4
+	if _, _, err := rc.UpdateRecipe(id.Hex(), updated, "roommate"); err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Number != 1 || revisions[0].Recipe.Servings != 2 || revisions[0].CreatedDate != "2020.05.06 07:00:00" {
+		t.Fatalf("Expected the recipe from before revisions were kept as the first revision %+v", revisions)
+	}
+	if revisions[1].Number != 2 || revisions[1].Recipe.Servings != 4 || revisions[1].Recipe.Version != 1 {
+		t.Fatalf("Expected the update as the second revision %+v", revisions[1])
+	}
+}
+
+func TestRevertRecipeConflict(t *testing.T) {
+	id := primitive.NewObjectID()
+	current := models.Recipe{RecipeID: id, RecipeName: "Waffles", Version: 7}
+	original := models.Recipe{
+		RecipeID:    id,
+		RecipeName:  "Pancakes",
+		Servings:    2,
+		Ingredients: []models.Ingredient{{Name: "flour", Amount: 1, Measurement: "cup"}},
+		Steps:       []models.Step{{Number: 1, Text: "Mix."}},
+		Version:     1,
+	}
+	revisions := []models.RecipeRevision{{Number: 1, Recipe: original}}
+	rc := controller.NewRecipeController(mockConflictingRecipeStore{mockRecipeStore{recipe: &current}}, mockRevisionDB{revisions: &revisions}, mockIngredientCatalog{})
+	recipe, err := rc.RevertRecipe(id.Hex(), 1, 6, "chef")
+	if !errors.Is(err, db.ErrVersionConflict) || recipe.Version != 7 || recipe.RecipeName != "Waffles" {
+		t.Fatalf("Expected a conflict with the current recipe but got %v %+v", err, recipe)
+	}
+}
+
+func TestUpdateRecipeKeepsOwner(t *testing.T) {
+	id := primitive.NewObjectID()
+	stored := models.Recipe{
+		RecipeID:    id,
+		RecipeName:  "Pancakes",
+		UserName:    "chef",
+		CreatedDate: "2021.01.02 08:00:00",
+		ForkedFrom:  &models.RecipeReference{RecipeID: primitive.NewObjectID(), RecipeName: "Crepes", UserName: "nonna"},
+		Servings:    2,
+		Ingredients: []models.Ingredient{{Name: "flour", Amount: 1, Measurement: "cup"}},
+		Steps:       []models.Step{{Number: 1, Text: "Mix."}},
+	}
+	revisions := []models.RecipeRevision{{Number: 1}}
+	rc := controller.NewRecipeController(mockRecipeStore{recipe: &stored}, mockRevisionDB{revisions: &revisions}, mockIngredientCatalog{})
+
+	updated := models.Recipe{
+		RecipeName:  "Waffles",
+		Servings:    2,
+		Ingredients: stored.Ingredients,
+		Steps:       stored.Steps,
+	}
+	recipe, _, err := rc.UpdateRecipe(id.Hex(), updated, "chef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipe.UserName != "chef" || recipe.CreatedDate != "2021.01.02 08:00:00" || stored.UserName != "chef" {
+		t.Fatalf("A replacement without the owner should keep it but got %q %q", recipe.UserName, recipe.CreatedDate)
+	}
+	if recipe.ForkedFrom == nil || recipe.ForkedFrom.UserName != "nonna" {
+		t.Fatalf("A replacement should keep where the recipe was forked from but got %+v", recipe.ForkedFrom)
+	}
+
+	updated.UserName = "roommate"
+	updated.CreatedDate = "2020.01.01 00:00:00"
+	updated.Version = stored.Version
+	recipe, _, err = rc.UpdateRecipe(id.Hex(), updated, "chef")
+	if err != nil || recipe.UserName != "chef" || recipe.CreatedDate != "2021.01.02 08:00:00" {
+		t.Fatalf("A replacement should not hand the recipe to another user %v %+v", err, recipe)
+	}
+}
+
+func TestUpdateRecipeKeepsVisibility(t *testing.T) {
+	id := primitive.NewObjectID()
+	stored := models.Recipe{
+		RecipeID:    id,
+		RecipeName:  "Pancakes",
+		UserName:    "chef",
+		Visibility:  models.VisibilityHousehold,
+		Servings:    2,
+		Ingredients: []models.Ingredient{{Name: "flour", Amount: 1, Measurement: "cup"}},
+		Steps:       []models.Step{{Number: 1, Text: "Mix."}},
+	}
+	revisions := []models.RecipeRevision{{Number: 1}}
+	rc := controller.NewRecipeController(mockRecipeStore{recipe: &stored}, mockRevisionDB{revisions: &revisions}, mockIngredientCatalog{})
+
+	updated := stored
+	updated.Visibility = ""
+	updated.Private = false
+	recipe, _, err := rc.UpdateRecipe(id.Hex(), updated, "chef")
+	if err != nil || recipe.Visibility != models.VisibilityHousehold || recipe.Private {
+		t.Fatalf("An update without a visibility should keep the recipe's %v %+v", err, recipe)
+	}
+
+	updated.Version = stored.Version
+	updated.Private = true
+	recipe, _, err = rc.UpdateRecipe(id.Hex(), updated, "chef")
+	if err != nil || recipe.Visibility != models.VisibilityPrivate || !recipe.Private {
+		t.Fatalf("An update marked private should make the recipe private %v %+v", err, recipe)
+	}
+
+	updated.Version = stored.Version
+	updated.Private = false
+	updated.Visibility = models.VisibilityPublic
+	recipe, _, err = rc.UpdateRecipe(id.Hex(), updated, "chef")
+	if err != nil || recipe.Visibility != models.VisibilityPublic || recipe.Private {
+		t.Fatalf("An update with a visibility should use it %v %+v", err, recipe)
+	}
+}
+
+func TestUpdateRecipeNormalizesIngredients(t *testing.T) {
+	id := primitive.NewObjectID()
+	stored := models.Recipe{
+		RecipeID:    id,
+		RecipeName:  "Omelette",
+		Ingredients: []models.Ingredient{{Name: "eggs", Amount: 2, Measurement: "large"}},
+		Steps:       []models.Step{{Number: 1, Text: "Whisk."}},
+	}
+	revisions := []models.RecipeRevision{{Number: 1}}
+	rc := controller.NewRecipeController(mockRecipeStore{recipe: &stored}, mockRevisionDB{revisions: &revisions}, mockIngredientCatalog{})
+
+	updated := stored
+	updated.Ingredients = []models.Ingredient{
+		{Name: "eggs", Quantity: "1 1/2", Measurement: "large"},
+		{Name: "butter", Amount: 1, Measurement: "Tablespoons"},
+	}
+	recipe, _, err := rc.UpdateRecipe(id.Hex(), updated, "chef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []models.Ingredient{
+		{Name: "eggs", Amount: 1.5, Measurement: "large"},
+		{Name: "butter", Amount: 1, Measurement: "tbsp"},
+	}
+	if !reflect.DeepEqual(recipe.Ingredients, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, recipe.Ingredients)
+	}
+	if updated.Ingredients[1].Measurement != "Tablespoons" || updated.Ingredients[0].Quantity != "1 1/2" {
+		t.Fatalf("The update's own ingredients should not be changed %+v", updated.Ingredients)
+	}
+
+	// measurements the recipe wasn't saved with still have to be units
+	updated.Version = stored.Version
+	updated.Ingredients = append(updated.Ingredients, models.Ingredient{Name: "chives", Amount: 1, Measurement: "armful"})
+	_, invalidFields, err := rc.UpdateRecipe(id.Hex(), updated, "chef")
+	if err == nil || !reflect.DeepEqual(invalidFields, []string{"ingredients[2].measurement"}) {
+		t.Fatalf("Expected the new measurement to be refused but got %v %v", err, invalidFields)
+	}
+}
@@ -0,0 +1,85 @@
+package test
+
+import (
+	"errors"
+	"server/controller"
+	"server/db"
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// mockReviewDB keeps one review per user like the unique index does
+type mockReviewDB struct {
+	db.ReviewDB
+	reviews map[string]models.Review
+}
+
+func (m mockReviewDB) SaveReview(review models.Review) (models.Review, error) {
+	m.reviews[review.UserName] = review
+	return review, nil
+}
+
+func (m mockReviewDB) GetRatingSummary(recipeID string) (float64, int, error) {
+	total := 0
+	for _, review := range m.reviews {
+		total += review.Rating
+	}
+	if len(m.reviews) == 0 {
+		return 0, 0, nil
+	}
+	return float64(total) / float64(len(m.reviews)), len(m.reviews), nil
+}
+
+type mockRatedRecipe struct {
+	mockRecipeGetter
+	rated *models.Recipe
+}
+
+func (m mockRatedRecipe) UpdateRating(recipeID string, averageRating float64, ratingCount int) error {
+	m.rated.AverageRating = averageRating
+	m.rated.RatingCount = ratingCount
+	return nil
+}
+
+func TestSaveReviewUpdatesRating(t *testing.T) {
+	recipe := models.Recipe{RecipeID: primitive.NewObjectID(), UserName: "chef", Visibility: models.VisibilityPublic}
+	var rated models.Recipe
+	reviews := mockReviewDB{reviews: map[string]models.Review{}}
+	rc := controller.NewReviewController(reviews, mockRatedRecipe{mockRecipeGetter{recipe: recipe}, &rated})
+
+	for _, review := range []struct {
+		userName string
+		rating   int
+	}{{"sam", 5}, {"alex", 4}, {"kim", 4}, {"sam", 2}} {
+		saved, err := rc.SaveReview(recipe.RecipeID.Hex(), models.Review{Rating: review.rating, Text: " Tasty "}, models.Viewer{UserName: review.userName})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.UserName != review.userName || saved.RecipeID != recipe.RecipeID || saved.Text != "Tasty" {
+			t.Fatalf("Review was not saved for the viewer %+v", saved)
+		}
+	}
+	if rated.RatingCount != 3 || rated.AverageRating != 3.33 {
+		t.Fatalf("Expected 3 ratings averaging 3.33 but got %d averaging %v", rated.RatingCount, rated.AverageRating)
+	}
+}
+
+func TestSaveReviewValidation(t *testing.T) {
+	recipe := models.Recipe{RecipeID: primitive.NewObjectID(), UserName: "chef", Visibility: models.VisibilityPrivate}
+	reviews := mockReviewDB{reviews: map[string]models.Review{}}
+	rc := controller.NewReviewController(reviews, mockRecipeGetter{recipe: recipe})
+
+	for _, rating := range []int{0, 6} {
+		if _, err := rc.SaveReview(recipe.RecipeID.Hex(), models.Review{Rating: rating}, models.Viewer{UserName: "chef"}); !errors.Is(err, controller.ErrInvalidRating) {
+			t.Errorf("Expected a rating of %d to be invalid but got %v", rating, err)
+		}
+	}
+	if _, err := rc.SaveReview(recipe.RecipeID.Hex(), models.Review{Rating: 5}, models.Viewer{UserName: "stranger"}); !errors.Is(err, controller.ErrRecipeNotFound) {
+		t.Errorf("A recipe the viewer can't see should not be reviewed, got %v", err)
+	}
+	if len(reviews.reviews) != 0 {
+		t.Fatal("Invalid reviews should not be saved")
+	}
+}
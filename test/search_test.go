@@ -0,0 +1,56 @@
+package test
+
+import (
+	"reflect"
+	"server/search"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	query := search.ParseQuery(`Chicken "sour  cream" -mushrooms (spicy!) low-fat`)
+	expected := search.Query{
+		Terms:    []string{"chicken", "spicy", "low", "fat"},
+		Phrases:  []string{"sour cream"},
+		Excluded: []string{"mushrooms"},
+	}
+	if !reflect.DeepEqual(query, expected) {
+		t.Fatalf("Expected %v but got %v", expected, query)
+	}
+	if query.String() != `"sour cream" chicken spicy low fat -mushrooms` {
+		t.Fatalf("Unexpected text search %q", query.String())
+	}
+}
+
+func TestParseQueryOnlyExclusions(t *testing.T) {
+	if !search.ParseQuery("-onion").IsEmpty() {
+		t.Fatal("A query with only exclusions should be empty")
+	}
+	if !search.ParseQuery(`.* "" $`).IsEmpty() {
+		t.Fatal("A query with only special characters should be empty")
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	snippet, ok := search.Highlight("Add the Onions & sour cream, then stir", search.ParseQuery(`onion "sour cream"`))
+	if !ok {
+		t.Fatal("Expected a match")
+	}
+	expected := "Add the <mark>Onions</mark> &amp; <mark>sour cream</mark>, then stir"
+	if snippet != expected {
+		t.Fatalf("Expected %q but got %q", expected, snippet)
+	}
+
+	if _, ok := search.Highlight("Add the onions", search.ParseQuery("garlic")); ok {
+		t.Fatal("Expected no match")
+	}
+}
+
+func TestHighlightTrimsLongText(t *testing.T) {
+	text := "Preheat the oven and line a baking sheet with parchment paper while you prepare everything else. " +
+		"Whisk the eggs until pale. Fold in the flour gently so the batter stays light and airy for the best texture possible."
+	snippet, _ := search.Highlight(text, search.ParseQuery("eggs"))
+	expected := "…prepare everything else. Whisk the <mark>eggs</mark> until pale. Fold in the flour gently so the batter stays light…"
+	if snippet != expected {
+		t.Fatalf("Expected %q but got %q", expected, snippet)
+	}
+}
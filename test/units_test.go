@@ -0,0 +1,123 @@
+package test
+
+import (
+	"errors"
+	"math"
+	"server/units"
+	"testing"
+)
+
+func closeTo(a float64, b float64) bool {
+	return math.Abs(a-b) < 0.001
+}
+
+func TestParseAmount(t *testing.T) {
+	cases := map[string]float64{
+		"2":     2,
+		"0.75":  0.75,
+		"3/4":   0.75,
+		"1 1/2": 1.5,
+		"1-1/2": 1.5,
+		"1½":    1.5,
+		"⅓":     1.0 / 3.0,
+	}
+	for text, expected := range cases {
+		amount, err := units.ParseAmount(text)
+		if err != nil {
+			t.Fatalf("Could not parse %q: %s", text, err)
+		}
+		if !closeTo(amount, expected) {
+			t.Fatalf("Expected %q to be %f but got %f", text, expected, amount)
+		}
+	}
+}
+
+func TestParseInvalidAmount(t *testing.T) {
+	for _, text := range []string{"", "a few", "1/0", "-2", "1/2 1/4", "1 2 3"} {
+		if _, err := units.ParseAmount(text); !errors.Is(err, units.ErrInvalidAmount) {
+			t.Fatalf("Expected %q to be an invalid amount", text)
+		}
+	}
+}
+
+func TestLookupUnit(t *testing.T) {
+	cases := map[string]string{
+		"Tablespoons": "tbsp",
+		"T":           "tbsp",
+		"t":           "tsp",
+		"lbs.":        "lb",
+		"Cups":        "cup",
+		"":            "",
+		"whole":       "",
+		"Cloves":      "clove",
+		"to taste":    "to taste",
+	}
+	for measurement, expected := range cases {
+		unit, err := units.Lookup(measurement)
+		if err != nil {
+			t.Fatalf("Could not look up %q: %s", measurement, err)
+		}
+		if unit.Name != expected {
+			t.Fatalf("Expected %q to be %q but got %q", measurement, expected, unit.Name)
+		}
+	}
+
+	if _, err := units.Lookup("smidgeon"); !errors.Is(err, units.ErrUnknownUnit) {
+		t.Fatal("Unknown unit did not return ErrUnknownUnit")
+	}
+}
+
+func TestAddQuantities(t *testing.T) {
+	sum, err := units.Add(units.Quantity{Amount: 0.25, Unit: units.Cup}, units.Quantity{Amount: 2, Unit: units.Tablespoon})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Unit.Name != "cup" || !closeTo(sum.Amount, 0.375) {
+		t.Fatalf("Expected 0.375 cup but got %f %s", sum.Amount, sum.Unit.Name)
+	}
+
+	if _, err := units.Add(units.Quantity{Amount: 1, Unit: units.Cup}, units.Quantity{Amount: 1, Unit: units.Gram}); !errors.Is(err, units.ErrIncompatibleUnit) {
+		t.Fatal("Adding volume to mass should fail")
+	}
+	if _, err := units.Add(units.Quantity{Amount: 1, Unit: units.Can}, units.Quantity{Amount: 1, Unit: units.Clove}); !errors.Is(err, units.ErrIncompatibleUnit) {
+		t.Fatal("Adding different counts should fail")
+	}
+}
+
+func TestToSystem(t *testing.T) {
+	metric, err := units.ToSystem(units.Quantity{Amount: 2, Unit: units.Cup}, units.Metric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metric.Unit.Name != "ml" || !closeTo(metric.Amount, 473.176) {
+		t.Fatalf("Expected 473.176 ml but got %f %s", metric.Amount, metric.Unit.Name)
+	}
+
+	us, err := units.ToSystem(units.Quantity{Amount: 1, Unit: units.Kilogram}, units.USCustomary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if us.Unit.Name != "lb" || !closeTo(us.Amount, 2.2046) {
+		t.Fatalf("Expected 2.2046 lb but got %f %s", us.Amount, us.Unit.Name)
+	}
+}
+
+func TestFormatIngredient(t *testing.T) {
+	cases := []struct {
+		amount      float64
+		measurement string
+		name        string
+		expected    string
+	}{
+		{1.5, "cup", "flour", "1 1/2 cup flour"},
+		{2, "", "eggs", "2 eggs"},
+		{0, "pinch", "nutmeg", "pinch nutmeg"},
+		{0, "", "bay leaf", "bay leaf"},
+		{0, "to taste", "salt", "salt, to taste"},
+	}
+	for _, c := range cases {
+		if formatted := units.FormatIngredient(c.amount, c.measurement, c.name); formatted != c.expected {
+			t.Fatalf("Expected %q but got %q", c.expected, formatted)
+		}
+	}
+}
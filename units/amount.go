@@ -0,0 +1,65 @@
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+var vulgarFractions = strings.NewReplacer(
+	"½", " 1/2", "⅓", " 1/3", "⅔", " 2/3", "¼", " 1/4", "¾", " 3/4",
+	"⅕", " 1/5", "⅖", " 2/5", "⅗", " 3/5", "⅘", " 4/5", "⅙", " 1/6",
+	"⅚", " 5/6", "⅛", " 1/8", "⅜", " 3/8", "⅝", " 5/8", "⅞", " 7/8",
+	"⁄", "/",
+)
+
+// ParseAmount reads an amount written the way recipes write them: "2", "0.75", "3/4",
+// "1 1/2", "1-1/2" or with unicode fractions like "1½"
+func ParseAmount(text string) (float64, error) {
+	normalized := vulgarFractions.Replace(strings.TrimSpace(text))
+	parts := strings.Fields(normalized)
+	if len(parts) == 1 && strings.Count(parts[0], "-") == 1 && strings.Contains(parts[0], "/") {
+		parts = strings.Split(parts[0], "-")
+	}
+	if len(parts) == 0 || len(parts) > 2 {
+		return 0, fmt.Errorf("%w %q", ErrInvalidAmount, text)
+	}
+
+	total := 0.0
+	for i, part := range parts {
+		value, err := parseNumber(part)
+		if err != nil {
+			return 0, fmt.Errorf("%w %q", ErrInvalidAmount, text)
+		}
+		// only the last part of a mixed number may be a fraction, "1/2 1/4" is not an amount
+		if i == 0 && len(parts) == 2 && strings.Contains(part, "/") {
+			return 0, fmt.Errorf("%w %q", ErrInvalidAmount, text)
+		}
+		total += value
+	}
+	return total, nil
+}
+
+func parseNumber(text string) (float64, error) {
+	if !strings.Contains(text, "/") {
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil || value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+			return 0, ErrInvalidAmount
+		}
+		return value, nil
+	}
+	fraction := strings.Split(text, "/")
+	if len(fraction) != 2 {
+		return 0, ErrInvalidAmount
+	}
+	numerator, numErr := strconv.Atoi(fraction[0])
+	denominator, denErr := strconv.Atoi(fraction[1])
+	if numErr != nil || denErr != nil || numerator < 0 || denominator <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	return float64(numerator) / float64(denominator), nil
+}
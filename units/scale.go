@@ -0,0 +1,106 @@
+package units
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// cookFractions are the fractional parts a cook can actually measure out
+var cookFractions = []float64{0, 1.0 / 8, 1.0 / 4, 1.0 / 3, 3.0 / 8, 1.0 / 2, 5.0 / 8, 2.0 / 3, 3.0 / 4, 7.0 / 8, 1}
+
+var fractionNames = map[float64]string{
+	1.0 / 8: "1/8", 1.0 / 4: "1/4", 1.0 / 3: "1/3", 3.0 / 8: "3/8", 1.0 / 2: "1/2",
+	5.0 / 8: "5/8", 2.0 / 3: "2/3", 3.0 / 4: "3/4", 7.0 / 8: "7/8",
+}
+
+// Scale multiplies a quantity, moves it to the most readable unit of its system and rounds it
+// so 48 tsp becomes 1 cup. "To taste" amounts are left alone.
+func Scale(q Quantity, factor float64) Quantity {
+	if q.Unit.Kind == ToTaste {
+		return q
+	}
+	q.Amount *= factor
+	return Round(Simplify(q))
+}
+
+// Simplify expresses a volume or mass in the most readable unit of the system it is already in.
+// Amounts too small to measure in that unit, like a pinch, keep their own unit.
+func Simplify(q Quantity) Quantity {
+	simplified, err := ToSystem(q, q.Unit.System)
+	if err != nil || simplified.Amount < cookFractions[1] {
+		return q
+	}
+	return simplified
+}
+
+// Round rounds an amount to something that can be measured out: whole grams and milliliters
+// (nearest 5 above 100) for metric, and eighths or thirds for everything else. Nothing
+// is ever rounded down to zero.
+func Round(q Quantity) Quantity {
+	if q.Amount <= 0 || q.Unit.Kind == ToTaste {
+		return q
+	}
+	rounded := q.Amount
+	switch {
+	case q.Unit.System == Metric && q.Unit.Factor == 1 && q.Amount >= 100:
+		rounded = math.Round(q.Amount/5) * 5
+	case q.Unit.System == Metric && q.Unit.Factor == 1:
+		rounded = math.Max(math.Round(q.Amount), 1)
+	case q.Unit.System == Metric:
+		rounded = math.Max(math.Round(q.Amount*10)/10, 0.1)
+	case q.Amount >= 10:
+		rounded = math.Round(q.Amount)
+	default:
+		rounded = roundToCookFraction(q.Amount)
+	}
+	return Quantity{Amount: rounded, Unit: q.Unit}
+}
+
+func roundToCookFraction(amount float64) float64 {
+	whole := math.Floor(amount)
+	remainder := amount - whole
+	nearest := cookFractions[0]
+	for _, fraction := range cookFractions {
+		if math.Abs(remainder-fraction) < math.Abs(remainder-nearest) {
+			nearest = fraction
+		}
+	}
+	if whole == 0 && nearest == 0 {
+		nearest = cookFractions[1]
+	}
+	return whole + nearest
+}
+
+// FormatAmount writes an amount the way a recipe would, "1 1/2" rather than "1.5", falling back
+// to a short decimal when the amount is not a cook friendly fraction
+func FormatAmount(amount float64) string {
+	whole := math.Floor(amount)
+	remainder := amount - whole
+	for fraction, name := range fractionNames {
+		if math.Abs(remainder-fraction) < 0.005 {
+			if whole == 0 {
+				return name
+			}
+			return strconv.Itoa(int(whole)) + " " + name
+		}
+	}
+	formatted := strconv.FormatFloat(amount, 'f', 2, 64)
+	return strings.TrimSuffix(strings.TrimRight(formatted, "0"), ".")
+}
+
+// FormatIngredient writes an amount of an ingredient the way a recipe lists it, like "1 1/2 cup flour" or
+// "salt, to taste". An amount of 0 is left out.
+func FormatIngredient(amount float64, measurement string, name string) string {
+	if measurement == Taste.Name {
+		return name + ", " + Taste.Name
+	}
+	var parts []string
+	if amount > 0 {
+		parts = append(parts, FormatAmount(amount))
+	}
+	if measurement != "" {
+		parts = append(parts, measurement)
+	}
+	return strings.Join(append(parts, name), " ")
+}
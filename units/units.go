@@ -0,0 +1,198 @@
+package units
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kind groups units that measure the same thing and can be converted into each other
+type Kind string
+
+const (
+	Volume  Kind = "volume"
+	Mass    Kind = "mass"
+	Count   Kind = "count"
+	ToTaste Kind = "toTaste"
+)
+
+// System is the measurement system a unit belongs to
+type System string
+
+const (
+	Metric      System = "metric"
+	USCustomary System = "us"
+	// Neither is used by counts and "to taste" amounts, which read the same in every system
+	Neither System = ""
+)
+
+var (
+	ErrUnknownUnit      = errors.New("unknown unit")
+	ErrIncompatibleUnit = errors.New("incompatible units")
+)
+
+// Unit is a canonical unit of measurement. Factor is how many of the kind's base unit
+// (milliliters for volume, grams for mass) there are in one of this unit.
+type Unit struct {
+	Name    string
+	Kind    Kind
+	System  System
+	Factor  float64
+	aliases []string
+}
+
+// Quantity is an amount of a unit
+type Quantity struct {
+	Amount float64
+	Unit   Unit
+}
+
+var (
+	Pinch      = Unit{Name: "pinch", Kind: Volume, System: USCustomary, Factor: 0.308057599609375, aliases: []string{"pinches"}}
+	Dash       = Unit{Name: "dash", Kind: Volume, System: USCustomary, Factor: 0.61611519921875, aliases: []string{"dashes"}}
+	Teaspoon   = Unit{Name: "tsp", Kind: Volume, System: USCustomary, Factor: 4.92892159375, aliases: []string{"teaspoon", "teaspoons", "tsps"}}
+	Tablespoon = Unit{Name: "tbsp", Kind: Volume, System: USCustomary, Factor: 14.78676478125, aliases: []string{"tablespoon", "tablespoons", "tbsps", "tbs", "tbl"}}
+	FluidOunce = Unit{Name: "fl oz", Kind: Volume, System: USCustomary, Factor: 29.5735295625, aliases: []string{"fluid ounce", "fluid ounces", "floz", "fl. oz"}}
+	Cup        = Unit{Name: "cup", Kind: Volume, System: USCustomary, Factor: 236.5882365, aliases: []string{"cups", "c"}}
+	Pint       = Unit{Name: "pint", Kind: Volume, System: USCustomary, Factor: 473.176473, aliases: []string{"pints", "pt"}}
+	Quart      = Unit{Name: "quart", Kind: Volume, System: USCustomary, Factor: 946.352946, aliases: []string{"quarts", "qt"}}
+	Gallon     = Unit{Name: "gallon", Kind: Volume, System: USCustomary, Factor: 3785.411784, aliases: []string{"gallons", "gal"}}
+	Milliliter = Unit{Name: "ml", Kind: Volume, System: Metric, Factor: 1, aliases: []string{"milliliter", "milliliters", "millilitre", "millilitres", "mls"}}
+	Liter      = Unit{Name: "l", Kind: Volume, System: Metric, Factor: 1000, aliases: []string{"liter", "liters", "litre", "litres"}}
+	Gram       = Unit{Name: "g", Kind: Mass, System: Metric, Factor: 1, aliases: []string{"gram", "grams", "gr"}}
+	Kilogram   = Unit{Name: "kg", Kind: Mass, System: Metric, Factor: 1000, aliases: []string{"kilogram", "kilograms", "kilo", "kilos", "kgs"}}
+	Ounce      = Unit{Name: "oz", Kind: Mass, System: USCustomary, Factor: 28.349523125, aliases: []string{"ounce", "ounces"}}
+	Pound      = Unit{Name: "lb", Kind: Mass, System: USCustomary, Factor: 453.59237, aliases: []string{"pound", "pounds", "lbs"}}
+	// Each is the unit of plain counts like "2 eggs", so its canonical name is empty
+	Each    = Unit{Name: "", Kind: Count, Factor: 1, aliases: []string{"each", "whole", "piece", "pieces", "pc", "pcs", "ea", "item", "items"}}
+	Clove   = Unit{Name: "clove", Kind: Count, Factor: 1, aliases: []string{"cloves"}}
+	Can     = Unit{Name: "can", Kind: Count, Factor: 1, aliases: []string{"cans", "tin", "tins"}}
+	Slice   = Unit{Name: "slice", Kind: Count, Factor: 1, aliases: []string{"slices"}}
+	Bunch   = Unit{Name: "bunch", Kind: Count, Factor: 1, aliases: []string{"bunches"}}
+	Package = Unit{Name: "package", Kind: Count, Factor: 1, aliases: []string{"packages", "pkg", "pkgs", "packet", "packets"}}
+	Stick   = Unit{Name: "stick", Kind: Count, Factor: 1, aliases: []string{"sticks"}}
+	Sprig   = Unit{Name: "sprig", Kind: Count, Factor: 1, aliases: []string{"sprigs"}}
+	Head    = Unit{Name: "head", Kind: Count, Factor: 1, aliases: []string{"heads"}}
+	Handful = Unit{Name: "handful", Kind: Count, Factor: 1, aliases: []string{"handfuls"}}
+	Taste   = Unit{Name: "to taste", Kind: ToTaste, Factor: 1, aliases: []string{"taste", "as needed", "as desired"}}
+)
+
+var registry = []Unit{
+	Pinch, Dash, Teaspoon, Tablespoon, FluidOunce, Cup, Pint, Quart, Gallon,
+	Milliliter, Liter,
+	Gram, Kilogram, Ounce, Pound,
+	Each, Clove, Can, Slice, Bunch, Package, Stick, Sprig, Head, Handful,
+	Taste,
+}
+
+// caseSensitiveAliases are the abbreviations that only differ from each other by case
+var caseSensitiveAliases = map[string]Unit{
+	"t": Teaspoon,
+	"T": Tablespoon,
+}
+
+var index = buildIndex()
+
+func buildIndex() map[string]Unit {
+	byName := map[string]Unit{}
+	for _, unit := range registry {
+		byName[unit.Name] = unit
+		for _, alias := range unit.aliases {
+			byName[alias] = unit
+		}
+	}
+	return byName
+}
+
+// Lookup finds the canonical unit for a free text measurement such as "Tablespoons" or "lbs.".
+// An empty measurement is a plain count.
+func Lookup(measurement string) (Unit, error) {
+	trimmed := strings.TrimSuffix(strings.TrimSpace(measurement), ".")
+	if unit, ok := caseSensitiveAliases[trimmed]; ok {
+		return unit, nil
+	}
+	key := strings.Join(strings.Fields(strings.ToLower(trimmed)), " ")
+	if unit, ok := index[key]; ok {
+		return unit, nil
+	}
+	if unit, ok := index[strings.TrimSuffix(key, "s")]; ok {
+		return unit, nil
+	}
+	return Unit{}, fmt.Errorf("%w %q", ErrUnknownUnit, measurement)
+}
+
+// Compatible reports whether amounts of the two units can be converted into each other
+func Compatible(a Unit, b Unit) bool {
+	if a.Name == b.Name {
+		return true
+	}
+	return a.Kind == b.Kind && (a.Kind == Volume || a.Kind == Mass)
+}
+
+// Convert expresses an amount of one unit in another unit of the same kind
+func Convert(amount float64, from Unit, to Unit) (float64, error) {
+	if !Compatible(from, to) {
+		return 0, fmt.Errorf("%w: cannot convert %q to %q", ErrIncompatibleUnit, from.Name, to.Name)
+	}
+	if from.Name == to.Name {
+		return amount, nil
+	}
+	return amount * from.Factor / to.Factor, nil
+}
+
+// To converts the quantity into another unit
+func (q Quantity) To(unit Unit) (Quantity, error) {
+	amount, err := Convert(q.Amount, q.Unit, unit)
+	if err != nil {
+		return Quantity{}, err
+	}
+	return Quantity{Amount: amount, Unit: unit}, nil
+}
+
+// Add sums two quantities, expressing the result in the unit of the first
+func Add(a Quantity, b Quantity) (Quantity, error) {
+	converted, err := b.To(a.Unit)
+	if err != nil {
+		return Quantity{}, err
+	}
+	return Quantity{Amount: a.Amount + converted.Amount, Unit: a.Unit}, nil
+}
+
+// ladders list the units used when picking the unit to express a quantity in, from smallest to
+// largest, along with the smallest amount of that unit worth switching to it for
+var ladders = map[Kind]map[System][]rung{
+	Volume: {
+		USCustomary: {{Teaspoon, 0}, {Tablespoon, 1}, {Cup, 0.25}, {Gallon, 1}},
+		Metric:      {{Milliliter, 0}, {Liter, 1}},
+	},
+	Mass: {
+		USCustomary: {{Ounce, 0}, {Pound, 1}},
+		Metric:      {{Gram, 0}, {Kilogram, 1}},
+	},
+}
+
+type rung struct {
+	unit    Unit
+	minimum float64
+}
+
+// ToSystem converts a quantity into the most readable unit of a measurement system, so
+// 500 g becomes about 1.1 lb and 2 cups becomes about 473 ml. Counts and "to taste"
+// amounts are returned unchanged.
+func ToSystem(q Quantity, system System) (Quantity, error) {
+	ladder, ok := ladders[q.Unit.Kind][system]
+	if !ok {
+		if q.Unit.Kind == Count || q.Unit.Kind == ToTaste {
+			return q, nil
+		}
+		return Quantity{}, fmt.Errorf("%w: no %s units in system %q", ErrIncompatibleUnit, q.Unit.Kind, system)
+	}
+	best := ladder[0].unit
+	for _, r := range ladder[1:] {
+		amount, _ := Convert(q.Amount, q.Unit, r.unit)
+		if amount >= r.minimum {
+			best = r.unit
+		}
+	}
+	return q.To(best)
+}